@@ -12,7 +12,12 @@ import (
 	"k8s.io/client-go/rest"
 
 	"github.com/appvia/gcp-operator/pkg/apis"
+	operatorconfig "github.com/appvia/gcp-operator/pkg/config"
 	"github.com/appvia/gcp-operator/pkg/controller"
+	"github.com/appvia/gcp-operator/pkg/indexes"
+	gcpmetrics "github.com/appvia/gcp-operator/pkg/metrics"
+	"github.com/appvia/gcp-operator/pkg/ownership"
+	"github.com/appvia/gcp-operator/pkg/webhook"
 	"github.com/appvia/gcp-operator/version"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
@@ -61,6 +66,10 @@ func main() {
 	pflag.BoolVar(&publishClasses, "publish-classes", true, "indicates the operator should publish it's classes and plans")
 	pflag.BoolVar(&publishCRDs, "publish-crds", true, "indicates the operator should register crds")
 
+	// Options for the controllers
+	options := operatorconfig.New()
+	options.AddFlags(pflag.CommandLine)
+
 	pflag.Parse()
 
 	// Use a zap logr.Logger implementation. If none of the zap
@@ -75,6 +84,17 @@ func main() {
 
 	printVersion()
 
+	if err := ownership.ValidateClusterID(options.ClusterID); err != nil {
+		log.Error(err, "invalid cluster ID")
+		os.Exit(1)
+	}
+
+	// Fail fast on an unreadable CA bundle or invalid proxy, before the controllers build the transport
+	if _, err := options.GCP.Transport(); err != nil {
+		log.Error(err, "invalid google api options")
+		os.Exit(1)
+	}
+
 	namespace, err := k8sutil.GetWatchNamespace()
 	if err != nil {
 		log.Error(err, "Failed to get watch namespace")
@@ -107,6 +127,8 @@ func main() {
 		Namespace:          namespace,
 		MapperProvider:     restmapper.NewDynamicRESTMapper,
 		MetricsBindAddress: fmt.Sprintf("%s:%d", metricsHost, metricsPort),
+		Port:               options.WebhookPort,
+		CertDir:            options.WebhookCertDir,
 	})
 	if err != nil {
 		log.Error(err, "")
@@ -121,12 +143,32 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup the field indexes used by the controllers to find dependent resources
+	if err := indexes.AddToManager(mgr); err != nil {
+		log.Error(err, "failed to register the field indexes")
+		os.Exit(1)
+	}
+
 	// Setup all Controllers
-	if err := controller.AddToManager(mgr); err != nil {
+	if err := controller.AddToManager(mgr, options); err != nil {
 		log.Error(err, "")
 		os.Exit(1)
 	}
 
+	// Setup the admission webhooks
+	if options.EnableWebhooks {
+		if err := webhook.AddToManager(mgr, options); err != nil {
+			log.Error(err, "failed to register the webhooks")
+			os.Exit(1)
+		}
+	}
+
+	// Expose the number of projects by Ready condition
+	if err := gcpmetrics.RegisterProjects(mgr.GetClient()); err != nil {
+		log.Error(err, "failed to register the project metrics")
+		os.Exit(1)
+	}
+
 	if err = serveCRMetrics(cfg); err != nil {
 		log.Info("Could not generate and serve custom resource metrics", "error", err.Error())
 	}
@@ -7,9 +7,11 @@ require (
 	github.com/ghodss/yaml v1.0.1-0.20180820084758-c7ce16629ff4
 	github.com/go-openapi/spec v0.19.3
 	github.com/operator-framework/operator-sdk v0.12.0
+	github.com/prometheus/client_golang v1.0.0
 	github.com/spf13/pflag v1.0.3
 	golang.org/x/net v0.0.0-20190827160401-ba9fcec4b297
 	golang.org/x/oauth2 v0.0.0-20190604053449-0f29369cfe45
+	golang.org/x/time v0.0.0-20190308202827-9d24e82272b4
 	google.golang.org/api v0.14.0
 	k8s.io/api v0.0.0
 	k8s.io/apiextensions-apiserver v0.0.0
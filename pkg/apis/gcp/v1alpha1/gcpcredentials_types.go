@@ -10,15 +10,31 @@ type GCPCredentialsSpec struct {
 	// Key is the credential used to create GCP projects
 	// You must create a service account with resourcemanager.projectCreator
 	// and billing.user roles at the organization level and use the JSON payload here
-	// +kubebuilder:validation:Minimum=3
-	// +kubebuilder:validation:Required
-	Key string `json:"key"`
+	// One of key, keyRef or impersonateServiceAccount must be set, keyRef is preferred over key
+	// +kubebuilder:validation:Optional
+	Key string `json:"key,omitempty"`
+	// KeyRef refers to a Secret holding the JSON service account key
+	// +kubebuilder:validation:Optional
+	KeyRef *SecretKeyReference `json:"keyRef,omitempty"`
+	// ImpersonateServiceAccount is the email of a service account the operator acts as with
+	// short lived tokens from the IAM Credentials api, rather than a key. The tokens are issued
+	// to the key or keyRef when given, otherwise to the operator itself, which is only allowed
+	// for the credentials generated for a GCPProject or GCPAdminProject. The holder of the tokens
+	// needs roles/iam.serviceAccountTokenCreator on the service account
+	// +kubebuilder:validation:Optional
+	ImpersonateServiceAccount string `json:"impersonateServiceAccount,omitempty"`
+	// TokenSecretRef has the operator keep an access token of the impersonated service account
+	// in the Secret, refreshed before it expires, for consumers which cannot impersonate it
+	// themselves. The key defaults to 'token'
+	// +kubebuilder:validation:Optional
+	TokenSecretRef *SecretKeyReference `json:"tokenSecretRef,omitempty"`
 	// ProjectId is the GCP project ID these credentials belong to
-	// +kubebuilder:validation:Minimum=3
+	// +kubebuilder:validation:MinLength=6
+	// +kubebuilder:validation:MaxLength=30
 	// +kubebuilder:validation:Required
 	ProjectId string `json:"projectId"`
 	// Organization is the GCP org you wish the projects to reside within
-	// +kubebuilder:validation:Minimum=3
+	// +kubebuilder:validation:Pattern=`^[0-9]+$`
 	// +kubebuilder:validation:Required
 	OrganizationId string `json:"organizationId"`
 }
@@ -28,8 +44,17 @@ type GCPCredentialsSpec struct {
 type GCPCredentialsStatus struct {
 	// Verified checks that the credentials are ok and valid
 	Verified bool `json:"verified,omitempty"`
+	// MissingPermissions is a list of the required permissions the credentials
+	// do not hold on the organization
+	MissingPermissions []string `json:"missingPermissions,omitempty"`
+	// LastVerified is the time the credentials were last verified
+	LastVerified *metav1.Time `json:"lastVerified,omitempty"`
+	// TokenExpiryTime is when the access token in the Secret of tokenSecretRef expires
+	TokenExpiryTime *metav1.Time `json:"tokenExpiryTime,omitempty"`
 	// Status provides a overall status
 	Status string `json:"status"`
+	// Conditions are the latest observations of the state of the resource
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -38,6 +63,10 @@ type GCPCredentialsStatus struct {
 // +k8s:openapi-gen=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=gcpcredentials,scope=Namespaced
+// +kubebuilder:printcolumn:name="Organization",type="string",JSONPath=".spec.organizationId"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type GCPCredentials struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -9,35 +9,85 @@ import (
 // GCPProjectSpec defines the desired state of GCPProject
 // +k8s:openapi-gen=true
 type GCPProjectSpec struct {
-	// ProjectId is the GCP project ID
-	// +kubebuilder:validation:Minimum=3
-	// +kubebuilder:validation:Required
-	ProjectId string `json:"projectId"`
+	// ProjectId is the GCP project ID, either projectId or projectIdPrefix must be set
+	// +kubebuilder:validation:MinLength=6
+	// +kubebuilder:validation:MaxLength=30
+	// +kubebuilder:validation:Optional
+	ProjectId string `json:"projectId,omitempty"`
+	// ProjectIdPrefix has the operator generate an unused project ID of the form
+	// 'prefix-abc123' when no projectId is given, the chosen ID is recorded in the status
+	// +kubebuilder:validation:MaxLength=23
+	// +kubebuilder:validation:Optional
+	ProjectIdPrefix string `json:"projectIdPrefix,omitempty"`
 	// ProjectName is the GCP project name
-	// +kubebuilder:validation:Minimum=3
+	// +kubebuilder:validation:MinLength=4
+	// +kubebuilder:validation:MaxLength=30
 	// +kubebuilder:validation:Required
 	ProjectName string `json:"projectName"`
 	// ParentType is the type of parent this project has
 	// Valid types are: "organization", "folder", and "project"
+	// Defaults to the namespace annotations or the organization of the credentials
 	// +kubebuilder:validation:Enum=organization;folder;project
-	// +kubebuilder:validation:Required
-	ParentType string `json:"parentType"`
+	// +kubebuilder:validation:Optional
+	ParentType string `json:"parentType,omitempty"`
 	// ParentId is the type specific ID of the parent this project has
-	// +kubebuilder:validation:Required
-	ParentId string `json:"parentId"`
+	// +kubebuilder:validation:Optional
+	ParentId string `json:"parentId,omitempty"`
 	// BillingAccountName is the resource name of the billing account associated with the project
 	// +kubebuilder:validation:Required
 	// +k8s:openapi-gen=false
 	BillingAccountName string `json:"billingAccountName"`
 	// ServiceAccountName is the name used when creating the service account
-	// e.g. `hub-admin`
-	// +kubebuilder:validation:Minimum=3
-	// +kubebuilder:validation:Required
-	ServiceAccountName string `json:"serviceAccountName"`
+	// e.g. 'hub-admin', defaults to the name the operator is started with
+	// +kubebuilder:validation:MinLength=6
+	// +kubebuilder:validation:MaxLength=30
+	// +kubebuilder:validation:Optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
 	// GCPCredentials is a reference to the gcp credentials object to use
 	// +kubebuilder:validation:Required
 	// +k8s:openapi-gen=false
 	Use core.Ownership `json:"use"`
+	// DeletionPolicy controls what happens to the GCP project when this resource is deleted
+	// Valid policies are: "Delete" (default), "Orphan" and "DisableBilling"
+	// +kubebuilder:validation:Optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// Services are the APIs to enable in the project on top of the baseline required by the operator
+	// e.g. 'container.googleapis.com'
+	// +kubebuilder:validation:Optional
+	Services []string `json:"services,omitempty"`
+	// DisableRemovedServices disables services in the project once they are removed from services
+	// +kubebuilder:validation:Optional
+	DisableRemovedServices bool `json:"disableRemovedServices,omitempty"`
+	// IAM are the role bindings to apply to the project, by default the generated
+	// service account is granted 'roles/owner'
+	// +kubebuilder:validation:Optional
+	IAM *IAMPolicy `json:"iam,omitempty"`
+	// AdoptionPolicy controls what happens when the GCP project already exists and was not
+	// created by the operator
+	// Valid policies are: "Fail" (default) and "Adopt"
+	// +kubebuilder:validation:Optional
+	AdoptionPolicy AdoptionPolicy `json:"adoptionPolicy,omitempty"`
+	// DriftPolicy controls what happens when the GCP project is found to no longer match the spec
+	// Valid policies are: "Correct" (default) and "Report"
+	// +kubebuilder:validation:Optional
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
+	// ResyncInterval is how often the GCP project is checked against the spec, defaults to the
+	// interval the operator is started with
+	// +kubebuilder:validation:Optional
+	ResyncInterval *metav1.Duration `json:"resyncInterval,omitempty"`
+	// Labels are set on the GCP project, they must follow the GCP rules for labels, lowercase
+	// letters, digits, dashes and underscores of at most 63 characters
+	// +kubebuilder:validation:Optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// KeyRotation periodically replaces the key of the generated credentials, by default the
+	// key is never replaced
+	// +kubebuilder:validation:Optional
+	KeyRotation *KeyRotation `json:"keyRotation,omitempty"`
+	// CredentialsPolicy controls how the generated credentials act as the service account
+	// Valid policies are: "Key" (default) and "Impersonate", which issues no key and needs the
+	// operator to hold roles/iam.serviceAccountTokenCreator on the service account
+	// +kubebuilder:validation:Optional
+	CredentialsPolicy CredentialsPolicy `json:"credentialsPolicy,omitempty"`
 }
 
 // GCPProjectStatus defines the observed state of GCPProject
@@ -45,6 +95,31 @@ type GCPProjectSpec struct {
 type GCPProjectStatus struct {
 	// Status provides a overall status
 	Status core.Status `json:"status"`
+	// ProjectId is the ID of the GCP project, as given in the spec or generated from the prefix
+	ProjectId string `json:"projectId,omitempty"`
+	// Operations are the long running GCP operations currently being waited on
+	Operations []Operation `json:"operations,omitempty"`
+	// Steps records the progress of each of the provisioning steps
+	Steps []StepStatus `json:"steps,omitempty"`
+	// EnabledServices are the services the operator has enabled in the project
+	EnabledServices []string `json:"enabledServices,omitempty"`
+	// Conditions are the latest observations of the state of the resource
+	Conditions []Condition `json:"conditions,omitempty"`
+	// LastSyncTime is when the GCP project was last checked against the spec
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// Drift are the differences from the spec found at the last resync
+	Drift []string `json:"drift,omitempty"`
+	// LabelKeys are the keys of the labels the operator has set on the GCP project from the spec
+	// and namespace, so labels which are no longer wanted can be removed
+	LabelKeys []string `json:"labelKeys,omitempty"`
+	// ManagedRoles are the roles the operator has applied authoritatively from spec.iam, so the
+	// members of roles which are dropped from it can be removed
+	ManagedRoles []string `json:"managedRoles,omitempty"`
+	// CredentialsRef refers to the GCPCredentials generated for the service account of the project
+	CredentialsRef *core.Ownership `json:"credentialsRef,omitempty"`
+	// Keys are the service account keys issued for the generated credentials, the current key
+	// and any replaced keys waiting out the overlap of the key rotation
+	Keys []ServiceAccountKey `json:"keys,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -53,6 +128,10 @@ type GCPProjectStatus struct {
 // +k8s:openapi-gen=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=gcpprojects,scope=Namespaced
+// +kubebuilder:printcolumn:name="Project ID",type="string",JSONPath=".status.projectId"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type GCPProject struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -0,0 +1,247 @@
+package v1alpha1
+
+import (
+	core "github.com/appvia/hub-apis/pkg/apis/core/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeletionPolicy defines what happens to the GCP project when the resource is deleted
+// +kubebuilder:validation:Enum=Delete;Orphan;DisableBilling
+type DeletionPolicy string
+
+const (
+	// DeletePolicy deletes the GCP project, its service account, key and generated credentials
+	DeletePolicy DeletionPolicy = "Delete"
+	// OrphanPolicy leaves the GCP project and everything in it untouched
+	OrphanPolicy DeletionPolicy = "Orphan"
+	// DisableBillingPolicy keeps the GCP project but unlinks its billing account, revokes
+	// the service account key and removes the generated credentials
+	DisableBillingPolicy DeletionPolicy = "DisableBilling"
+)
+
+// DriftPolicy defines what happens when the GCP project no longer matches the spec
+// +kubebuilder:validation:Enum=Correct;Report
+type DriftPolicy string
+
+const (
+	// CorrectDriftPolicy reruns the provisioning steps to bring the project back in line
+	CorrectDriftPolicy DriftPolicy = "Correct"
+	// ReportDriftPolicy only reports the drift in the status
+	ReportDriftPolicy DriftPolicy = "Report"
+)
+
+// AdoptionPolicy defines what happens when the GCP project already exists and is not managed
+// by the operator
+// +kubebuilder:validation:Enum=Adopt;Fail
+type AdoptionPolicy string
+
+const (
+	// AdoptPolicy takes over the existing project, stamping the ownership labels on it
+	AdoptPolicy AdoptionPolicy = "Adopt"
+	// FailPolicy refuses to touch the existing project
+	FailPolicy AdoptionPolicy = "Fail"
+)
+
+// CredentialsPolicy defines how the generated credentials act as the service account
+// +kubebuilder:validation:Enum=Key;Impersonate
+type CredentialsPolicy string
+
+const (
+	// KeyCredentialsPolicy issues a service account key held in a Secret
+	KeyCredentialsPolicy CredentialsPolicy = "Key"
+	// ImpersonateCredentialsPolicy issues no key, the operator impersonates the service account
+	// with short lived tokens
+	ImpersonateCredentialsPolicy CredentialsPolicy = "Impersonate"
+)
+
+// IAMPolicyMode defines how the bindings in spec.iam are applied to the project policy
+// +kubebuilder:validation:Enum=Additive;Authoritative
+type IAMPolicyMode string
+
+const (
+	// AdditiveMode adds the members to the roles, leaving any other members in place
+	AdditiveMode IAMPolicyMode = "Additive"
+	// AuthoritativeMode makes the members the only members of the roles, removing any
+	// others. Roles which are not listed are left untouched
+	AuthoritativeMode IAMPolicyMode = "Authoritative"
+)
+
+// ServiceAccountMember is the placeholder member replaced by the generated service account
+const ServiceAccountMember = "$(serviceAccount)"
+
+// IAMBinding binds members to a role in the project
+// +k8s:openapi-gen=true
+type IAMBinding struct {
+	// Role is the role to grant e.g. 'roles/viewer'
+	// +kubebuilder:validation:Required
+	Role string `json:"role"`
+	// Members are the members to grant the role to, in the form used by GCP e.g.
+	// 'group:team@example.com', or '$(serviceAccount)' for the generated service account
+	// +kubebuilder:validation:Required
+	Members []string `json:"members"`
+}
+
+// IAMPolicy are the bindings to apply to the project policy
+// +k8s:openapi-gen=true
+type IAMPolicy struct {
+	// Mode controls how the bindings are applied
+	// Valid modes are: "Additive" (default) and "Authoritative"
+	// +kubebuilder:validation:Optional
+	Mode IAMPolicyMode `json:"mode,omitempty"`
+	// Bindings are the role bindings to apply
+	// +kubebuilder:validation:Optional
+	Bindings []IAMBinding `json:"bindings,omitempty"`
+}
+
+// SecretKeyReference refers to a key in a Secret in the namespace of the resource
+// +k8s:openapi-gen=true
+type SecretKeyReference struct {
+	// Name is the name of the Secret
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// Key is the key in the Secret holding the value, defaults to 'key.json' for service
+	// account keys and 'token' for bearer tokens
+	// +kubebuilder:validation:Optional
+	Key string `json:"key,omitempty"`
+}
+
+// KeyRotation controls how often the service account key of the generated credentials is replaced
+// +k8s:openapi-gen=true
+type KeyRotation struct {
+	// Interval is how long a key is used before it is replaced e.g. '2160h' for 90 days
+	// +kubebuilder:validation:Required
+	Interval metav1.Duration `json:"interval"`
+	// Overlap is how long a replaced key stays valid so consumers can pick up the new key,
+	// after which it is deleted. It must be shorter than the interval, defaults to deleting the
+	// replaced key straight away
+	// +kubebuilder:validation:Optional
+	Overlap *metav1.Duration `json:"overlap,omitempty"`
+}
+
+// ServiceAccountKey records a service account key issued for the generated credentials
+// +k8s:openapi-gen=true
+type ServiceAccountKey struct {
+	// ID is the ID of the key
+	ID string `json:"id"`
+	// Name is the resource name of the key
+	Name string `json:"name"`
+	// CreationTime is when the key was issued
+	CreationTime metav1.Time `json:"creationTime"`
+	// ReplacedTime is when a newer key took over, the key is deleted once the overlap has passed
+	ReplacedTime *metav1.Time `json:"replacedTime,omitempty"`
+}
+
+const (
+	// CloudResourceManagerService is the api owning project operations
+	CloudResourceManagerService = "cloudresourcemanager"
+	// ServiceManagementService is the api owning service enablement operations
+	ServiceManagementService = "servicemanagement"
+)
+
+const (
+	// CreateProjectStep creates the project or brings its name and parent in line with the spec
+	CreateProjectStep = "CreateProject"
+	// LinkBillingStep links the billing account to the project, before the services as some
+	// of them can only be enabled in projects with billing
+	LinkBillingStep = "LinkBilling"
+	// EnableServicesStep enables the required apis in the project
+	EnableServicesStep = "EnableServices"
+	// CreateServiceAccountStep creates the service account in the project
+	CreateServiceAccountStep = "CreateServiceAccount"
+	// GrantPermissionsStep grants the service account its roles
+	GrantPermissionsStep = "GrantPermissions"
+	// IssueCredentialsStep creates a service account key and the GCPCredentials holding it
+	IssueCredentialsStep = "IssueCredentials"
+)
+
+// Operation is a long running GCP operation the reconciler is waiting on
+// +k8s:openapi-gen=true
+type Operation struct {
+	// Name is the name of the operation
+	Name string `json:"name"`
+	// Service is the api owning the operation, cloudresourcemanager or servicemanagement
+	Service string `json:"service"`
+	// Step is the provisioning step which started the operation
+	Step string `json:"step"`
+	// StartTime is when the operation was started
+	StartTime metav1.Time `json:"startTime"`
+}
+
+// StepStatus records the progress of a provisioning step
+// +k8s:openapi-gen=true
+type StepStatus struct {
+	// Name is the name of the step
+	Name string `json:"name"`
+	// Status is the status of the step
+	Status core.Status `json:"status"`
+	// Message is the error the step last failed with
+	Message string `json:"message,omitempty"`
+	// ObservedGeneration is the generation of the resource the step was last completed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastTransitionTime is when the step last changed status
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// ConditionType is the type of a condition
+type ConditionType string
+
+const (
+	// ReadyCondition is true when the resource has been fully reconciled
+	ReadyCondition ConditionType = "Ready"
+	// ProjectCreatedCondition is true when the GCP project exists and matches the spec
+	ProjectCreatedCondition ConditionType = "ProjectCreated"
+	// BillingLinkedCondition is true when the billing account is linked to the project
+	BillingLinkedCondition ConditionType = "BillingLinked"
+	// APIsEnabledCondition is true when the required apis are enabled in the project
+	APIsEnabledCondition ConditionType = "APIsEnabled"
+	// ServiceAccountReadyCondition is true when the service account exists and holds its roles
+	ServiceAccountReadyCondition ConditionType = "ServiceAccountReady"
+	// CredentialsIssuedCondition is true when the GCPCredentials for the service account exist
+	CredentialsIssuedCondition ConditionType = "CredentialsIssued"
+	// DriftedCondition is true when the GCP project no longer matched the spec at the last resync
+	DriftedCondition ConditionType = "Drifted"
+	// ConflictCondition is true when the GCP project is managed by another resource or cluster
+	ConflictCondition ConditionType = "Conflict"
+	// TokenExpiredCondition is true when GCP has rejected the bearer token of a GCPAdminProject
+	TokenExpiredCondition ConditionType = "TokenExpired"
+)
+
+// ConditionStatus is the status of a condition
+type ConditionStatus string
+
+const (
+	// ConditionTrue means the resource is in the condition
+	ConditionTrue ConditionStatus = "True"
+	// ConditionFalse means the resource is not in the condition
+	ConditionFalse ConditionStatus = "False"
+	// ConditionUnknown means it is not yet known if the resource is in the condition
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// StepConditions maps each provisioning step to the condition reporting on it
+var StepConditions = map[string]ConditionType{
+	CreateProjectStep:        ProjectCreatedCondition,
+	LinkBillingStep:          BillingLinkedCondition,
+	EnableServicesStep:       APIsEnabledCondition,
+	CreateServiceAccountStep: ServiceAccountReadyCondition,
+	GrantPermissionsStep:     ServiceAccountReadyCondition,
+	IssueCredentialsStep:     CredentialsIssuedCondition,
+}
+
+// Condition describes an aspect of the state of a resource
+// +k8s:openapi-gen=true
+type Condition struct {
+	// Type is the type of the condition
+	Type ConditionType `json:"type"`
+	// Status is the status of the condition, one of True, False or Unknown
+	Status ConditionStatus `json:"status"`
+	// Reason is a one word CamelCase reason for the last transition
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable description of the last transition
+	Message string `json:"message,omitempty"`
+	// ObservedGeneration is the generation of the resource the condition was set for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastTransitionTime is when the condition last changed status
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
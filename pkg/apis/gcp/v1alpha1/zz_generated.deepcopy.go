@@ -5,16 +5,35 @@
 package v1alpha1
 
 import (
+	corev1 "github.com/appvia/hub-apis/pkg/apis/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GCPAdminProject) DeepCopyInto(out *GCPAdminProject) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -72,6 +91,33 @@ func (in *GCPAdminProjectList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GCPAdminProjectSpec) DeepCopyInto(out *GCPAdminProjectSpec) {
 	*out = *in
+	if in.TokenRef != nil {
+		in, out := &in.TokenRef, &out.TokenRef
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResyncInterval != nil {
+		in, out := &in.ResyncInterval, &out.ResyncInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.KeyRotation != nil {
+		in, out := &in.KeyRotation, &out.KeyRotation
+		*out = new(KeyRotation)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -88,6 +134,58 @@ func (in *GCPAdminProjectSpec) DeepCopy() *GCPAdminProjectSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GCPAdminProjectStatus) DeepCopyInto(out *GCPAdminProjectStatus) {
 	*out = *in
+	if in.Operations != nil {
+		in, out := &in.Operations, &out.Operations
+		*out = make([]Operation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]StepStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EnabledServices != nil {
+		in, out := &in.EnabledServices, &out.EnabledServices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LabelKeys != nil {
+		in, out := &in.LabelKeys, &out.LabelKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CredentialsRef != nil {
+		in, out := &in.CredentialsRef, &out.CredentialsRef
+		*out = new(corev1.Ownership)
+		**out = **in
+	}
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]ServiceAccountKey, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -106,8 +204,8 @@ func (in *GCPCredentials) DeepCopyInto(out *GCPCredentials) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -165,6 +263,16 @@ func (in *GCPCredentialsList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GCPCredentialsSpec) DeepCopyInto(out *GCPCredentialsSpec) {
 	*out = *in
+	if in.KeyRef != nil {
+		in, out := &in.KeyRef, &out.KeyRef
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
+	if in.TokenSecretRef != nil {
+		in, out := &in.TokenSecretRef, &out.TokenSecretRef
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
 	return
 }
 
@@ -181,6 +289,26 @@ func (in *GCPCredentialsSpec) DeepCopy() *GCPCredentialsSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GCPCredentialsStatus) DeepCopyInto(out *GCPCredentialsStatus) {
 	*out = *in
+	if in.MissingPermissions != nil {
+		in, out := &in.MissingPermissions, &out.MissingPermissions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastVerified != nil {
+		in, out := &in.LastVerified, &out.LastVerified
+		*out = (*in).DeepCopy()
+	}
+	if in.TokenExpiryTime != nil {
+		in, out := &in.TokenExpiryTime, &out.TokenExpiryTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -199,8 +327,8 @@ func (in *GCPProject) DeepCopyInto(out *GCPProject) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -259,6 +387,33 @@ func (in *GCPProjectList) DeepCopyObject() runtime.Object {
 func (in *GCPProjectSpec) DeepCopyInto(out *GCPProjectSpec) {
 	*out = *in
 	out.Use = in.Use
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IAM != nil {
+		in, out := &in.IAM, &out.IAM
+		*out = new(IAMPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResyncInterval != nil {
+		in, out := &in.ResyncInterval, &out.ResyncInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.KeyRotation != nil {
+		in, out := &in.KeyRotation, &out.KeyRotation
+		*out = new(KeyRotation)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -275,6 +430,63 @@ func (in *GCPProjectSpec) DeepCopy() *GCPProjectSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GCPProjectStatus) DeepCopyInto(out *GCPProjectStatus) {
 	*out = *in
+	if in.Operations != nil {
+		in, out := &in.Operations, &out.Operations
+		*out = make([]Operation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]StepStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EnabledServices != nil {
+		in, out := &in.EnabledServices, &out.EnabledServices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LabelKeys != nil {
+		in, out := &in.LabelKeys, &out.LabelKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ManagedRoles != nil {
+		in, out := &in.ManagedRoles, &out.ManagedRoles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CredentialsRef != nil {
+		in, out := &in.CredentialsRef, &out.CredentialsRef
+		*out = new(corev1.Ownership)
+		**out = **in
+	}
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]ServiceAccountKey, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -287,3 +499,140 @@ func (in *GCPProjectStatus) DeepCopy() *GCPProjectStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IAMBinding) DeepCopyInto(out *IAMBinding) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IAMBinding.
+func (in *IAMBinding) DeepCopy() *IAMBinding {
+	if in == nil {
+		return nil
+	}
+	out := new(IAMBinding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IAMPolicy) DeepCopyInto(out *IAMPolicy) {
+	*out = *in
+	if in.Bindings != nil {
+		in, out := &in.Bindings, &out.Bindings
+		*out = make([]IAMBinding, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IAMPolicy.
+func (in *IAMPolicy) DeepCopy() *IAMPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(IAMPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyRotation) DeepCopyInto(out *KeyRotation) {
+	*out = *in
+	out.Interval = in.Interval
+	if in.Overlap != nil {
+		in, out := &in.Overlap, &out.Overlap
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyRotation.
+func (in *KeyRotation) DeepCopy() *KeyRotation {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyRotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Operation) DeepCopyInto(out *Operation) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Operation.
+func (in *Operation) DeepCopy() *Operation {
+	if in == nil {
+		return nil
+	}
+	out := new(Operation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeyReference) DeepCopyInto(out *SecretKeyReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyReference.
+func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceAccountKey) DeepCopyInto(out *ServiceAccountKey) {
+	*out = *in
+	in.CreationTime.DeepCopyInto(&out.CreationTime)
+	if in.ReplacedTime != nil {
+		in, out := &in.ReplacedTime, &out.ReplacedTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceAccountKey.
+func (in *ServiceAccountKey) DeepCopy() *ServiceAccountKey {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceAccountKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepStatus) DeepCopyInto(out *StepStatus) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepStatus.
+func (in *StepStatus) DeepCopy() *StepStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StepStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -11,18 +11,80 @@ import (
 
 func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
 	return map[string]common.OpenAPIDefinition{
+		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.Condition":             schema_pkg_apis_gcp_v1alpha1_Condition(ref),
 		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPAdminProject":       schema_pkg_apis_gcp_v1alpha1_GCPAdminProject(ref),
 		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPAdminProjectSpec":   schema_pkg_apis_gcp_v1alpha1_GCPAdminProjectSpec(ref),
 		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPAdminProjectStatus": schema_pkg_apis_gcp_v1alpha1_GCPAdminProjectStatus(ref),
-		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPAdminToken":         schema_pkg_apis_gcp_v1alpha1_GCPAdminToken(ref),
-		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPAdminTokenSpec":     schema_pkg_apis_gcp_v1alpha1_GCPAdminTokenSpec(ref),
-		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPAdminTokenStatus":   schema_pkg_apis_gcp_v1alpha1_GCPAdminTokenStatus(ref),
 		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPCredentials":        schema_pkg_apis_gcp_v1alpha1_GCPCredentials(ref),
 		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPCredentialsSpec":    schema_pkg_apis_gcp_v1alpha1_GCPCredentialsSpec(ref),
 		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPCredentialsStatus":  schema_pkg_apis_gcp_v1alpha1_GCPCredentialsStatus(ref),
 		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPProject":            schema_pkg_apis_gcp_v1alpha1_GCPProject(ref),
 		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPProjectSpec":        schema_pkg_apis_gcp_v1alpha1_GCPProjectSpec(ref),
 		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPProjectStatus":      schema_pkg_apis_gcp_v1alpha1_GCPProjectStatus(ref),
+		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.IAMBinding":            schema_pkg_apis_gcp_v1alpha1_IAMBinding(ref),
+		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.IAMPolicy":             schema_pkg_apis_gcp_v1alpha1_IAMPolicy(ref),
+		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.KeyRotation":           schema_pkg_apis_gcp_v1alpha1_KeyRotation(ref),
+		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.Operation":             schema_pkg_apis_gcp_v1alpha1_Operation(ref),
+		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.SecretKeyReference":    schema_pkg_apis_gcp_v1alpha1_SecretKeyReference(ref),
+		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.ServiceAccountKey":     schema_pkg_apis_gcp_v1alpha1_ServiceAccountKey(ref),
+		"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.StepStatus":            schema_pkg_apis_gcp_v1alpha1_StepStatus(ref),
+	}
+}
+
+func schema_pkg_apis_gcp_v1alpha1_Condition(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "Condition describes an aspect of the state of a resource",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Type is the type of the condition",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Status is the status of the condition, one of True, False or Unknown",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"reason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Reason is a one word CamelCase reason for the last transition",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message is a human readable description of the last transition",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"observedGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ObservedGeneration is the generation of the resource the condition was set for",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"lastTransitionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastTransitionTime is when the condition last changed status",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+				},
+				Required: []string{"type", "status"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
@@ -77,6 +139,26 @@ func schema_pkg_apis_gcp_v1alpha1_GCPAdminProjectSpec(ref common.ReferenceCallba
 				Description: "GCPAdminProjectSpec defines the desired state of GCPAdminProject",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
+					"token": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Token is the bearer token used to setup the initial GCP admin project and service account You must grab a token using 'gcloud auth print-access-token you@example.com' Either token or tokenRef must be set, tokenRef is preferred",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"tokenRef": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TokenRef refers to a Secret holding the bearer token, the key defaults to 'token'",
+							Ref:         ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.SecretKeyReference"),
+						},
+					},
+					"deleteTokenAfterBootstrap": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DeleteTokenAfterBootstrap deletes the Secret referred to by tokenRef once the credentials for the admin service account have been issued",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
 					"projectId": {
 						SchemaProps: spec.SchemaProps{
 							Description: "ProjectId is the GCP project ID",
@@ -93,7 +175,7 @@ func schema_pkg_apis_gcp_v1alpha1_GCPAdminProjectSpec(ref common.ReferenceCallba
 					},
 					"parentType": {
 						SchemaProps: spec.SchemaProps{
-							Description: "ParentType is the type of parent this project has Valid types are: \"organization\", \"folder\", and \"project\"",
+							Description: "ParentType is the type of parent this project has Valid types are: \"organization\", \"folder\", and \"project\" Defaults to the annotations of the namespace",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -107,15 +189,86 @@ func schema_pkg_apis_gcp_v1alpha1_GCPAdminProjectSpec(ref common.ReferenceCallba
 					},
 					"serviceAccountName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "ServiceAccountName is the name used when creating the service account e.g. 'hub-admin'",
+							Description: "ServiceAccountName is the name used when creating the service account e.g. 'hub-admin', defaults to the name the operator is started with",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"deletionPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DeletionPolicy controls what happens to the GCP project when this resource is deleted Valid policies are: \"Delete\" (default), \"Orphan\" and \"DisableBilling\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"services": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Services are the APIs to enable in the project on top of the baseline required by the operator e.g. 'container.googleapis.com'",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"disableRemovedServices": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DisableRemovedServices disables services in the project once they are removed from services",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"driftPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DriftPolicy controls what happens when the GCP project is found to no longer match the spec Valid policies are: \"Correct\" (default) and \"Report\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"resyncInterval": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ResyncInterval is how often the GCP project is checked against the spec, defaults to the interval the operator is started with",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+					"labels": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Labels are set on the GCP project, they must follow the GCP rules for labels, lowercase letters, digits, dashes and underscores of at most 63 characters",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"keyRotation": {
+						SchemaProps: spec.SchemaProps{
+							Description: "KeyRotation periodically replaces the key of the generated credentials, by default the key is never replaced",
+							Ref:         ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.KeyRotation"),
+						},
+					},
+					"credentialsPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CredentialsPolicy controls how the generated credentials act as the service account Valid policies are: \"Key\" (default) and \"Impersonate\", which issues no key and needs the operator to hold roles/iam.serviceAccountTokenCreator on the service account",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
 				},
-				Required: []string{"projectId", "projectName", "parentType", "parentId", "serviceAccountName"},
+				Required: []string{"projectId", "projectName"},
 			},
 		},
+		Dependencies: []string{
+			"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.KeyRotation", "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.SecretKeyReference", "k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
 	}
 }
 
@@ -133,18 +286,133 @@ func schema_pkg_apis_gcp_v1alpha1_GCPAdminProjectStatus(ref common.ReferenceCall
 							Format:      "",
 						},
 					},
+					"operations": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Operations are the long running GCP operations currently being waited on",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.Operation"),
+									},
+								},
+							},
+						},
+					},
+					"steps": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Steps records the progress of each of the provisioning steps",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.StepStatus"),
+									},
+								},
+							},
+						},
+					},
+					"enabledServices": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EnabledServices are the services the operator has enabled in the project",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"conditions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Conditions are the latest observations of the state of the resource",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.Condition"),
+									},
+								},
+							},
+						},
+					},
+					"lastSyncTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastSyncTime is when the GCP project was last checked against the spec",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"drift": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Drift are the differences from the spec found at the last resync",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"labelKeys": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LabelKeys are the keys of the labels the operator has set on the GCP project from the spec and namespace, so labels which are no longer wanted can be removed",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"credentialsRef": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CredentialsRef refers to the GCPCredentials generated for the service account of the project",
+							Ref:         ref("github.com/appvia/hub-apis/pkg/apis/core/v1.Ownership"),
+						},
+					},
+					"keys": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Keys are the service account keys issued for the generated credentials, the current key and any replaced keys waiting out the overlap of the key rotation",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.ServiceAccountKey"),
+									},
+								},
+							},
+						},
+					},
+					"tokenDeleted": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TokenDeleted records that the token Secret was deleted after bootstrap, so a token provided again later is left in place",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
 				},
 				Required: []string{"status"},
 			},
 		},
+		Dependencies: []string{
+			"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.Condition", "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.Operation", "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.ServiceAccountKey", "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.StepStatus", "github.com/appvia/hub-apis/pkg/apis/core/v1.Ownership", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
-func schema_pkg_apis_gcp_v1alpha1_GCPAdminToken(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_gcp_v1alpha1_GCPCredentials(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "GCPAdminToken is the Schema for the gcpadmintokens API",
+				Description: "GCPCredentials is the Schema for the gcpcredentials API",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
 					"kind": {
@@ -168,36 +436,55 @@ func schema_pkg_apis_gcp_v1alpha1_GCPAdminToken(ref common.ReferenceCallback) co
 					},
 					"spec": {
 						SchemaProps: spec.SchemaProps{
-							Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPAdminTokenSpec"),
+							Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPCredentialsSpec"),
 						},
 					},
 					"status": {
 						SchemaProps: spec.SchemaProps{
-							Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPAdminTokenStatus"),
+							Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPCredentialsStatus"),
 						},
 					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPAdminTokenSpec", "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPAdminTokenStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+			"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPCredentialsSpec", "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPCredentialsStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
 	}
 }
 
-func schema_pkg_apis_gcp_v1alpha1_GCPAdminTokenSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_gcp_v1alpha1_GCPCredentialsSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "GCPAdminTokenSpec defines the desired state of GCPAdminToken",
+				Description: "GCPCredentialsSpec defines the desired state of GCPCredentials",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"token": {
+					"key": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Token is the bearer token used to setup the initial GCP admin project and service account You must grab a token using 'gcloud auth print-access-token you@example.com'",
+							Description: "Key is the credential used to create GCP projects You must create a service account with resourcemanager.projectCreator and billing.user roles at the organization level and use the JSON payload here One of key, keyRef or impersonateServiceAccount must be set, keyRef is preferred over key",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
+					"keyRef": {
+						SchemaProps: spec.SchemaProps{
+							Description: "KeyRef refers to a Secret holding the JSON service account key",
+							Ref:         ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.SecretKeyReference"),
+						},
+					},
+					"impersonateServiceAccount": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ImpersonateServiceAccount is the email of a service account the operator acts as with short lived tokens from the IAM Credentials api, rather than a key. The tokens are issued to the key or keyRef when given, otherwise to the operator itself, which is only allowed for the credentials generated for a GCPProject or GCPAdminProject. The holder of the tokens needs roles/iam.serviceAccountTokenCreator on the service account",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"tokenSecretRef": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TokenSecretRef has the operator keep an access token of the impersonated service account in the Secret, refreshed before it expires, for consumers which cannot impersonate it themselves. The key defaults to 'token'",
+							Ref:         ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.SecretKeyReference"),
+						},
+					},
 					"projectId": {
 						SchemaProps: spec.SchemaProps{
 							Description: "ProjectId is the GCP project ID these credentials belong to",
@@ -213,26 +500,54 @@ func schema_pkg_apis_gcp_v1alpha1_GCPAdminTokenSpec(ref common.ReferenceCallback
 						},
 					},
 				},
-				Required: []string{"token", "projectId", "organizationId"},
+				Required: []string{"projectId", "organizationId"},
 			},
 		},
+		Dependencies: []string{
+			"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.SecretKeyReference"},
 	}
 }
 
-func schema_pkg_apis_gcp_v1alpha1_GCPAdminTokenStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_gcp_v1alpha1_GCPCredentialsStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "GCPAdminTokenStatus defines the observed state of GCPAdminToken",
+				Description: "GCPCredentialsStatus defines the observed state of GCPCredentials",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
 					"verified": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Verified checks that the token is ok and valid",
+							Description: "Verified checks that the credentials are ok and valid",
 							Type:        []string{"boolean"},
 							Format:      "",
 						},
 					},
+					"missingPermissions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MissingPermissions is a list of the required permissions the credentials do not hold on the organization",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"lastVerified": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastVerified is the time the credentials were last verified",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"tokenExpiryTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TokenExpiryTime is when the access token in the Secret of tokenSecretRef expires",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
 					"status": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Status provides a overall status",
@@ -240,18 +555,33 @@ func schema_pkg_apis_gcp_v1alpha1_GCPAdminTokenStatus(ref common.ReferenceCallba
 							Format:      "",
 						},
 					},
+					"conditions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Conditions are the latest observations of the state of the resource",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.Condition"),
+									},
+								},
+							},
+						},
+					},
 				},
-				Required: []string{"verified", "status"},
+				Required: []string{"status"},
 			},
 		},
+		Dependencies: []string{
+			"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.Condition", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
-func schema_pkg_apis_gcp_v1alpha1_GCPCredentials(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_gcp_v1alpha1_GCPProject(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "GCPCredentials is the Schema for the gcpcredentials API",
+				Description: "GCPProject is the Schema for the gcpprojects API",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
 					"kind": {
@@ -275,188 +605,569 @@ func schema_pkg_apis_gcp_v1alpha1_GCPCredentials(ref common.ReferenceCallback) c
 					},
 					"spec": {
 						SchemaProps: spec.SchemaProps{
-							Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPCredentialsSpec"),
+							Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPProjectSpec"),
 						},
 					},
 					"status": {
 						SchemaProps: spec.SchemaProps{
-							Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPCredentialsStatus"),
+							Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPProjectStatus"),
 						},
 					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPCredentialsSpec", "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPCredentialsStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+			"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPProjectSpec", "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPProjectStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
 	}
 }
 
-func schema_pkg_apis_gcp_v1alpha1_GCPCredentialsSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_gcp_v1alpha1_GCPProjectSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "GCPCredentialsSpec defines the desired state of GCPCredentials",
+				Description: "GCPProjectSpec defines the desired state of GCPProject",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"key": {
+					"projectId": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Key is the credential used to create GCP projects You must create a service account with resourcemanager.projectCreator and billing.user roles at the organization level and use the JSON payload here",
+							Description: "ProjectId is the GCP project ID, either projectId or projectIdPrefix must be set",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"projectId": {
+					"projectIdPrefix": {
 						SchemaProps: spec.SchemaProps{
-							Description: "ProjectId is the GCP project ID these credentials belong to",
+							Description: "ProjectIdPrefix has the operator generate an unused project ID of the form 'prefix-abc123' when no projectId is given, the chosen ID is recorded in the status",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"organizationId": {
+					"projectName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Organization is the GCP org you wish the projects to reside within",
+							Description: "ProjectName is the GCP project name",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"parentType": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ParentType is the type of parent this project has Valid types are: \"organization\", \"folder\", and \"project\" Defaults to the namespace annotations or the organization of the credentials",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"parentId": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ParentId is the type specific ID of the parent this project has",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"serviceAccountName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ServiceAccountName is the name used when creating the service account e.g. 'hub-admin', defaults to the name the operator is started with",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"deletionPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DeletionPolicy controls what happens to the GCP project when this resource is deleted Valid policies are: \"Delete\" (default), \"Orphan\" and \"DisableBilling\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"services": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Services are the APIs to enable in the project on top of the baseline required by the operator e.g. 'container.googleapis.com'",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"disableRemovedServices": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DisableRemovedServices disables services in the project once they are removed from services",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"iam": {
+						SchemaProps: spec.SchemaProps{
+							Description: "IAM are the role bindings to apply to the project, by default the generated service account is granted 'roles/owner'",
+							Ref:         ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.IAMPolicy"),
+						},
+					},
+					"adoptionPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AdoptionPolicy controls what happens when the GCP project already exists and was not created by the operator Valid policies are: \"Fail\" (default) and \"Adopt\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"driftPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DriftPolicy controls what happens when the GCP project is found to no longer match the spec Valid policies are: \"Correct\" (default) and \"Report\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"resyncInterval": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ResyncInterval is how often the GCP project is checked against the spec, defaults to the interval the operator is started with",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+					"labels": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Labels are set on the GCP project, they must follow the GCP rules for labels, lowercase letters, digits, dashes and underscores of at most 63 characters",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"keyRotation": {
+						SchemaProps: spec.SchemaProps{
+							Description: "KeyRotation periodically replaces the key of the generated credentials, by default the key is never replaced",
+							Ref:         ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.KeyRotation"),
+						},
+					},
+					"credentialsPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CredentialsPolicy controls how the generated credentials act as the service account Valid policies are: \"Key\" (default) and \"Impersonate\", which issues no key and needs the operator to hold roles/iam.serviceAccountTokenCreator on the service account",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
 				},
-				Required: []string{"key", "projectId", "organizationId"},
+				Required: []string{"projectName"},
 			},
 		},
+		Dependencies: []string{
+			"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.IAMPolicy", "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.KeyRotation", "k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
 	}
 }
 
-func schema_pkg_apis_gcp_v1alpha1_GCPCredentialsStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_gcp_v1alpha1_GCPProjectStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "GCPCredentialsStatus defines the observed state of GCPCredentials",
+				Description: "GCPProjectStatus defines the observed state of GCPProject",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"verified": {
+					"status": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Verified checks that the credentials are ok and valid",
-							Type:        []string{"boolean"},
+							Description: "Status provides a overall status",
+							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"status": {
+					"projectId": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Status provides a overall status",
+							Description: "ProjectId is the ID of the GCP project, as given in the spec or generated from the prefix",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
+					"operations": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Operations are the long running GCP operations currently being waited on",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.Operation"),
+									},
+								},
+							},
+						},
+					},
+					"steps": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Steps records the progress of each of the provisioning steps",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.StepStatus"),
+									},
+								},
+							},
+						},
+					},
+					"enabledServices": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EnabledServices are the services the operator has enabled in the project",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"conditions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Conditions are the latest observations of the state of the resource",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.Condition"),
+									},
+								},
+							},
+						},
+					},
+					"lastSyncTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastSyncTime is when the GCP project was last checked against the spec",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"drift": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Drift are the differences from the spec found at the last resync",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"labelKeys": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LabelKeys are the keys of the labels the operator has set on the GCP project from the spec and namespace, so labels which are no longer wanted can be removed",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"managedRoles": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ManagedRoles are the roles the operator has applied authoritatively from spec.iam, so the members of roles which are dropped from it can be removed",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"credentialsRef": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CredentialsRef refers to the GCPCredentials generated for the service account of the project",
+							Ref:         ref("github.com/appvia/hub-apis/pkg/apis/core/v1.Ownership"),
+						},
+					},
+					"keys": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Keys are the service account keys issued for the generated credentials, the current key and any replaced keys waiting out the overlap of the key rotation",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.ServiceAccountKey"),
+									},
+								},
+							},
+						},
+					},
 				},
 				Required: []string{"status"},
 			},
 		},
+		Dependencies: []string{
+			"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.Condition", "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.Operation", "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.ServiceAccountKey", "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.StepStatus", "github.com/appvia/hub-apis/pkg/apis/core/v1.Ownership", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
-func schema_pkg_apis_gcp_v1alpha1_GCPProject(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_gcp_v1alpha1_IAMBinding(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "GCPProject is the Schema for the gcpprojects API",
+				Description: "IAMBinding binds members to a role in the project",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"kind": {
+					"role": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Description: "Role is the role to grant e.g. 'roles/viewer'",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"apiVersion": {
+					"members": {
 						SchemaProps: spec.SchemaProps{
-							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Description: "Members are the members to grant the role to, in the form used by GCP e.g. 'group:team@example.com', or '$(serviceAccount)' for the generated service account",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"role", "members"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_gcp_v1alpha1_IAMPolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "IAMPolicy are the bindings to apply to the project policy",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"mode": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Mode controls how the bindings are applied Valid modes are: \"Additive\" (default) and \"Authoritative\"",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"metadata": {
+					"bindings": {
 						SchemaProps: spec.SchemaProps{
-							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+							Description: "Bindings are the role bindings to apply",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.IAMBinding"),
+									},
+								},
+							},
 						},
 					},
-					"spec": {
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.IAMBinding"},
+	}
+}
+
+func schema_pkg_apis_gcp_v1alpha1_KeyRotation(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KeyRotation controls how often the service account key of the generated credentials is replaced",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"interval": {
 						SchemaProps: spec.SchemaProps{
-							Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPProjectSpec"),
+							Description: "Interval is how long a key is used before it is replaced e.g. '2160h' for 90 days",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
 						},
 					},
-					"status": {
+					"overlap": {
 						SchemaProps: spec.SchemaProps{
-							Ref: ref("github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPProjectStatus"),
+							Description: "Overlap is how long a replaced key stays valid so consumers can pick up the new key, after which it is deleted. It must be shorter than the interval, defaults to deleting the replaced key straight away",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
 						},
 					},
 				},
+				Required: []string{"interval"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPProjectSpec", "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1.GCPProjectStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
 	}
 }
 
-func schema_pkg_apis_gcp_v1alpha1_GCPProjectSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_gcp_v1alpha1_Operation(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "GCPProjectSpec defines the desired state of GCPProject",
+				Description: "Operation is a long running GCP operation the reconciler is waiting on",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"projectId": {
+					"name": {
 						SchemaProps: spec.SchemaProps{
-							Description: "ProjectId is the GCP project ID",
+							Description: "Name is the name of the operation",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"projectName": {
+					"service": {
 						SchemaProps: spec.SchemaProps{
-							Description: "ProjectName is the GCP project name",
+							Description: "Service is the api owning the operation, cloudresourcemanager or servicemanagement",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"parentType": {
+					"step": {
 						SchemaProps: spec.SchemaProps{
-							Description: "ParentType is the type of parent this project has Valid types are: \"organization\", \"folder\", and \"project\"",
+							Description: "Step is the provisioning step which started the operation",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"parentId": {
+					"startTime": {
 						SchemaProps: spec.SchemaProps{
-							Description: "ParentId is the type specific ID of the parent this project has",
+							Description: "StartTime is when the operation was started",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+				},
+				Required: []string{"name", "service", "step", "startTime"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_gcp_v1alpha1_SecretKeyReference(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "SecretKeyReference refers to a key in a Secret in the namespace of the resource",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name is the name of the Secret",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"key": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Key is the key in the Secret holding the value, defaults to 'key.json' for service account keys and 'token' for bearer tokens",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
 				},
-				Required: []string{"projectId", "projectName", "parentType", "parentId"},
+				Required: []string{"name"},
 			},
 		},
 	}
 }
 
-func schema_pkg_apis_gcp_v1alpha1_GCPProjectStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_gcp_v1alpha1_ServiceAccountKey(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "GCPProjectStatus defines the observed state of GCPProject",
+				Description: "ServiceAccountKey records a service account key issued for the generated credentials",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"id": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ID is the ID of the key",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name is the resource name of the key",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"creationTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CreationTime is when the key was issued",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"replacedTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ReplacedTime is when a newer key took over, the key is deleted once the overlap has passed",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+				},
+				Required: []string{"id", "name", "creationTime"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_gcp_v1alpha1_StepStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "StepStatus records the progress of a provisioning step",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name is the name of the step",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 					"status": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Status provides a overall status",
+							Description: "Status is the status of the step",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message is the error the step last failed with",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"observedGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ObservedGeneration is the generation of the resource the step was last completed for",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"lastTransitionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastTransitionTime is when the step last changed status",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
 				},
-				Required: []string{"status"},
+				Required: []string{"name", "status"},
 			},
 		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
@@ -57,13 +57,73 @@ definitions:
             description: BillingAccountName is the resource name of the billing account
               associated with the project e.g. '012345-567890-ABCDEF'
             type: string
+          credentialsPolicy:
+            description: 'CredentialsPolicy controls how the generated credentials
+              act as the service account Valid policies are: "Key" (default) and "Impersonate",
+              which issues no key and needs the operator to hold roles/iam.serviceAccountTokenCreator
+              on the service account'
+            enum:
+            - Key
+            - Impersonate
+            type: string
+          deleteTokenAfterBootstrap:
+            description: DeleteTokenAfterBootstrap deletes the Secret referred to
+              by tokenRef once the credentials for the admin service account have
+              been issued
+            type: boolean
+          deletionPolicy:
+            description: 'DeletionPolicy controls what happens to the GCP project
+              when this resource is deleted Valid policies are: "Delete" (default),
+              "Orphan" and "DisableBilling"'
+            enum:
+            - Delete
+            - Orphan
+            - DisableBilling
+            type: string
+          disableRemovedServices:
+            description: DisableRemovedServices disables services in the project once
+              they are removed from services
+            type: boolean
+          driftPolicy:
+            description: 'DriftPolicy controls what happens when the GCP project is
+              found to no longer match the spec Valid policies are: "Correct" (default)
+              and "Report"'
+            enum:
+            - Correct
+            - Report
+            type: string
+          keyRotation:
+            description: KeyRotation periodically replaces the key of the generated
+              credentials, by default the key is never replaced
+            properties:
+              interval:
+                description: Interval is how long a key is used before it is replaced
+                  e.g. '2160h' for 90 days
+                type: string
+              overlap:
+                description: Overlap is how long a replaced key stays valid so consumers
+                  can pick up the new key, after which it is deleted. It must be shorter
+                  than the interval, defaults to deleting the replaced key straight
+                  away
+                type: string
+            required:
+            - interval
+            type: object
+          labels:
+            additionalProperties:
+              type: string
+            description: Labels are set on the GCP project, they must follow the GCP
+              rules for labels, lowercase letters, digits, dashes and underscores
+              of at most 63 characters
+            type: object
           parentId:
             description: ParentId is the type specific ID of the parent this project
               has
             type: string
           parentType:
             description: 'ParentType is the type of parent this project has Valid
-              types are: "organization", "folder", and "project"'
+              types are: "organization", "folder", and "project" Defaults to the annotations
+              of the namespace'
             enum:
             - organization
             - folder
@@ -71,16 +131,98 @@ definitions:
             type: string
           projectId:
             description: ProjectId is the GCP project ID
+            maxLength: 30
+            minLength: 6
             type: string
           projectName:
             description: ProjectName is the GCP project name
+            maxLength: 30
+            minLength: 4
+            type: string
+          resyncInterval:
+            description: ResyncInterval is how often the GCP project is checked against
+              the spec, defaults to the interval the operator is started with
             type: string
           serviceAccountName:
             description: ServiceAccountName is the name used when creating the service
-              account e.g. 'hub-admin'
+              account e.g. 'hub-admin', defaults to the name the operator is started
+              with
+            maxLength: 30
+            minLength: 6
             type: string
-          use:
-            description: GCPAdminToken is a reference to the gcp token object to use
+          services:
+            description: Services are the APIs to enable in the project on top of
+              the baseline required by the operator e.g. 'container.googleapis.com'
+            items:
+              type: string
+            type: array
+          token:
+            description: Token is the bearer token used to setup the initial GCP admin
+              project and service account You must grab a token using 'gcloud auth
+              print-access-token you@example.com' Either token or tokenRef must be
+              set, tokenRef is preferred
+            type: string
+          tokenRef:
+            description: TokenRef refers to a Secret holding the bearer token, the
+              key defaults to 'token'
+            properties:
+              key:
+                description: Key is the key in the Secret holding the value, defaults
+                  to 'key.json' for service account keys and 'token' for bearer tokens
+                type: string
+              name:
+                description: Name is the name of the Secret
+                type: string
+            required:
+            - name
+            type: object
+        required:
+        - billingAccountName
+        - projectId
+        - projectName
+        type: object
+      status:
+        description: GCPAdminProjectStatus defines the observed state of GCPAdminProject
+        properties:
+          conditions:
+            description: Conditions are the latest observations of the state of the
+              resource
+            items:
+              description: Condition describes an aspect of the state of a resource
+              properties:
+                lastTransitionTime:
+                  description: LastTransitionTime is when the condition last changed
+                    status
+                  format: date-time
+                  type: string
+                message:
+                  description: Message is a human readable description of the last
+                    transition
+                  type: string
+                observedGeneration:
+                  description: ObservedGeneration is the generation of the resource
+                    the condition was set for
+                  format: int64
+                  type: integer
+                reason:
+                  description: Reason is a one word CamelCase reason for the last
+                    transition
+                  type: string
+                status:
+                  description: Status is the status of the condition, one of True,
+                    False or Unknown
+                  type: string
+                type:
+                  description: Type is the type of the condition
+                  type: string
+              required:
+              - status
+              - type
+              type: object
+            type: array
+          credentialsRef:
+            description: CredentialsRef refers to the GCPCredentials generated for
+              the service account of the project
             properties:
               group:
                 description: Group is the api group
@@ -104,73 +246,124 @@ definitions:
             - namespace
             - version
             type: object
-        required:
-        - billingAccountName
-        - parentId
-        - parentType
-        - projectId
-        - projectName
-        - serviceAccountName
-        - use
-        type: object
-      status:
-        description: GCPAdminProjectStatus defines the observed state of GCPAdminProject
-        properties:
-          status:
-            description: Status provides a overall status
-            type: string
-        required:
-        - status
-        type: object
-    type: object
-  GCPAdminToken:
-    description: GCPAdminToken is the Schema for the gcpadmintokens API
-    properties:
-      apiVersion:
-        description: 'APIVersion defines the versioned schema of this representation
-          of an object. Servers should convert recognized schemas to the latest internal
-          value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources'
-        type: string
-      kind:
-        description: 'Kind is a string value representing the REST resource this object
-          represents. Servers may infer this from the endpoint the client submits
-          requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds'
-        type: string
-      metadata:
-        type: object
-      spec:
-        description: GCPAdminTokenSpec defines the desired state of GCPAdminToken
-        properties:
-          organizationId:
-            description: Organization is the GCP org you wish the projects to reside
-              within
-            type: string
-          projectId:
-            description: ProjectId is the GCP project ID these credentials belong
-              to
-            type: string
-          token:
-            description: Token is the bearer token used to setup the initial GCP admin
-              project and service account You must grab a token using 'gcloud auth
-              print-access-token you@example.com'
+          drift:
+            description: Drift are the differences from the spec found at the last
+              resync
+            items:
+              type: string
+            type: array
+          enabledServices:
+            description: EnabledServices are the services the operator has enabled
+              in the project
+            items:
+              type: string
+            type: array
+          keys:
+            description: Keys are the service account keys issued for the generated
+              credentials, the current key and any replaced keys waiting out the overlap
+              of the key rotation
+            items:
+              description: ServiceAccountKey records a service account key issued
+                for the generated credentials
+              properties:
+                creationTime:
+                  description: CreationTime is when the key was issued
+                  format: date-time
+                  type: string
+                id:
+                  description: ID is the ID of the key
+                  type: string
+                name:
+                  description: Name is the resource name of the key
+                  type: string
+                replacedTime:
+                  description: ReplacedTime is when a newer key took over, the key
+                    is deleted once the overlap has passed
+                  format: date-time
+                  type: string
+              required:
+              - creationTime
+              - id
+              - name
+              type: object
+            type: array
+          labelKeys:
+            description: LabelKeys are the keys of the labels the operator has set
+              on the GCP project from the spec and namespace, so labels which are
+              no longer wanted can be removed
+            items:
+              type: string
+            type: array
+          lastSyncTime:
+            description: LastSyncTime is when the GCP project was last checked against
+              the spec
+            format: date-time
             type: string
-        required:
-        - organizationId
-        - projectId
-        - token
-        type: object
-      status:
-        description: GCPAdminTokenStatus defines the observed state of GCPAdminToken
-        properties:
+          operations:
+            description: Operations are the long running GCP operations currently
+              being waited on
+            items:
+              description: Operation is a long running GCP operation the reconciler
+                is waiting on
+              properties:
+                name:
+                  description: Name is the name of the operation
+                  type: string
+                service:
+                  description: Service is the api owning the operation, cloudresourcemanager
+                    or servicemanagement
+                  type: string
+                startTime:
+                  description: StartTime is when the operation was started
+                  format: date-time
+                  type: string
+                step:
+                  description: Step is the provisioning step which started the operation
+                  type: string
+              required:
+              - name
+              - service
+              - startTime
+              - step
+              type: object
+            type: array
           status:
             description: Status provides a overall status
             type: string
-          verified:
-            description: Verified checks that the token is ok and valid
+          steps:
+            description: Steps records the progress of each of the provisioning steps
+            items:
+              description: StepStatus records the progress of a provisioning step
+              properties:
+                lastTransitionTime:
+                  description: LastTransitionTime is when the step last changed status
+                  format: date-time
+                  type: string
+                message:
+                  description: Message is the error the step last failed with
+                  type: string
+                name:
+                  description: Name is the name of the step
+                  type: string
+                observedGeneration:
+                  description: ObservedGeneration is the generation of the resource
+                    the step was last completed for
+                  format: int64
+                  type: integer
+                status:
+                  description: Status is the status of the step
+                  type: string
+              required:
+              - name
+              - status
+              type: object
+            type: array
+          tokenDeleted:
+            description: TokenDeleted records that the token Secret was deleted after
+              bootstrap, so a token provided again later is left in place
             type: boolean
         required:
         - status
-        - verified
         type: object
     type: object
   GCPCredentials:
@@ -191,30 +384,124 @@ definitions:
       spec:
         description: GCPCredentialsSpec defines the desired state of GCPCredentials
         properties:
+          impersonateServiceAccount:
+            description: ImpersonateServiceAccount is the email of a service account
+              the operator acts as with short lived tokens from the IAM Credentials
+              api, rather than a key. The tokens are issued to the key or keyRef when
+              given, otherwise to the operator itself, which is only allowed for the
+              credentials generated for a GCPProject or GCPAdminProject. The holder
+              of the tokens needs roles/iam.serviceAccountTokenCreator on the service
+              account
+            type: string
           key:
             description: Key is the credential used to create GCP projects You must
               create a service account with resourcemanager.projectCreator and billing.user
-              roles at the organization level and use the JSON payload here
+              roles at the organization level and use the JSON payload here One of
+              key, keyRef or impersonateServiceAccount must be set, keyRef is preferred
+              over key
             type: string
+          keyRef:
+            description: KeyRef refers to a Secret holding the JSON service account
+              key
+            properties:
+              key:
+                description: Key is the key in the Secret holding the value, defaults
+                  to 'key.json' for service account keys and 'token' for bearer tokens
+                type: string
+              name:
+                description: Name is the name of the Secret
+                type: string
+            required:
+            - name
+            type: object
           organizationId:
             description: Organization is the GCP org you wish the projects to reside
               within
+            pattern: ^[0-9]+$
             type: string
           projectId:
             description: ProjectId is the GCP project ID these credentials belong
               to
+            maxLength: 30
+            minLength: 6
             type: string
+          tokenSecretRef:
+            description: TokenSecretRef has the operator keep an access token of the
+              impersonated service account in the Secret, refreshed before it expires,
+              for consumers which cannot impersonate it themselves. The key defaults
+              to 'token'
+            properties:
+              key:
+                description: Key is the key in the Secret holding the value, defaults
+                  to 'key.json' for service account keys and 'token' for bearer tokens
+                type: string
+              name:
+                description: Name is the name of the Secret
+                type: string
+            required:
+            - name
+            type: object
         required:
-        - key
         - organizationId
         - projectId
         type: object
       status:
         description: GCPCredentialsStatus defines the observed state of GCPCredentials
         properties:
+          conditions:
+            description: Conditions are the latest observations of the state of the
+              resource
+            items:
+              description: Condition describes an aspect of the state of a resource
+              properties:
+                lastTransitionTime:
+                  description: LastTransitionTime is when the condition last changed
+                    status
+                  format: date-time
+                  type: string
+                message:
+                  description: Message is a human readable description of the last
+                    transition
+                  type: string
+                observedGeneration:
+                  description: ObservedGeneration is the generation of the resource
+                    the condition was set for
+                  format: int64
+                  type: integer
+                reason:
+                  description: Reason is a one word CamelCase reason for the last
+                    transition
+                  type: string
+                status:
+                  description: Status is the status of the condition, one of True,
+                    False or Unknown
+                  type: string
+                type:
+                  description: Type is the type of the condition
+                  type: string
+              required:
+              - status
+              - type
+              type: object
+            type: array
+          lastVerified:
+            description: LastVerified is the time the credentials were last verified
+            format: date-time
+            type: string
+          missingPermissions:
+            description: MissingPermissions is a list of the required permissions
+              the credentials do not hold on the organization
+            items:
+              type: string
+            type: array
           status:
             description: Status provides a overall status
             type: string
+          tokenExpiryTime:
+            description: TokenExpiryTime is when the access token in the Secret of
+              tokenSecretRef expires
+            format: date-time
+            type: string
           verified:
             description: Verified checks that the credentials are ok and valid
             type: boolean
@@ -240,28 +527,151 @@ definitions:
       spec:
         description: GCPProjectSpec defines the desired state of GCPProject
         properties:
+          adoptionPolicy:
+            description: 'AdoptionPolicy controls what happens when the GCP project
+              already exists and was not created by the operator Valid policies are:
+              "Fail" (default) and "Adopt"'
+            enum:
+            - Adopt
+            - Fail
+            type: string
           billingAccountName:
             description: BillingAccountName is the resource name of the billing account
               associated with the project
             type: string
+          credentialsPolicy:
+            description: 'CredentialsPolicy controls how the generated credentials
+              act as the service account Valid policies are: "Key" (default) and "Impersonate",
+              which issues no key and needs the operator to hold roles/iam.serviceAccountTokenCreator
+              on the service account'
+            enum:
+            - Key
+            - Impersonate
+            type: string
+          deletionPolicy:
+            description: 'DeletionPolicy controls what happens to the GCP project
+              when this resource is deleted Valid policies are: "Delete" (default),
+              "Orphan" and "DisableBilling"'
+            enum:
+            - Delete
+            - Orphan
+            - DisableBilling
+            type: string
+          disableRemovedServices:
+            description: DisableRemovedServices disables services in the project once
+              they are removed from services
+            type: boolean
+          driftPolicy:
+            description: 'DriftPolicy controls what happens when the GCP project is
+              found to no longer match the spec Valid policies are: "Correct" (default)
+              and "Report"'
+            enum:
+            - Correct
+            - Report
+            type: string
+          iam:
+            description: IAM are the role bindings to apply to the project, by default
+              the generated service account is granted 'roles/owner'
+            properties:
+              bindings:
+                description: Bindings are the role bindings to apply
+                items:
+                  description: IAMBinding binds members to a role in the project
+                  properties:
+                    members:
+                      description: Members are the members to grant the role to, in
+                        the form used by GCP e.g. 'group:team@example.com', or '$(serviceAccount)'
+                        for the generated service account
+                      items:
+                        type: string
+                      type: array
+                    role:
+                      description: Role is the role to grant e.g. 'roles/viewer'
+                      type: string
+                  required:
+                  - members
+                  - role
+                  type: object
+                type: array
+              mode:
+                description: 'Mode controls how the bindings are applied Valid modes
+                  are: "Additive" (default) and "Authoritative"'
+                enum:
+                - Additive
+                - Authoritative
+                type: string
+            type: object
+          keyRotation:
+            description: KeyRotation periodically replaces the key of the generated
+              credentials, by default the key is never replaced
+            properties:
+              interval:
+                description: Interval is how long a key is used before it is replaced
+                  e.g. '2160h' for 90 days
+                type: string
+              overlap:
+                description: Overlap is how long a replaced key stays valid so consumers
+                  can pick up the new key, after which it is deleted. It must be shorter
+                  than the interval, defaults to deleting the replaced key straight
+                  away
+                type: string
+            required:
+            - interval
+            type: object
+          labels:
+            additionalProperties:
+              type: string
+            description: Labels are set on the GCP project, they must follow the GCP
+              rules for labels, lowercase letters, digits, dashes and underscores
+              of at most 63 characters
+            type: object
           parentId:
             description: ParentId is the type specific ID of the parent this project
               has
             type: string
           parentType:
             description: 'ParentType is the type of parent this project has Valid
-              types are: "organization", "folder", and "project"'
+              types are: "organization", "folder", and "project" Defaults to the namespace
+              annotations or the organization of the credentials'
             enum:
             - organization
             - folder
             - project
             type: string
           projectId:
-            description: ProjectId is the GCP project ID
+            description: ProjectId is the GCP project ID, either projectId or projectIdPrefix
+              must be set
+            maxLength: 30
+            minLength: 6
+            type: string
+          projectIdPrefix:
+            description: ProjectIdPrefix has the operator generate an unused project
+              ID of the form 'prefix-abc123' when no projectId is given, the chosen
+              ID is recorded in the status
+            maxLength: 23
             type: string
           projectName:
             description: ProjectName is the GCP project name
+            maxLength: 30
+            minLength: 4
+            type: string
+          resyncInterval:
+            description: ResyncInterval is how often the GCP project is checked against
+              the spec, defaults to the interval the operator is started with
             type: string
+          serviceAccountName:
+            description: ServiceAccountName is the name used when creating the service
+              account e.g. 'hub-admin', defaults to the name the operator is started
+              with
+            maxLength: 30
+            minLength: 6
+            type: string
+          services:
+            description: Services are the APIs to enable in the project on top of
+              the baseline required by the operator e.g. 'container.googleapis.com'
+            items:
+              type: string
+            type: array
           use:
             description: GCPCredentials is a reference to the gcp credentials object
               to use
@@ -290,18 +700,197 @@ definitions:
             type: object
         required:
         - billingAccountName
-        - parentId
-        - parentType
-        - projectId
         - projectName
         - use
         type: object
       status:
         description: GCPProjectStatus defines the observed state of GCPProject
         properties:
+          conditions:
+            description: Conditions are the latest observations of the state of the
+              resource
+            items:
+              description: Condition describes an aspect of the state of a resource
+              properties:
+                lastTransitionTime:
+                  description: LastTransitionTime is when the condition last changed
+                    status
+                  format: date-time
+                  type: string
+                message:
+                  description: Message is a human readable description of the last
+                    transition
+                  type: string
+                observedGeneration:
+                  description: ObservedGeneration is the generation of the resource
+                    the condition was set for
+                  format: int64
+                  type: integer
+                reason:
+                  description: Reason is a one word CamelCase reason for the last
+                    transition
+                  type: string
+                status:
+                  description: Status is the status of the condition, one of True,
+                    False or Unknown
+                  type: string
+                type:
+                  description: Type is the type of the condition
+                  type: string
+              required:
+              - status
+              - type
+              type: object
+            type: array
+          credentialsRef:
+            description: CredentialsRef refers to the GCPCredentials generated for
+              the service account of the project
+            properties:
+              group:
+                description: Group is the api group
+                type: string
+              kind:
+                description: Kind is the name of the resource under the group
+                type: string
+              name:
+                description: Name is name of the resource
+                type: string
+              namespace:
+                description: Namespace is the location of the object
+                type: string
+              version:
+                description: Version is the group version
+                type: string
+            required:
+            - group
+            - kind
+            - name
+            - namespace
+            - version
+            type: object
+          drift:
+            description: Drift are the differences from the spec found at the last
+              resync
+            items:
+              type: string
+            type: array
+          enabledServices:
+            description: EnabledServices are the services the operator has enabled
+              in the project
+            items:
+              type: string
+            type: array
+          keys:
+            description: Keys are the service account keys issued for the generated
+              credentials, the current key and any replaced keys waiting out the overlap
+              of the key rotation
+            items:
+              description: ServiceAccountKey records a service account key issued
+                for the generated credentials
+              properties:
+                creationTime:
+                  description: CreationTime is when the key was issued
+                  format: date-time
+                  type: string
+                id:
+                  description: ID is the ID of the key
+                  type: string
+                name:
+                  description: Name is the resource name of the key
+                  type: string
+                replacedTime:
+                  description: ReplacedTime is when a newer key took over, the key
+                    is deleted once the overlap has passed
+                  format: date-time
+                  type: string
+              required:
+              - creationTime
+              - id
+              - name
+              type: object
+            type: array
+          labelKeys:
+            description: LabelKeys are the keys of the labels the operator has set
+              on the GCP project from the spec and namespace, so labels which are
+              no longer wanted can be removed
+            items:
+              type: string
+            type: array
+          lastSyncTime:
+            description: LastSyncTime is when the GCP project was last checked against
+              the spec
+            format: date-time
+            type: string
+          managedRoles:
+            description: ManagedRoles are the roles the operator has applied authoritatively
+              from spec.iam, so the members of roles which are dropped from it can
+              be removed
+            items:
+              type: string
+            type: array
+          operations:
+            description: Operations are the long running GCP operations currently
+              being waited on
+            items:
+              description: Operation is a long running GCP operation the reconciler
+                is waiting on
+              properties:
+                name:
+                  description: Name is the name of the operation
+                  type: string
+                service:
+                  description: Service is the api owning the operation, cloudresourcemanager
+                    or servicemanagement
+                  type: string
+                startTime:
+                  description: StartTime is when the operation was started
+                  format: date-time
+                  type: string
+                step:
+                  description: Step is the provisioning step which started the operation
+                  type: string
+              required:
+              - name
+              - service
+              - startTime
+              - step
+              type: object
+            type: array
+          projectId:
+            description: ProjectId is the ID of the GCP project, as given in the spec
+              or generated from the prefix
+            type: string
           status:
             description: Status provides a overall status
             type: string
+          steps:
+            description: Steps records the progress of each of the provisioning steps
+            items:
+              description: StepStatus records the progress of a provisioning step
+              properties:
+                lastTransitionTime:
+                  description: LastTransitionTime is when the step last changed status
+                  format: date-time
+                  type: string
+                message:
+                  description: Message is the error the step last failed with
+                  type: string
+                name:
+                  description: Name is the name of the step
+                  type: string
+                observedGeneration:
+                  description: ObservedGeneration is the generation of the resource
+                    the step was last completed for
+                  format: int64
+                  type: integer
+                status:
+                  description: Status is the status of the step
+                  type: string
+              required:
+              - name
+              - status
+              type: object
+            type: array
         required:
         - status
         type: object
@@ -315,6 +904,19 @@ kind: CustomResourceDefinition
 metadata:
   name: gcpadminprojects.gcp.compute.hub.appvia.io
 spec:
+  additionalPrinterColumns:
+  - JSONPath: .spec.projectId
+    name: Project ID
+    type: string
+  - JSONPath: .status.conditions[?(@.type=="Ready")].status
+    name: Ready
+    type: string
+  - JSONPath: .status.conditions[?(@.type=="Ready")].reason
+    name: Reason
+    type: string
+  - JSONPath: .metadata.creationTimestamp
+    name: Age
+    type: date
   group: gcp.compute.hub.appvia.io
   names:
     kind: GCPAdminProject
@@ -347,13 +949,73 @@ spec:
               description: BillingAccountName is the resource name of the billing
                 account associated with the project e.g. '012345-567890-ABCDEF'
               type: string
+            credentialsPolicy:
+              description: 'CredentialsPolicy controls how the generated credentials
+                act as the service account Valid policies are: "Key" (default) and
+                "Impersonate", which issues no key and needs the operator to hold
+                roles/iam.serviceAccountTokenCreator on the service account'
+              enum:
+              - Key
+              - Impersonate
+              type: string
+            deleteTokenAfterBootstrap:
+              description: DeleteTokenAfterBootstrap deletes the Secret referred to
+                by tokenRef once the credentials for the admin service account have
+                been issued
+              type: boolean
+            deletionPolicy:
+              description: 'DeletionPolicy controls what happens to the GCP project
+                when this resource is deleted Valid policies are: "Delete" (default),
+                "Orphan" and "DisableBilling"'
+              enum:
+              - Delete
+              - Orphan
+              - DisableBilling
+              type: string
+            disableRemovedServices:
+              description: DisableRemovedServices disables services in the project
+                once they are removed from services
+              type: boolean
+            driftPolicy:
+              description: 'DriftPolicy controls what happens when the GCP project
+                is found to no longer match the spec Valid policies are: "Correct"
+                (default) and "Report"'
+              enum:
+              - Correct
+              - Report
+              type: string
+            keyRotation:
+              description: KeyRotation periodically replaces the key of the generated
+                credentials, by default the key is never replaced
+              properties:
+                interval:
+                  description: Interval is how long a key is used before it is replaced
+                    e.g. '2160h' for 90 days
+                  type: string
+                overlap:
+                  description: Overlap is how long a replaced key stays valid so consumers
+                    can pick up the new key, after which it is deleted. It must be
+                    shorter than the interval, defaults to deleting the replaced key
+                    straight away
+                  type: string
+              required:
+              - interval
+              type: object
+            labels:
+              additionalProperties:
+                type: string
+              description: Labels are set on the GCP project, they must follow the
+                GCP rules for labels, lowercase letters, digits, dashes and underscores
+                of at most 63 characters
+              type: object
             parentId:
               description: ParentId is the type specific ID of the parent this project
                 has
               type: string
             parentType:
               description: 'ParentType is the type of parent this project has Valid
-                types are: "organization", "folder", and "project"'
+                types are: "organization", "folder", and "project" Defaults to the
+                annotations of the namespace'
               enum:
               - organization
               - folder
@@ -361,17 +1023,100 @@ spec:
               type: string
             projectId:
               description: ProjectId is the GCP project ID
+              maxLength: 30
+              minLength: 6
               type: string
             projectName:
               description: ProjectName is the GCP project name
+              maxLength: 30
+              minLength: 4
+              type: string
+            resyncInterval:
+              description: ResyncInterval is how often the GCP project is checked
+                against the spec, defaults to the interval the operator is started
+                with
               type: string
             serviceAccountName:
               description: ServiceAccountName is the name used when creating the service
-                account e.g. 'hub-admin'
+                account e.g. 'hub-admin', defaults to the name the operator is started
+                with
+              maxLength: 30
+              minLength: 6
               type: string
-            use:
-              description: GCPAdminToken is a reference to the gcp token object to
-                use
+            services:
+              description: Services are the APIs to enable in the project on top of
+                the baseline required by the operator e.g. 'container.googleapis.com'
+              items:
+                type: string
+              type: array
+            token:
+              description: Token is the bearer token used to setup the initial GCP
+                admin project and service account You must grab a token using 'gcloud
+                auth print-access-token you@example.com' Either token or tokenRef
+                must be set, tokenRef is preferred
+              type: string
+            tokenRef:
+              description: TokenRef refers to a Secret holding the bearer token, the
+                key defaults to 'token'
+              properties:
+                key:
+                  description: Key is the key in the Secret holding the value, defaults
+                    to 'key.json' for service account keys and 'token' for bearer
+                    tokens
+                  type: string
+                name:
+                  description: Name is the name of the Secret
+                  type: string
+              required:
+              - name
+              type: object
+          required:
+          - billingAccountName
+          - projectId
+          - projectName
+          type: object
+        status:
+          description: GCPAdminProjectStatus defines the observed state of GCPAdminProject
+          properties:
+            conditions:
+              description: Conditions are the latest observations of the state of
+                the resource
+              items:
+                description: Condition describes an aspect of the state of a resource
+                properties:
+                  lastTransitionTime:
+                    description: LastTransitionTime is when the condition last changed
+                      status
+                    format: date-time
+                    type: string
+                  message:
+                    description: Message is a human readable description of the last
+                      transition
+                    type: string
+                  observedGeneration:
+                    description: ObservedGeneration is the generation of the resource
+                      the condition was set for
+                    format: int64
+                    type: integer
+                  reason:
+                    description: Reason is a one word CamelCase reason for the last
+                      transition
+                    type: string
+                  status:
+                    description: Status is the status of the condition, one of True,
+                      False or Unknown
+                    type: string
+                  type:
+                    description: Type is the type of the condition
+                    type: string
+                required:
+                - status
+                - type
+                type: object
+              type: array
+            credentialsRef:
+              description: CredentialsRef refers to the GCPCredentials generated for
+                the service account of the project
               properties:
                 group:
                   description: Group is the api group
@@ -395,94 +1140,126 @@ spec:
               - namespace
               - version
               type: object
-          required:
-          - billingAccountName
-          - parentId
-          - parentType
-          - projectId
-          - projectName
-          - serviceAccountName
-          - use
-          type: object
-        status:
-          description: GCPAdminProjectStatus defines the observed state of GCPAdminProject
-          properties:
-            status:
-              description: Status provides a overall status
-              type: string
-          required:
-          - status
-          type: object
-      type: object
-  version: v1alpha1
-  versions:
-  - name: v1alpha1
-    served: true
-    storage: true
----
-apiVersion: apiextensions.k8s.io/v1beta1
-kind: CustomResourceDefinition
-metadata:
-  name: gcpadmintokens.gcp.compute.hub.appvia.io
-spec:
-  group: gcp.compute.hub.appvia.io
-  names:
-    kind: GCPAdminToken
-    listKind: GCPAdminTokenList
-    plural: gcpadmintokens
-    singular: gcpadmintoken
-  scope: Namespaced
-  subresources:
-    status: {}
-  validation:
-    openAPIV3Schema:
-      description: GCPAdminToken is the Schema for the gcpadmintokens API
-      properties:
-        apiVersion:
-          description: 'APIVersion defines the versioned schema of this representation
-            of an object. Servers should convert recognized schemas to the latest
-            internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources'
-          type: string
-        kind:
-          description: 'Kind is a string value representing the REST resource this
-            object represents. Servers may infer this from the endpoint the client
-            submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds'
-          type: string
-        metadata:
-          type: object
-        spec:
-          description: GCPAdminTokenSpec defines the desired state of GCPAdminToken
-          properties:
-            organizationId:
-              description: Organization is the GCP org you wish the projects to reside
-                within
-              type: string
-            projectId:
-              description: ProjectId is the GCP project ID these credentials belong
-                to
-              type: string
-            token:
-              description: Token is the bearer token used to setup the initial GCP
-                admin project and service account You must grab a token using 'gcloud
-                auth print-access-token you@example.com'
+            drift:
+              description: Drift are the differences from the spec found at the last
+                resync
+              items:
+                type: string
+              type: array
+            enabledServices:
+              description: EnabledServices are the services the operator has enabled
+                in the project
+              items:
+                type: string
+              type: array
+            keys:
+              description: Keys are the service account keys issued for the generated
+                credentials, the current key and any replaced keys waiting out the
+                overlap of the key rotation
+              items:
+                description: ServiceAccountKey records a service account key issued
+                  for the generated credentials
+                properties:
+                  creationTime:
+                    description: CreationTime is when the key was issued
+                    format: date-time
+                    type: string
+                  id:
+                    description: ID is the ID of the key
+                    type: string
+                  name:
+                    description: Name is the resource name of the key
+                    type: string
+                  replacedTime:
+                    description: ReplacedTime is when a newer key took over, the key
+                      is deleted once the overlap has passed
+                    format: date-time
+                    type: string
+                required:
+                - creationTime
+                - id
+                - name
+                type: object
+              type: array
+            labelKeys:
+              description: LabelKeys are the keys of the labels the operator has set
+                on the GCP project from the spec and namespace, so labels which are
+                no longer wanted can be removed
+              items:
+                type: string
+              type: array
+            lastSyncTime:
+              description: LastSyncTime is when the GCP project was last checked against
+                the spec
+              format: date-time
               type: string
-          required:
-          - organizationId
-          - projectId
-          - token
-          type: object
-        status:
-          description: GCPAdminTokenStatus defines the observed state of GCPAdminToken
-          properties:
+            operations:
+              description: Operations are the long running GCP operations currently
+                being waited on
+              items:
+                description: Operation is a long running GCP operation the reconciler
+                  is waiting on
+                properties:
+                  name:
+                    description: Name is the name of the operation
+                    type: string
+                  service:
+                    description: Service is the api owning the operation, cloudresourcemanager
+                      or servicemanagement
+                    type: string
+                  startTime:
+                    description: StartTime is when the operation was started
+                    format: date-time
+                    type: string
+                  step:
+                    description: Step is the provisioning step which started the operation
+                    type: string
+                required:
+                - name
+                - service
+                - startTime
+                - step
+                type: object
+              type: array
             status:
               description: Status provides a overall status
               type: string
-            verified:
-              description: Verified checks that the token is ok and valid
+            steps:
+              description: Steps records the progress of each of the provisioning
+                steps
+              items:
+                description: StepStatus records the progress of a provisioning step
+                properties:
+                  lastTransitionTime:
+                    description: LastTransitionTime is when the step last changed
+                      status
+                    format: date-time
+                    type: string
+                  message:
+                    description: Message is the error the step last failed with
+                    type: string
+                  name:
+                    description: Name is the name of the step
+                    type: string
+                  observedGeneration:
+                    description: ObservedGeneration is the generation of the resource
+                      the step was last completed for
+                    format: int64
+                    type: integer
+                  status:
+                    description: Status is the status of the step
+                    type: string
+                required:
+                - name
+                - status
+                type: object
+              type: array
+            tokenDeleted:
+              description: TokenDeleted records that the token Secret was deleted
+                after bootstrap, so a token provided again later is left in place
               type: boolean
           required:
           - status
-          - verified
           type: object
       type: object
   version: v1alpha1
@@ -496,6 +1273,19 @@ kind: CustomResourceDefinition
 metadata:
   name: gcpcredentials.gcp.compute.hub.appvia.io
 spec:
+  additionalPrinterColumns:
+  - JSONPath: .spec.organizationId
+    name: Organization
+    type: string
+  - JSONPath: .status.conditions[?(@.type=="Ready")].status
+    name: Ready
+    type: string
+  - JSONPath: .status.conditions[?(@.type=="Ready")].reason
+    name: Reason
+    type: string
+  - JSONPath: .metadata.creationTimestamp
+    name: Age
+    type: date
   group: gcp.compute.hub.appvia.io
   names:
     kind: GCPCredentials
@@ -524,30 +1314,126 @@ spec:
         spec:
           description: GCPCredentialsSpec defines the desired state of GCPCredentials
           properties:
+            impersonateServiceAccount:
+              description: ImpersonateServiceAccount is the email of a service account
+                the operator acts as with short lived tokens from the IAM Credentials
+                api, rather than a key. The tokens are issued to the key or keyRef
+                when given, otherwise to the operator itself, which is only allowed
+                for the credentials generated for a GCPProject or GCPAdminProject.
+                The holder of the tokens needs roles/iam.serviceAccountTokenCreator
+                on the service account
+              type: string
             key:
               description: Key is the credential used to create GCP projects You must
                 create a service account with resourcemanager.projectCreator and billing.user
-                roles at the organization level and use the JSON payload here
+                roles at the organization level and use the JSON payload here One
+                of key, keyRef or impersonateServiceAccount must be set, keyRef is
+                preferred over key
               type: string
+            keyRef:
+              description: KeyRef refers to a Secret holding the JSON service account
+                key
+              properties:
+                key:
+                  description: Key is the key in the Secret holding the value, defaults
+                    to 'key.json' for service account keys and 'token' for bearer
+                    tokens
+                  type: string
+                name:
+                  description: Name is the name of the Secret
+                  type: string
+              required:
+              - name
+              type: object
             organizationId:
               description: Organization is the GCP org you wish the projects to reside
                 within
+              pattern: ^[0-9]+$
               type: string
             projectId:
               description: ProjectId is the GCP project ID these credentials belong
                 to
+              maxLength: 30
+              minLength: 6
               type: string
+            tokenSecretRef:
+              description: TokenSecretRef has the operator keep an access token of
+                the impersonated service account in the Secret, refreshed before it
+                expires, for consumers which cannot impersonate it themselves. The
+                key defaults to 'token'
+              properties:
+                key:
+                  description: Key is the key in the Secret holding the value, defaults
+                    to 'key.json' for service account keys and 'token' for bearer
+                    tokens
+                  type: string
+                name:
+                  description: Name is the name of the Secret
+                  type: string
+              required:
+              - name
+              type: object
           required:
-          - key
           - organizationId
           - projectId
           type: object
         status:
           description: GCPCredentialsStatus defines the observed state of GCPCredentials
           properties:
+            conditions:
+              description: Conditions are the latest observations of the state of
+                the resource
+              items:
+                description: Condition describes an aspect of the state of a resource
+                properties:
+                  lastTransitionTime:
+                    description: LastTransitionTime is when the condition last changed
+                      status
+                    format: date-time
+                    type: string
+                  message:
+                    description: Message is a human readable description of the last
+                      transition
+                    type: string
+                  observedGeneration:
+                    description: ObservedGeneration is the generation of the resource
+                      the condition was set for
+                    format: int64
+                    type: integer
+                  reason:
+                    description: Reason is a one word CamelCase reason for the last
+                      transition
+                    type: string
+                  status:
+                    description: Status is the status of the condition, one of True,
+                      False or Unknown
+                    type: string
+                  type:
+                    description: Type is the type of the condition
+                    type: string
+                required:
+                - status
+                - type
+                type: object
+              type: array
+            lastVerified:
+              description: LastVerified is the time the credentials were last verified
+              format: date-time
+              type: string
+            missingPermissions:
+              description: MissingPermissions is a list of the required permissions
+                the credentials do not hold on the organization
+              items:
+                type: string
+              type: array
             status:
               description: Status provides a overall status
               type: string
+            tokenExpiryTime:
+              description: TokenExpiryTime is when the access token in the Secret
+                of tokenSecretRef expires
+              format: date-time
+              type: string
             verified:
               description: Verified checks that the credentials are ok and valid
               type: boolean
@@ -566,6 +1452,19 @@ kind: CustomResourceDefinition
 metadata:
   name: gcpprojects.gcp.compute.hub.appvia.io
 spec:
+  additionalPrinterColumns:
+  - JSONPath: .status.projectId
+    name: Project ID
+    type: string
+  - JSONPath: .status.conditions[?(@.type=="Ready")].status
+    name: Ready
+    type: string
+  - JSONPath: .status.conditions[?(@.type=="Ready")].reason
+    name: Reason
+    type: string
+  - JSONPath: .metadata.creationTimestamp
+    name: Age
+    type: date
   group: gcp.compute.hub.appvia.io
   names:
     kind: GCPProject
@@ -594,28 +1493,152 @@ spec:
         spec:
           description: GCPProjectSpec defines the desired state of GCPProject
           properties:
+            adoptionPolicy:
+              description: 'AdoptionPolicy controls what happens when the GCP project
+                already exists and was not created by the operator Valid policies
+                are: "Fail" (default) and "Adopt"'
+              enum:
+              - Adopt
+              - Fail
+              type: string
             billingAccountName:
               description: BillingAccountName is the resource name of the billing
                 account associated with the project
               type: string
+            credentialsPolicy:
+              description: 'CredentialsPolicy controls how the generated credentials
+                act as the service account Valid policies are: "Key" (default) and
+                "Impersonate", which issues no key and needs the operator to hold
+                roles/iam.serviceAccountTokenCreator on the service account'
+              enum:
+              - Key
+              - Impersonate
+              type: string
+            deletionPolicy:
+              description: 'DeletionPolicy controls what happens to the GCP project
+                when this resource is deleted Valid policies are: "Delete" (default),
+                "Orphan" and "DisableBilling"'
+              enum:
+              - Delete
+              - Orphan
+              - DisableBilling
+              type: string
+            disableRemovedServices:
+              description: DisableRemovedServices disables services in the project
+                once they are removed from services
+              type: boolean
+            driftPolicy:
+              description: 'DriftPolicy controls what happens when the GCP project
+                is found to no longer match the spec Valid policies are: "Correct"
+                (default) and "Report"'
+              enum:
+              - Correct
+              - Report
+              type: string
+            iam:
+              description: IAM are the role bindings to apply to the project, by default
+                the generated service account is granted 'roles/owner'
+              properties:
+                bindings:
+                  description: Bindings are the role bindings to apply
+                  items:
+                    description: IAMBinding binds members to a role in the project
+                    properties:
+                      members:
+                        description: Members are the members to grant the role to,
+                          in the form used by GCP e.g. 'group:team@example.com', or
+                          '$(serviceAccount)' for the generated service account
+                        items:
+                          type: string
+                        type: array
+                      role:
+                        description: Role is the role to grant e.g. 'roles/viewer'
+                        type: string
+                    required:
+                    - members
+                    - role
+                    type: object
+                  type: array
+                mode:
+                  description: 'Mode controls how the bindings are applied Valid modes
+                    are: "Additive" (default) and "Authoritative"'
+                  enum:
+                  - Additive
+                  - Authoritative
+                  type: string
+              type: object
+            keyRotation:
+              description: KeyRotation periodically replaces the key of the generated
+                credentials, by default the key is never replaced
+              properties:
+                interval:
+                  description: Interval is how long a key is used before it is replaced
+                    e.g. '2160h' for 90 days
+                  type: string
+                overlap:
+                  description: Overlap is how long a replaced key stays valid so consumers
+                    can pick up the new key, after which it is deleted. It must be
+                    shorter than the interval, defaults to deleting the replaced key
+                    straight away
+                  type: string
+              required:
+              - interval
+              type: object
+            labels:
+              additionalProperties:
+                type: string
+              description: Labels are set on the GCP project, they must follow the
+                GCP rules for labels, lowercase letters, digits, dashes and underscores
+                of at most 63 characters
+              type: object
             parentId:
               description: ParentId is the type specific ID of the parent this project
                 has
               type: string
             parentType:
               description: 'ParentType is the type of parent this project has Valid
-                types are: "organization", "folder", and "project"'
+                types are: "organization", "folder", and "project" Defaults to the
+                namespace annotations or the organization of the credentials'
               enum:
               - organization
               - folder
               - project
               type: string
             projectId:
-              description: ProjectId is the GCP project ID
+              description: ProjectId is the GCP project ID, either projectId or projectIdPrefix
+                must be set
+              maxLength: 30
+              minLength: 6
+              type: string
+            projectIdPrefix:
+              description: ProjectIdPrefix has the operator generate an unused project
+                ID of the form 'prefix-abc123' when no projectId is given, the chosen
+                ID is recorded in the status
+              maxLength: 23
               type: string
             projectName:
               description: ProjectName is the GCP project name
+              maxLength: 30
+              minLength: 4
+              type: string
+            resyncInterval:
+              description: ResyncInterval is how often the GCP project is checked
+                against the spec, defaults to the interval the operator is started
+                with
+              type: string
+            serviceAccountName:
+              description: ServiceAccountName is the name used when creating the service
+                account e.g. 'hub-admin', defaults to the name the operator is started
+                with
+              maxLength: 30
+              minLength: 6
               type: string
+            services:
+              description: Services are the APIs to enable in the project on top of
+                the baseline required by the operator e.g. 'container.googleapis.com'
+              items:
+                type: string
+              type: array
             use:
               description: GCPCredentials is a reference to the gcp credentials object
                 to use
@@ -644,18 +1667,199 @@ spec:
               type: object
           required:
           - billingAccountName
-          - parentId
-          - parentType
-          - projectId
           - projectName
           - use
           type: object
         status:
           description: GCPProjectStatus defines the observed state of GCPProject
           properties:
+            conditions:
+              description: Conditions are the latest observations of the state of
+                the resource
+              items:
+                description: Condition describes an aspect of the state of a resource
+                properties:
+                  lastTransitionTime:
+                    description: LastTransitionTime is when the condition last changed
+                      status
+                    format: date-time
+                    type: string
+                  message:
+                    description: Message is a human readable description of the last
+                      transition
+                    type: string
+                  observedGeneration:
+                    description: ObservedGeneration is the generation of the resource
+                      the condition was set for
+                    format: int64
+                    type: integer
+                  reason:
+                    description: Reason is a one word CamelCase reason for the last
+                      transition
+                    type: string
+                  status:
+                    description: Status is the status of the condition, one of True,
+                      False or Unknown
+                    type: string
+                  type:
+                    description: Type is the type of the condition
+                    type: string
+                required:
+                - status
+                - type
+                type: object
+              type: array
+            credentialsRef:
+              description: CredentialsRef refers to the GCPCredentials generated for
+                the service account of the project
+              properties:
+                group:
+                  description: Group is the api group
+                  type: string
+                kind:
+                  description: Kind is the name of the resource under the group
+                  type: string
+                name:
+                  description: Name is name of the resource
+                  type: string
+                namespace:
+                  description: Namespace is the location of the object
+                  type: string
+                version:
+                  description: Version is the group version
+                  type: string
+              required:
+              - group
+              - kind
+              - name
+              - namespace
+              - version
+              type: object
+            drift:
+              description: Drift are the differences from the spec found at the last
+                resync
+              items:
+                type: string
+              type: array
+            enabledServices:
+              description: EnabledServices are the services the operator has enabled
+                in the project
+              items:
+                type: string
+              type: array
+            keys:
+              description: Keys are the service account keys issued for the generated
+                credentials, the current key and any replaced keys waiting out the
+                overlap of the key rotation
+              items:
+                description: ServiceAccountKey records a service account key issued
+                  for the generated credentials
+                properties:
+                  creationTime:
+                    description: CreationTime is when the key was issued
+                    format: date-time
+                    type: string
+                  id:
+                    description: ID is the ID of the key
+                    type: string
+                  name:
+                    description: Name is the resource name of the key
+                    type: string
+                  replacedTime:
+                    description: ReplacedTime is when a newer key took over, the key
+                      is deleted once the overlap has passed
+                    format: date-time
+                    type: string
+                required:
+                - creationTime
+                - id
+                - name
+                type: object
+              type: array
+            labelKeys:
+              description: LabelKeys are the keys of the labels the operator has set
+                on the GCP project from the spec and namespace, so labels which are
+                no longer wanted can be removed
+              items:
+                type: string
+              type: array
+            lastSyncTime:
+              description: LastSyncTime is when the GCP project was last checked against
+                the spec
+              format: date-time
+              type: string
+            managedRoles:
+              description: ManagedRoles are the roles the operator has applied authoritatively
+                from spec.iam, so the members of roles which are dropped from it can
+                be removed
+              items:
+                type: string
+              type: array
+            operations:
+              description: Operations are the long running GCP operations currently
+                being waited on
+              items:
+                description: Operation is a long running GCP operation the reconciler
+                  is waiting on
+                properties:
+                  name:
+                    description: Name is the name of the operation
+                    type: string
+                  service:
+                    description: Service is the api owning the operation, cloudresourcemanager
+                      or servicemanagement
+                    type: string
+                  startTime:
+                    description: StartTime is when the operation was started
+                    format: date-time
+                    type: string
+                  step:
+                    description: Step is the provisioning step which started the operation
+                    type: string
+                required:
+                - name
+                - service
+                - startTime
+                - step
+                type: object
+              type: array
+            projectId:
+              description: ProjectId is the ID of the GCP project, as given in the
+                spec or generated from the prefix
+              type: string
             status:
               description: Status provides a overall status
               type: string
+            steps:
+              description: Steps records the progress of each of the provisioning
+                steps
+              items:
+                description: StepStatus records the progress of a provisioning step
+                properties:
+                  lastTransitionTime:
+                    description: LastTransitionTime is when the step last changed
+                      status
+                    format: date-time
+                    type: string
+                  message:
+                    description: Message is the error the step last failed with
+                    type: string
+                  name:
+                    description: Name is the name of the step
+                    type: string
+                  observedGeneration:
+                    description: ObservedGeneration is the generation of the resource
+                      the step was last completed for
+                    format: int64
+                    type: integer
+                  status:
+                    description: Status is the status of the step
+                    type: string
+                required:
+                - name
+                - status
+                type: object
+              type: array
           required:
           - status
           type: object
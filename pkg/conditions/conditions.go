@@ -0,0 +1,131 @@
+package conditions
+
+import (
+	"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	core "github.com/appvia/hub-apis/pkg/apis/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// CompletedReason is used when the work behind a condition has finished
+	CompletedReason = "Completed"
+	// InProgressReason is used while waiting on GCP to finish the work behind a condition
+	InProgressReason = "InProgress"
+	// FailedReason is used when the work behind a condition has failed
+	FailedReason = "Failed"
+	// PendingReason is used when the work behind a condition has not started
+	PendingReason = "Pending"
+)
+
+// Get returns the condition of the type, or nil if it has not been set
+func Get(conditions []v1alpha1.Condition, t v1alpha1.ConditionType) *v1alpha1.Condition {
+	for i := range conditions {
+		if conditions[i].Type == t {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsTrue checks if the condition of the type is true
+func IsTrue(conditions []v1alpha1.Condition, t v1alpha1.ConditionType) bool {
+	c := Get(conditions, t)
+
+	return c != nil && c.Status == v1alpha1.ConditionTrue
+}
+
+// Set adds or updates the condition, only moving the transition time when the status changes
+func Set(conditions *[]v1alpha1.Condition, condition v1alpha1.Condition) {
+	current := Get(*conditions, condition.Type)
+	if current == nil {
+		condition.LastTransitionTime = metav1.Now()
+		*conditions = append(*conditions, condition)
+		return
+	}
+
+	if current.Status != condition.Status {
+		current.LastTransitionTime = metav1.Now()
+	}
+	current.Status = condition.Status
+	current.Reason = condition.Reason
+	current.Message = condition.Message
+	current.ObservedGeneration = condition.ObservedGeneration
+}
+
+// FromSteps sets the condition for each of the provisioning steps and the overall Ready condition
+// from the progress recorded for the generation. Steps sharing a condition must all complete for
+// it to become true
+func FromSteps(conditions *[]v1alpha1.Condition, steps []v1alpha1.StepStatus, names []string, generation int64) {
+	var order []v1alpha1.ConditionType
+	results := make(map[v1alpha1.ConditionType]v1alpha1.Condition)
+
+	for _, name := range names {
+		t := v1alpha1.StepConditions[name]
+		c := stepCondition(t, steps, name, generation)
+
+		previous, found := results[t]
+		if !found {
+			order = append(order, t)
+		}
+		// the least progressed step decides the condition
+		if !found || previous.Status == v1alpha1.ConditionTrue || c.Reason == FailedReason {
+			results[t] = c
+		}
+	}
+
+	ready := v1alpha1.Condition{
+		Type:               v1alpha1.ReadyCondition,
+		Status:             v1alpha1.ConditionTrue,
+		Reason:             CompletedReason,
+		ObservedGeneration: generation,
+	}
+
+	for _, t := range order {
+		c := results[t]
+		Set(conditions, c)
+
+		if ready.Status == v1alpha1.ConditionTrue && c.Status != v1alpha1.ConditionTrue {
+			ready.Status = v1alpha1.ConditionFalse
+			ready.Reason = c.Reason
+			ready.Message = string(t) + " is not yet true"
+		}
+		if c.Reason == FailedReason {
+			ready.Reason = FailedReason
+			ready.Message = c.Message
+		}
+	}
+
+	Set(conditions, ready)
+}
+
+// stepCondition returns the condition reporting on the progress of the step
+func stepCondition(t v1alpha1.ConditionType, steps []v1alpha1.StepStatus, name string, generation int64) v1alpha1.Condition {
+	c := v1alpha1.Condition{
+		Type:               t,
+		Status:             v1alpha1.ConditionFalse,
+		Reason:             PendingReason,
+		ObservedGeneration: generation,
+	}
+
+	var s *v1alpha1.StepStatus
+	for i := range steps {
+		if steps[i].Name == name {
+			s = &steps[i]
+		}
+	}
+
+	switch {
+	case s == nil:
+		c.Status = v1alpha1.ConditionUnknown
+	case s.Status == core.SuccessStatus && s.ObservedGeneration >= generation:
+		c.Status = v1alpha1.ConditionTrue
+		c.Reason = CompletedReason
+	case s.Status == core.PendingStatus:
+		c.Reason = InProgressReason
+	case s.Status == core.FailureStatus:
+		c.Reason = FailedReason
+		c.Message = s.Message
+	}
+
+	return c
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"time"
+
+	"github.com/appvia/gcp-operator/pkg/defaults"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Config is the operator configuration handed to the controllers
+type Config struct {
+	// OperationTimeout is how long a long running operation can take before it is failed
+	OperationTimeout time.Duration
+	// OperationPollInterval is how often pending long running operations are polled
+	OperationPollInterval time.Duration
+	// ResyncInterval is how often provisioned projects are checked for drift from their spec
+	ResyncInterval time.Duration
+	// ClusterID identifies the cluster in the ownership labels stamped on projects
+	ClusterID string
+	// NamespaceLabels are the keys of the namespace labels copied onto the projects created from it
+	NamespaceLabels []string
+	// EnableWebhooks serves the admission webhooks for the GCP resources
+	EnableWebhooks bool
+	// WebhookPort is the port the admission webhooks are served on
+	WebhookPort int
+	// WebhookCertDir is the directory holding the tls.crt and tls.key served by the webhooks
+	WebhookCertDir string
+	// DefaultServiceAccountName is the service account name given to new projects which set none
+	DefaultServiceAccountName string
+	// DefaultLabels are added to the labels of new projects unless already set
+	DefaultLabels map[string]string
+	// GCP configures how the google apis are reached
+	GCP gcp.Options
+}
+
+// New returns the default configuration
+func New() Config {
+	return Config{
+		OperationTimeout:          10 * time.Minute,
+		OperationPollInterval:     5 * time.Second,
+		ResyncInterval:            10 * time.Minute,
+		WebhookPort:               9443,
+		DefaultServiceAccountName: "gcp-operator",
+		GCP: gcp.Options{
+			Retry: gcp.Retry{
+				MaxRetries: 5,
+				MinBackoff: time.Second,
+				MaxBackoff: 30 * time.Second,
+			},
+			RateLimit: 5,
+			RateBurst: 10,
+		},
+	}
+}
+
+// Defaulter returns the defaulter filling in the fields left out of GCP projects
+func (c Config) Defaulter(reader client.Reader) defaults.Defaulter {
+	return defaults.Defaulter{Reader: reader, ServiceAccountName: c.DefaultServiceAccountName, Labels: c.DefaultLabels}
+}
+
+// AddFlags registers the configuration options on the flag set
+func (c *Config) AddFlags(fs *pflag.FlagSet) {
+	fs.DurationVar(&c.OperationTimeout, "operation-timeout", c.OperationTimeout, "the maximum time to wait on a google long running operation before failing it")
+	fs.DurationVar(&c.OperationPollInterval, "operation-poll-interval", c.OperationPollInterval, "how often pending google long running operations are polled")
+	fs.DurationVar(&c.ResyncInterval, "resync-interval", c.ResyncInterval, "how often provisioned projects are checked for drift from their spec, 0 disables the checks")
+	fs.StringVar(&c.ClusterID, "cluster-id", "", "identifies the cluster in the labels stamped on the projects it manages, lowercase letters, digits, dashes and underscores")
+	fs.StringSliceVar(&c.NamespaceLabels, "namespace-labels", nil, "keys of the namespace labels copied onto every project created from the namespace e.g. cost-center,team,environment")
+	fs.BoolVar(&c.EnableWebhooks, "enable-webhooks", false, "serve the admission webhooks which validate and default the GCP resources")
+	fs.IntVar(&c.WebhookPort, "webhook-port", c.WebhookPort, "the port the admission webhooks are served on")
+	fs.StringVar(&c.WebhookCertDir, "webhook-cert-dir", "", "the directory holding the tls.crt and tls.key served by the admission webhooks, defaults to /tmp/k8s-webhook-server/serving-certs")
+	fs.StringVar(&c.DefaultServiceAccountName, "default-service-account-name", c.DefaultServiceAccountName, "the service account name given to projects which set none")
+	fs.StringToStringVar(&c.DefaultLabels, "default-labels", nil, "labels the defaulting webhook adds to new projects unless already set e.g. managed-via=hub")
+	fs.StringVar(&c.GCP.Endpoints.CloudResourceManager, "cloudresourcemanager-endpoint", "", "overrides the base URL of the cloud resource manager api e.g. https://restricted.googleapis.com/")
+	fs.StringVar(&c.GCP.Endpoints.CloudBilling, "cloudbilling-endpoint", "", "overrides the base URL of the cloud billing api")
+	fs.StringVar(&c.GCP.Endpoints.ServiceManagement, "servicemanagement-endpoint", "", "overrides the base URL of the service management api")
+	fs.StringVar(&c.GCP.Endpoints.IAM, "iam-endpoint", "", "overrides the base URL of the iam api")
+	fs.StringVar(&c.GCP.Endpoints.IAMCredentials, "iamcredentials-endpoint", "", "overrides the base URL of the iam credentials api")
+	fs.StringVar(&c.GCP.CABundle, "google-ca-bundle", "", "path to a PEM bundle of certificates trusted for the google apis in addition to the system roots")
+	fs.StringVar(&c.GCP.Proxy, "google-proxy", "", "URL of the HTTP proxy used for the google apis, defaults to the proxy environment variables")
+	fs.IntVar(&c.GCP.Retry.MaxRetries, "google-max-retries", c.GCP.Retry.MaxRetries, "the number of times a google api request failing with a 429 or 5xx is retried")
+	fs.DurationVar(&c.GCP.Retry.MinBackoff, "google-min-backoff", c.GCP.Retry.MinBackoff, "the delay before the first retry of a google api request, doubling with each retry")
+	fs.DurationVar(&c.GCP.Retry.MaxBackoff, "google-max-backoff", c.GCP.Retry.MaxBackoff, "the maximum delay between retries of a google api request, including those requested by Retry-After")
+	fs.Float64Var(&c.GCP.RateLimit, "google-rate-limit", c.GCP.RateLimit, "the google api requests per second allowed for each credential, 0 disables rate limiting")
+	fs.IntVar(&c.GCP.RateBurst, "google-rate-burst", c.GCP.RateBurst, "the google api requests allowed in a burst for each credential")
+	fs.StringVar(&c.GCP.RootKeyFile, "root-key-file", "", "path to the JSON service account key the operator impersonates service accounts with, defaults to the application default credentials")
+}
@@ -0,0 +1,10 @@
+package controller
+
+import (
+	"github.com/appvia/gcp-operator/pkg/controller/gcpcredentials"
+)
+
+func init() {
+	// AddToManagerFuncs is a list of functions to create controllers and add them to a manager.
+	AddToManagerFuncs = append(AddToManagerFuncs, gcpcredentials.Add)
+}
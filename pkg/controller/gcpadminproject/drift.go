@@ -0,0 +1,66 @@
+package gcpadminproject
+
+import (
+	"context"
+	"strings"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/conditions"
+	"github.com/appvia/gcp-operator/pkg/drift"
+	"github.com/appvia/gcp-operator/pkg/events"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/services"
+	"github.com/appvia/gcp-operator/pkg/steps"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// detectDrift checks the admin project against the spec, recording any drift in the status and,
+// unless the drift policy is Report, marking the steps which correct it as pending so they run again
+func (r *ReconcileGCPAdminProject) detectDrift(ctx context.Context, c *gcp.Client, adminProjectInstance *gcpv1alpha1.GCPAdminProject, desiredLabels map[string]string, driftPolicy gcpv1alpha1.DriftPolicy) error {
+	spec := adminProjectInstance.Spec
+
+	found, err := drift.Detect(ctx, c, drift.Desired{
+		ProjectId:          spec.ProjectId,
+		ProjectName:        spec.ProjectName,
+		ParentType:         spec.ParentType,
+		ParentId:           spec.ParentId,
+		BillingAccountName: spec.BillingAccountName,
+		ServiceAccountName: spec.ServiceAccountName,
+		Services:           services.Desired(spec.Services),
+		Bindings:           adminBindings(spec.ProjectId, spec.ServiceAccountName),
+		Mode:               gcpv1alpha1.AdditiveMode,
+		Labels:             desiredLabels,
+		LabelKeys:          adminProjectInstance.Status.LabelKeys,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	now := metav1.Now()
+	adminProjectInstance.Status.LastSyncTime = &now
+	adminProjectInstance.Status.Drift = drift.Messages(found)
+
+	conditions.Set(&adminProjectInstance.Status.Conditions, drift.Condition(found, driftPolicy, adminProjectInstance.Generation))
+
+	if len(found) == 0 {
+		return nil
+	}
+
+	Logger.Info("Admin project has drifted from the spec", "Drift", adminProjectInstance.Status.Drift, "DriftPolicy", driftPolicy)
+
+	r.recorder.Event(adminProjectInstance, corev1.EventTypeWarning, events.DriftDetected, strings.Join(adminProjectInstance.Status.Drift, "; "))
+
+	if driftPolicy == gcpv1alpha1.ReportDriftPolicy {
+		return nil
+	}
+
+	for _, d := range found {
+		if d.Step != "" {
+			steps.SetPending(&adminProjectInstance.Status.Steps, d.Step)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package gcpadminproject
+
+import (
+	"context"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/credentials"
+	"github.com/appvia/gcp-operator/pkg/events"
+	"github.com/appvia/gcp-operator/pkg/finalizers"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/keys"
+	"github.com/appvia/gcp-operator/pkg/ownership"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// finalizerName is the finalizer placed on GCPAdminProjects to clean up the GCP project
+const finalizerName = "gcpadminproject.gcp.compute.hub.appvia.io"
+
+// finalize is responsible for cleaning up a deleted GCPAdminProject according to its deletion policy
+func (r *ReconcileGCPAdminProject) finalize(ctx context.Context, adminProjectInstance *gcpv1alpha1.GCPAdminProject) (reconcile.Result, error) {
+	reqLogger := Logger.WithValues("Request.Namespace", adminProjectInstance.Namespace, "Request.Name", adminProjectInstance.Name)
+
+	if !finalizers.Has(adminProjectInstance, finalizerName) {
+		return reconcile.Result{}, nil
+	}
+
+	policy := adminProjectInstance.Spec.DeletionPolicy
+	if policy == "" {
+		policy = gcpv1alpha1.DeletePolicy
+	}
+
+	reqLogger.Info("Deleting GCPAdminProject", "DeletionPolicy", policy)
+
+	// A token removed after bootstrap is not coming back, so the project is left in place rather
+	// than the resource being stuck deleting
+	if policy != gcpv1alpha1.OrphanPolicy && adminProjectInstance.Spec.DeleteTokenAfterBootstrap {
+		if _, err := r.token(ctx, adminProjectInstance); errors.IsNotFound(err) {
+			reqLogger.Info("The token was removed after bootstrap, leaving the admin project in place")
+
+			r.recorder.Eventf(adminProjectInstance, corev1.EventTypeWarning, events.CleanupSkipped,
+				"The token Secret was removed after bootstrap, project %s was left in place", adminProjectInstance.Spec.ProjectId)
+
+			policy = gcpv1alpha1.OrphanPolicy
+		}
+	}
+
+	if policy != gcpv1alpha1.OrphanPolicy {
+		if err := r.cleanup(ctx, adminProjectInstance, policy); err != nil {
+			reqLogger.Error(err, "failed to clean up the admin project")
+
+			r.recorder.Event(adminProjectInstance, corev1.EventTypeWarning, events.DeletionFailed, err.Error())
+
+			return reconcile.Result{}, err
+		}
+	}
+
+	finalizers.Remove(adminProjectInstance, finalizerName)
+
+	if err := r.client.Update(ctx, adminProjectInstance); err != nil {
+		reqLogger.Error(err, "failed to remove the finalizer")
+
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// cleanup revokes the generated credentials and then disables billing or deletes the project
+func (r *ReconcileGCPAdminProject) cleanup(ctx context.Context, adminProjectInstance *gcpv1alpha1.GCPAdminProject, policy gcpv1alpha1.DeletionPolicy) error {
+	reqLogger := Logger.WithValues("Request.Namespace", adminProjectInstance.Namespace, "Request.Name", adminProjectInstance.Name)
+	projectId := adminProjectInstance.Spec.ProjectId
+
+	// A token is needed to clean up, including when it was removed after bootstrap
+	bearer, err := r.token(ctx, adminProjectInstance)
+
+	if err != nil {
+		return err
+	}
+
+	c, err := r.clients.New(ctx, gcp.Auth{Token: bearer})
+
+	if err != nil {
+		return err
+	}
+
+	// Revoke the key held by the generated credentials and remove them
+	generated := &gcpv1alpha1.GCPCredentials{}
+
+	err = r.client.Get(ctx, types.NamespacedName{Namespace: adminProjectInstance.Namespace, Name: credentials.Name(projectId)}, generated)
+
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if err == nil && !keys.HasKey(generated) {
+		reqLogger.Info("The generated credentials impersonate the service account, there is no key to revoke")
+	}
+
+	if err == nil && keys.HasKey(generated) {
+		key, err := keys.Get(ctx, r.client, generated)
+
+		switch {
+		case errors.IsNotFound(err):
+			reqLogger.Info("The Secret holding the generated key no longer exists, skipping revocation")
+		case err != nil:
+			return err
+		default:
+			keyName, err := keys.Name(key)
+
+			if err != nil {
+				return err
+			}
+
+			reqLogger.Info("Revoking service account key: " + keyName)
+
+			if err := c.IAM.DeleteServiceAccountKey(ctx, keyName); err != nil {
+				return err
+			}
+
+			r.recorder.Eventf(adminProjectInstance, corev1.EventTypeNormal, events.CredentialsRevoked, "Revoked service account key %s", keyName)
+		}
+
+		if err := r.client.Delete(ctx, generated); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	// Revoke the replaced keys still within the overlap of the key rotation
+	if err := credentials.Revoke(ctx, c.IAM, adminProjectInstance.Status.Keys); err != nil {
+		return err
+	}
+
+	// Only a project labelled as managed by this resource is cleaned up, leaving alone projects
+	// managed elsewhere as well as unlabelled projects it was refused or never adopted
+	owner := ownership.Owner{ClusterID: r.config.ClusterID, Object: adminProjectInstance}
+
+	owned, err := owner.Owns(ctx, c.Projects, projectId)
+
+	if err != nil {
+		return err
+	}
+
+	if !owned {
+		reqLogger.Info("The project does not exist or is not managed by this resource, skipping its clean up")
+
+		r.recorder.Eventf(adminProjectInstance, corev1.EventTypeWarning, events.CleanupSkipped, "Project %s does not exist or is not managed by this resource, it was left in place", projectId)
+
+		return nil
+	}
+
+	if policy == gcpv1alpha1.DisableBillingPolicy {
+		reqLogger.Info("Disabling billing for project: " + projectId)
+
+		if err := c.Billing.DisableBilling(ctx, projectId); err != nil {
+			return err
+		}
+
+		r.recorder.Eventf(adminProjectInstance, corev1.EventTypeNormal, events.BillingDisabled, "Disabled billing for project %s", projectId)
+
+		return nil
+	}
+
+	reqLogger.Info("Deleting service account: " + adminProjectInstance.Spec.ServiceAccountName)
+
+	if err := c.IAM.DeleteServiceAccount(ctx, projectId, adminProjectInstance.Spec.ServiceAccountName); err != nil {
+		return err
+	}
+
+	reqLogger.Info("Deleting project: " + projectId)
+
+	if err := c.Projects.Delete(ctx, projectId); err != nil {
+		return err
+	}
+
+	r.recorder.Eventf(adminProjectInstance, corev1.EventTypeNormal, events.ProjectDeleted, "Deleted project %s", projectId)
+
+	return nil
+}
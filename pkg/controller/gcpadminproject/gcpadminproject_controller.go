@@ -2,12 +2,29 @@ package gcpadminproject
 
 import (
 	"context"
+	"reflect"
+	"strings"
+	"time"
 
 	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/conditions"
+	"github.com/appvia/gcp-operator/pkg/config"
+	"github.com/appvia/gcp-operator/pkg/drift"
+	"github.com/appvia/gcp-operator/pkg/events"
+	"github.com/appvia/gcp-operator/pkg/finalizers"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/labels"
+	"github.com/appvia/gcp-operator/pkg/metrics"
+	"github.com/appvia/gcp-operator/pkg/operations"
+	"github.com/appvia/gcp-operator/pkg/ownership"
+	"github.com/appvia/gcp-operator/pkg/provisioner"
+	"github.com/appvia/gcp-operator/pkg/steps"
+	"github.com/appvia/gcp-operator/pkg/validation"
 	core "github.com/appvia/hub-apis/pkg/apis/core/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -22,13 +39,23 @@ var Logger = logf.Log.WithName("controller_gcpadminproject")
 
 // Add creates a new GCPAdminProject Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+func Add(mgr manager.Manager, options config.Config) error {
+	r, err := newReconciler(mgr, options)
+	if err != nil {
+		return err
+	}
+
+	return add(mgr, r)
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileGCPAdminProject{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+func newReconciler(mgr manager.Manager, options config.Config) (reconcile.Reconciler, error) {
+	clients, err := gcp.NewFactory(options.GCP)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReconcileGCPAdminProject{client: mgr.GetClient(), scheme: mgr.GetScheme(), config: options, clients: clients, recorder: mgr.GetEventRecorderFor("gcpadminproject-controller"), reader: mgr.GetAPIReader()}, nil
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -57,6 +84,13 @@ type ReconcileGCPAdminProject struct {
 	// that reads objects from the cache and writes to the apiserver
 	client client.Client
 	scheme *runtime.Scheme
+	config config.Config
+	// clients creates the GCP clients, a fake can be injected for testing
+	clients gcp.Factory
+	// recorder records events on the resources
+	recorder record.EventRecorder
+	// reader reads directly from the api server, for resources which are not cached
+	reader client.Reader
 }
 
 // Note:
@@ -80,178 +114,248 @@ func (r *ReconcileGCPAdminProject) Reconcile(request reconcile.Request) (reconci
 
 	ctx := context.Background()
 
-	bearer := adminProjectInstance.Spec.Token
+	// Clean up the admin project according to the deletion policy
+	if adminProjectInstance.GetDeletionTimestamp() != nil {
+		return r.finalize(ctx, adminProjectInstance)
+	}
 
-	// Get project details from spec
-	projectId, projectName, parentType, parentId := adminProjectInstance.Spec.ProjectId, adminProjectInstance.Spec.ProjectName, adminProjectInstance.Spec.ParentType, adminProjectInstance.Spec.ParentId
+	// Ensure the admin project is cleaned up when the resource is deleted
+	if !finalizers.Has(adminProjectInstance, finalizerName) {
+		finalizers.Add(adminProjectInstance, finalizerName)
 
-	// Check if project already exists
-	projectExists, err := HttpProjectExists(ctx, bearer, projectId)
+		// resources created before deletion policies existed were never cleaned up, so they keep
+		// their GCP project rather than taking on the destructive default
+		if adminProjectInstance.Spec.DeletionPolicy == "" && adminProjectInstance.Status.Status != "" {
+			reqLogger.Info("Defaulting the deletion policy of an existing resource to Orphan")
+
+			adminProjectInstance.Spec.DeletionPolicy = gcpv1alpha1.OrphanPolicy
+		}
+
+		if err := r.client.Update(ctx, adminProjectInstance); err != nil {
+			reqLogger.Error(err, "failed to add the finalizer")
+
+			return reconcile.Result{}, err
+		}
+	}
+
+	// The fields left out are defaulted here as well as by the webhook, which may not be enabled
+	defaulted := adminProjectInstance.DeepCopy()
+
+	if err := r.config.Defaulter(r.reader).AdminProject(ctx, adminProjectInstance.Namespace, defaulted); err != nil {
+		reqLogger.Error(err, "failed to default the spec")
 
-	if err != nil {
-		reqLogger.Info("Error getting checking if project exists")
 		return reconcile.Result{}, err
 	}
 
-	if projectExists {
-		_, project, err := HttpGetProject(ctx, bearer, projectId)
+	if !reflect.DeepEqual(defaulted.Spec, adminProjectInstance.Spec) {
+		reqLogger.Info("Defaulting the spec")
 
-		billingAccountName, err := HttpGetBilling(projectId, bearer)
+		if err := r.client.Update(ctx, defaulted); err != nil {
+			reqLogger.Error(err, "failed to update the defaulted spec")
 
-		if projectName != project.Name || parentType != project.Parent.Type || parentId != project.Parent.Id {
-			reqLogger.Info("Project exists but state differs, updating")
+			return reconcile.Result{}, err
+		}
 
-			updateOperationName, err := HttpUpdateProject(ctx, bearer, projectId, projectName, parentId, parentType)
+		adminProjectInstance = defaulted
+	}
 
-			// Set status to pending
-			adminProjectInstance.Status.Status = core.PendingStatus
+	if err := validation.Defaulted(adminProjectInstance.Spec.ParentType, adminProjectInstance.Spec.ParentId, adminProjectInstance.Spec.ServiceAccountName); err != nil {
+		return r.failed(ctx, adminProjectInstance, gcpv1alpha1.CreateProjectStep, err)
+	}
 
-			if err := r.client.Status().Update(ctx, adminProjectInstance); err != nil {
-				reqLogger.Error(err, "failed to update the resource status")
-				return reconcile.Result{}, err
-			}
+	bearer, err := r.token(ctx, adminProjectInstance)
 
-			// Wait for project update operation to complete
-			_, err = HttpWaitForCRMOperation(updateOperationName, bearer)
+	if err != nil {
+		// Once bootstrapped the token may have been removed on purpose, leaving nothing to do
+		if errors.IsNotFound(err) && adminProjectInstance.Spec.DeleteTokenAfterBootstrap &&
+			conditions.IsTrue(adminProjectInstance.Status.Conditions, gcpv1alpha1.CredentialsIssuedCondition) {
+			reqLogger.Info("The token has been removed after bootstrap, skipping")
 
-			if err != nil {
-				return reconcile.Result{}, err
-			}
-		} else {
-			reqLogger.Info("Project exists and state matches")
+			return reconcile.Result{}, nil
 		}
 
-		if billingAccountName != "billingAccounts/"+adminProjectInstance.Spec.BillingAccountName {
-			reqLogger.Info("Project exists but billing account doesnt match, updating")
+		reqLogger.Error(err, "failed to read the token")
+
+		return reconcile.Result{}, err
+	}
 
-			err = HttpUpdateBilling(projectId, adminProjectInstance.Spec.BillingAccountName, bearer)
+	c, err := r.clients.New(ctx, gcp.Auth{Token: bearer})
 
-			if err != nil {
-				return reconcile.Result{}, err
-			}
-			// Set status to pending
-			adminProjectInstance.Status.Status = core.PendingStatus
+	if err != nil {
+		reqLogger.Error(err, "failed to obtain GCP client")
+
+		return reconcile.Result{}, err
+	}
+
+	p := &provisioner.Provisioner{
+		Resource: resource(adminProjectInstance),
+		Client:   r.client,
+		Scheme:   r.scheme,
+		GCP:      c,
+		Recorder: r.recorder,
+		Owner:    r.owner(adminProjectInstance),
+	}
+
+	// Resume from any long running operations started by a previous reconcile
+	if len(adminProjectInstance.Status.Operations) > 0 {
+		step := adminProjectInstance.Status.Operations[0].Step
+
+		pending, err := operations.Poll(adminProjectInstance.Status.Operations, r.config.OperationTimeout, func(op gcpv1alpha1.Operation) (bool, error, error) {
+			return c.Operations.Done(ctx, op)
+		})
+
+		// the operations after a failed one are still tracked
+		adminProjectInstance.Status.Operations = pending
+
+		if operations.IsFailed(err) {
+			return r.failed(ctx, adminProjectInstance, step, err)
+		}
+
+		// the operations could not be checked, they are polled again rather than failing the step
+		if err != nil {
+			reqLogger.Error(err, "failed to poll the operations", "Step", step)
+
+			setTokenExpired(adminProjectInstance, err)
 
 			if err := r.client.Status().Update(ctx, adminProjectInstance); err != nil {
 				reqLogger.Error(err, "failed to update the resource status")
-				return reconcile.Result{}, err
 			}
+
+			return reconcile.Result{}, err
 		}
 
-		reqLogger.Info("Project exists and billing account matches")
+		if len(pending) > 0 {
+			reqLogger.Info("Waiting on operations", "Step", step, "Pending", len(pending))
 
-		// Set status to success
-		adminProjectInstance.Status.Status = core.SuccessStatus
+			if err := r.updateStatus(ctx, adminProjectInstance); err != nil {
+				reqLogger.Error(err, "failed to update the resource status")
 
-		if err := r.client.Status().Update(ctx, adminProjectInstance); err != nil {
-			reqLogger.Error(err, "failed to update the resource status")
+				return reconcile.Result{}, err
+			}
 
-			return reconcile.Result{}, err
+			return reconcile.Result{RequeueAfter: r.config.OperationPollInterval}, nil
 		}
 
-		return reconcile.Result{}, nil
-	}
+		reqLogger.Info("Operations completed", "Step", step)
 
-	// Project doesnt exist, create it
-	operationName, err := HttpCreateProject(bearer, projectId, projectName, parentId, parentType)
+		// a step whose operations partly failed is run again rather than completed
+		if !steps.IsFailed(adminProjectInstance.Status.Steps, step) {
+			if step == gcpv1alpha1.CreateProjectStep {
+				r.recorder.Eventf(adminProjectInstance, corev1.EventTypeNormal, events.ProjectCreated, "Created project %s", adminProjectInstance.Spec.ProjectId)
+			}
 
-	if err != nil {
-		return reconcile.Result{}, err
+			steps.SetComplete(&adminProjectInstance.Status.Steps, step, adminProjectInstance.Generation)
+		}
 	}
 
-	// Set status to pending
-	adminProjectInstance.Status.Status = core.PendingStatus
+	interval := drift.Interval(adminProjectInstance.Spec.ResyncInterval, r.config.ResyncInterval)
+	driftPolicy := drift.Policy(adminProjectInstance.Spec.DriftPolicy)
 
-	if err := r.client.Status().Update(ctx, adminProjectInstance); err != nil {
-		reqLogger.Error(err, "failed to update the resource status")
+	// Never touch a project managed by another resource or cluster
+	err = p.Owner.Verify(ctx, c.Projects, adminProjectInstance.Spec.ProjectId)
+
+	if err != nil && !ownership.IsConflict(err) {
+		reqLogger.Error(err, "failed to check the ownership of the admin project")
+
+		setTokenExpired(adminProjectInstance, err)
+
+		if err := r.client.Status().Update(ctx, adminProjectInstance); err != nil {
+			reqLogger.Error(err, "failed to update the resource status")
+		}
 
 		return reconcile.Result{}, err
 	}
 
-	// Wait for operation to complete
-	_, err = HttpWaitForCRMOperation(operationName, bearer)
+	conditions.Set(&adminProjectInstance.Status.Conditions, ownership.Condition(err, adminProjectInstance.Generation))
 
 	if err != nil {
-		return reconcile.Result{}, err
+		return r.conflict(ctx, adminProjectInstance, err, interval)
 	}
 
-	// Set billing account for admin project
-	err = HttpUpdateBilling(projectId, adminProjectInstance.Spec.BillingAccountName, bearer)
+	// The labels set on the project from the spec and the namespace
+	desiredLabels, skippedLabels, err := labels.Desired(ctx, r.reader, adminProjectInstance.Namespace, r.config.NamespaceLabels, adminProjectInstance.Spec.Labels, p.Owner.Labels())
 
 	if err != nil {
-		return reconcile.Result{}, err
+		return r.failed(ctx, adminProjectInstance, gcpv1alpha1.CreateProjectStep, err)
 	}
 
-	if err := r.client.Status().Update(ctx, adminProjectInstance); err != nil {
-		reqLogger.Error(err, "failed to update the resource status")
-		return reconcile.Result{}, err
+	if len(skippedLabels) > 0 {
+		r.recorder.Event(adminProjectInstance, corev1.EventTypeWarning, events.LabelsSkipped, strings.Join(skippedLabels, "; "))
 	}
 
-	servicesToEnable := []string{
-		"cloudresourcemanager.googleapis.com",
-		"cloudbilling.googleapis.com",
-		"iam.googleapis.com",
-		"compute.googleapis.com",
-		"serviceusage.googleapis.com",
+	p.Labels = desiredLabels
+
+	// Once provisioned, periodically check the admin project still matches the spec
+	if steps.AllComplete(adminProjectInstance.Status.Steps, provisioner.StepNames(), adminProjectInstance.Generation) && drift.Due(adminProjectInstance.Status.LastSyncTime, interval) {
+		if err := r.detectDrift(ctx, c, adminProjectInstance, desiredLabels, driftPolicy); err != nil {
+			reqLogger.Error(err, "failed to check the admin project for drift")
+
+			return reconcile.Result{}, err
+		}
 	}
 
-	// Enable each API in the new project
-	for _, s := range servicesToEnable {
-		reqLogger.Info("Enabling service: " + s + " for project: " + projectId)
-		operationName, err := HttpEnableAPI(projectId, s, bearer)
-		reqLogger.Info("Waiting for operation: " + operationName)
-		_, err = HttpWaitForSMOperation(operationName, bearer)
+	// Run each of the provisioning steps which has not completed for this generation, along
+	// with the continuous steps unless drift is only being reported
+	for _, s := range p.Steps() {
+		if steps.IsComplete(adminProjectInstance.Status.Steps, s.Name, adminProjectInstance.Generation) && (!s.Continuous || driftPolicy == gcpv1alpha1.ReportDriftPolicy) {
+			continue
+		}
+
+		reqLogger.Info("Running step: " + s.Name)
+
+		start := time.Now()
+
+		ops, err := s.Run(ctx)
+
+		metrics.ObserveStep(p.Kind, s.Name, start, err)
 
 		if err != nil {
-			reqLogger.Error(err, "failed to enable the service: "+s)
+			return r.failed(ctx, adminProjectInstance, s.Name, err)
+		}
+
+		if len(ops) > 0 {
+			steps.SetPending(&adminProjectInstance.Status.Steps, s.Name)
+
+			return r.waitForOperations(ctx, adminProjectInstance, ops...)
+		}
+
+		steps.SetComplete(&adminProjectInstance.Status.Steps, s.Name, adminProjectInstance.Generation)
+
+		if err := r.updateStatus(ctx, adminProjectInstance); err != nil {
+			reqLogger.Error(err, "failed to update the resource status")
+
 			return reconcile.Result{}, err
 		}
-		reqLogger.Info("Enabled service: " + s)
 	}
 
-	// Create service account, assign permissions, create a key
-	reqLogger.Info("Creating service account: " + adminProjectInstance.Spec.ServiceAccountName)
-	serviceAccount, err := HttpCreateServiceAccount(bearer, projectId, adminProjectInstance.Spec.ServiceAccountName, "Created by the Appvia Hub")
-	reqLogger.Info("Creating service account key for: " + adminProjectInstance.Spec.ServiceAccountName)
-	key, err := HttpCreateServiceAccountKey(bearer, projectId, adminProjectInstance.Spec.ServiceAccountName)
-	reqLogger.Info("Assigning project permissions to service account: " + serviceAccount.Email)
-	err = HttpSetProjectIam(bearer, serviceAccount.Email, projectId)
-
-	// Commented until test organization setup TODO: uncomment and test
-	// reqLogger.Info("Assigning org permissions to service account: " + serviceAccount.Email)
-	// err = HttpSetOrgIam(bearer, serviceAccount.Email, parentId)
-
-	// Create the credential as a CR
-	reqLogger.Info("Creating the GCPCredentials CR: " + projectId + " in namespace: " + request.Namespace)
-
-	adminCredential := &gcpv1alpha1.GCPCredentials{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      adminProjectInstance.Spec.ProjectId + "-gcpcreds",
-			Namespace: request.Namespace,
-		},
-		Spec: gcpv1alpha1.GCPCredentialsSpec{
-			Key:            key,
-			ProjectId:      projectId,
-			OrganizationId: parentId,
-		},
-		Status: gcpv1alpha1.GCPCredentialsStatus{
-			Status: "Success",
-		},
+	// Keep track of the key of the generated credentials and replace it when due
+	rotateAfter, err := p.RotateKey(ctx)
+
+	if err != nil {
+		return r.failed(ctx, adminProjectInstance, gcpv1alpha1.IssueCredentialsStep, err)
+	}
+
+	requeueAfter := interval
+	if rotateAfter > 0 && rotateAfter < requeueAfter {
+		requeueAfter = rotateAfter
 	}
 
-	err = r.client.Create(ctx, adminCredential)
+	// The credentials have been issued, so the token is no longer needed
+	if err := r.deleteToken(ctx, adminProjectInstance); err != nil {
+		reqLogger.Error(err, "failed to delete the token Secret")
 
-	if err != nil {
 		return reconcile.Result{}, err
 	}
 
 	// Set project status to success
 	adminProjectInstance.Status.Status = core.SuccessStatus
+	setTokenExpired(adminProjectInstance, nil)
 
-	if err := r.client.Status().Update(ctx, adminProjectInstance); err != nil {
+	if err := r.updateStatus(ctx, adminProjectInstance); err != nil {
 		reqLogger.Error(err, "failed to update the resource status")
 
 		return reconcile.Result{}, err
 	}
-	return reconcile.Result{}, nil
+
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
 }
@@ -0,0 +1,308 @@
+package gcpadminproject
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/conditions"
+	"github.com/appvia/gcp-operator/pkg/config"
+	"github.com/appvia/gcp-operator/pkg/credentials"
+	"github.com/appvia/gcp-operator/pkg/events"
+	"github.com/appvia/gcp-operator/pkg/finalizers"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/gcp/fake"
+	"github.com/appvia/gcp-operator/pkg/ownership"
+	core "github.com/appvia/hub-apis/pkg/apis/core/v1"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v1"
+	"google.golang.org/api/googleapi"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	testNamespace      = "admin"
+	testProjectId      = "admin-project"
+	testOrganizationId = "123456789012"
+	testBillingAccount = "012345-567890-ABCDEF"
+	testServiceAccount = "admin-account"
+)
+
+var (
+	testRequest = reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: "admin"}}
+	testToken   = types.NamespacedName{Namespace: testNamespace, Name: "gcp-admin-token"}
+)
+
+func init() {
+	// the fake client decodes with the client-go scheme, so the types are registered there
+	if err := gcpv1alpha1.SchemeBuilder.AddToScheme(scheme.Scheme); err != nil {
+		panic(err)
+	}
+}
+
+// newAdminProject returns a GCPAdminProject reading its token from the Secret returned by newToken
+func newAdminProject() *gcpv1alpha1.GCPAdminProject {
+	return &gcpv1alpha1.GCPAdminProject{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "admin",
+			Namespace: testNamespace,
+			UID:       "4b1e7e52-0002",
+		},
+		Spec: gcpv1alpha1.GCPAdminProjectSpec{
+			TokenRef:           &gcpv1alpha1.SecretKeyReference{Name: testToken.Name},
+			ProjectId:          testProjectId,
+			ProjectName:        "Admin",
+			ParentType:         "organization",
+			ParentId:           testOrganizationId,
+			BillingAccountName: testBillingAccount,
+			ServiceAccountName: testServiceAccount,
+		},
+	}
+}
+
+// newToken returns the Secret holding the bearer token, which the fake ignores
+func newToken() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: testToken.Name, Namespace: testNamespace},
+		Data:       map[string][]byte{tokenSecretKey: []byte("ya29.token\n")},
+	}
+}
+
+// newTestReconciler returns a reconciler backed by fake clients holding the objects
+func newTestReconciler(objs ...runtime.Object) (*ReconcileGCPAdminProject, *fake.Fake, *record.FakeRecorder) {
+	c := fakeclient.NewFakeClientWithScheme(scheme.Scheme, objs...)
+	clients := fake.New()
+	recorder := record.NewFakeRecorder(100)
+
+	return &ReconcileGCPAdminProject{
+		client:   c,
+		scheme:   scheme.Scheme,
+		config:   config.New(),
+		clients:  clients,
+		recorder: recorder,
+		reader:   c,
+	}, clients, recorder
+}
+
+// reconcileUntilDone reconciles the admin project until it is provisioned or fails
+func reconcileUntilDone(t *testing.T, r *ReconcileGCPAdminProject) *gcpv1alpha1.GCPAdminProject {
+	t.Helper()
+
+	for i := 0; i < 20; i++ {
+		if _, err := r.Reconcile(testRequest); err != nil {
+			t.Fatalf("reconcile failed: %v", err)
+		}
+
+		project := getAdminProject(t, r.client)
+
+		switch project.Status.Status {
+		case core.SuccessStatus:
+			return project
+		case core.FailureStatus:
+			t.Fatalf("provisioning failed: %+v", project.Status.Steps)
+		}
+	}
+
+	t.Fatal("the admin project was not provisioned after 20 reconciles")
+
+	return nil
+}
+
+func getAdminProject(t *testing.T, c client.Client) *gcpv1alpha1.GCPAdminProject {
+	t.Helper()
+
+	project := &gcpv1alpha1.GCPAdminProject{}
+
+	if err := c.Get(context.TODO(), testRequest.NamespacedName, project); err != nil {
+		t.Fatalf("failed to get the admin project: %v", err)
+	}
+
+	return project
+}
+
+// recorded returns the reasons of the events recorded so far
+func recorded(recorder *record.FakeRecorder) []string {
+	var reasons []string
+
+	for {
+		select {
+		case event := <-recorder.Events:
+			reasons = append(reasons, strings.Fields(event)[1])
+		default:
+			return reasons
+		}
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestReconcileProvisionsAdminProject(t *testing.T) {
+	r, f, recorder := newTestReconciler(newAdminProject(), newToken())
+
+	project := reconcileUntilDone(t, r)
+
+	if !finalizers.Has(project, finalizerName) {
+		t.Error("the finalizer was not added")
+	}
+
+	created, found := f.Projects[testProjectId]
+	if !found {
+		t.Fatal("the admin project was not created")
+	}
+	if created.Labels[ownership.UIDLabel] != ownership.LabelValue(string(project.UID)) {
+		t.Errorf("the admin project is not labelled for the resource: %v", created.Labels)
+	}
+	if f.BillingAccounts[testProjectId] != "billingAccounts/"+testBillingAccount {
+		t.Errorf("expected billing account %s to be linked, got %q", testBillingAccount, f.BillingAccounts[testProjectId])
+	}
+
+	member := "serviceAccount:" + gcp.ServiceAccountEmail(testProjectId, testServiceAccount)
+	if !bound(f.Policies[testProjectId], "roles/viewer", member) {
+		t.Errorf("expected %s to be granted roles/viewer, got %+v", member, f.Policies[testProjectId].Bindings)
+	}
+
+	generated := &gcpv1alpha1.GCPCredentials{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: testNamespace, Name: credentials.Name(testProjectId)}, generated); err != nil {
+		t.Fatalf("the credentials were not generated: %v", err)
+	}
+
+	if !conditions.IsTrue(project.Status.Conditions, gcpv1alpha1.CredentialsIssuedCondition) {
+		t.Errorf("expected the %s condition to be true, got %+v", gcpv1alpha1.CredentialsIssuedCondition, project.Status.Conditions)
+	}
+	if conditions.IsTrue(project.Status.Conditions, gcpv1alpha1.TokenExpiredCondition) {
+		t.Errorf("expected the %s condition to be false", gcpv1alpha1.TokenExpiredCondition)
+	}
+
+	// the token is kept unless asked to delete it
+	if err := r.client.Get(context.TODO(), testToken, &corev1.Secret{}); err != nil {
+		t.Errorf("expected the token Secret to be kept: %v", err)
+	}
+
+	reasons := recorded(recorder)
+	for _, reason := range []string{events.ProjectCreated, events.CredentialsIssued} {
+		if !contains(reasons, reason) {
+			t.Errorf("expected a %s event, got %v", reason, reasons)
+		}
+	}
+}
+
+func TestReconcileAdoptsUnmanagedAdminProject(t *testing.T) {
+	r, f, recorder := newTestReconciler(newAdminProject(), newToken())
+	f.Projects[testProjectId] = &cloudresourcemanager.Project{
+		ProjectId:      testProjectId,
+		Name:           "Admin",
+		Parent:         &cloudresourcemanager.ResourceId{Type: "organization", Id: testOrganizationId},
+		LifecycleState: "ACTIVE",
+	}
+	f.Policies[testProjectId] = &cloudresourcemanager.Policy{Etag: "0"}
+
+	project := reconcileUntilDone(t, r)
+
+	if f.Calls["Projects.Create"] != 0 {
+		t.Error("expected the existing admin project to be adopted rather than created")
+	}
+	owner := ownership.Owner{Object: project}
+
+	if !owner.IsOwner(f.Projects[testProjectId].Labels) {
+		t.Errorf("expected the adopted project to be labelled for the resource, got %v", f.Projects[testProjectId].Labels)
+	}
+	if reasons := recorded(recorder); !contains(reasons, events.ProjectAdopted) {
+		t.Errorf("expected a %s event, got %v", events.ProjectAdopted, reasons)
+	}
+}
+
+func TestReconcileDeletesTokenAfterBootstrap(t *testing.T) {
+	project := newAdminProject()
+	project.Spec.DeleteTokenAfterBootstrap = true
+
+	r, f, _ := newTestReconciler(project, newToken())
+
+	if provisioned := reconcileUntilDone(t, r); !provisioned.Status.TokenDeleted {
+		t.Error("expected the deletion of the token to be recorded in the status")
+	}
+
+	if err := r.client.Get(context.TODO(), testToken, &corev1.Secret{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected the token Secret to be deleted, got %v", err)
+	}
+
+	// without the token there is nothing left to do
+	calls := f.Calls["Factory.New"]
+
+	if _, err := r.Reconcile(testRequest); err != nil {
+		t.Fatalf("expected the reconcile without a token to be skipped, got %v", err)
+	}
+	if f.Calls["Factory.New"] != calls {
+		t.Error("expected no GCP client to be created without a token")
+	}
+
+	// a token provided again later is left for the user to remove
+	if err := r.client.Create(context.TODO(), newToken()); err != nil {
+		t.Fatalf("failed to recreate the token: %v", err)
+	}
+
+	if _, err := r.Reconcile(testRequest); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	if err := r.client.Get(context.TODO(), testToken, &corev1.Secret{}); err != nil {
+		t.Errorf("expected the token provided again to be kept: %v", err)
+	}
+}
+
+func TestReconcileReportsExpiredToken(t *testing.T) {
+	r, f, _ := newTestReconciler(newAdminProject(), newToken())
+	f.Errors["Projects.Exists"] = &googleapi.Error{Code: http.StatusUnauthorized, Message: "invalid credentials"}
+
+	if _, err := r.Reconcile(testRequest); err == nil {
+		t.Fatal("expected the reconcile to fail with the token rejected")
+	}
+
+	if project := getAdminProject(t, r.client); !conditions.IsTrue(project.Status.Conditions, gcpv1alpha1.TokenExpiredCondition) {
+		t.Fatalf("expected the %s condition to be true, got %+v", gcpv1alpha1.TokenExpiredCondition, project.Status.Conditions)
+	}
+
+	// a fresh token clears the condition
+	delete(f.Errors, "Projects.Exists")
+
+	if project := reconcileUntilDone(t, r); conditions.IsTrue(project.Status.Conditions, gcpv1alpha1.TokenExpiredCondition) {
+		t.Errorf("expected the %s condition to be cleared", gcpv1alpha1.TokenExpiredCondition)
+	}
+}
+
+func TestReconcileFailsWithoutToken(t *testing.T) {
+	r, _, _ := newTestReconciler(newAdminProject())
+
+	if _, err := r.Reconcile(testRequest); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the missing token Secret to be reported, got %v", err)
+	}
+}
+
+// bound checks the member is granted the role, without a condition
+func bound(policy *cloudresourcemanager.Policy, role, member string) bool {
+	if policy == nil {
+		return false
+	}
+	for _, b := range policy.Bindings {
+		if b.Role == role && b.Condition == nil && contains(b.Members, member) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package gcpadminproject
+
+import (
+	"context"
+	"time"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/ownership"
+	"github.com/appvia/gcp-operator/pkg/provisioner"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// resource describes the GCPAdminProject to the shared provisioner
+func resource(adminProjectInstance *gcpv1alpha1.GCPAdminProject) provisioner.Resource {
+	spec, status := adminProjectInstance.Spec, &adminProjectInstance.Status
+
+	return provisioner.Resource{
+		Object:    adminProjectInstance,
+		Kind:      "GCPAdminProject",
+		ProjectId: spec.ProjectId,
+		Spec: provisioner.Spec{
+			ProjectName:            spec.ProjectName,
+			ParentType:             spec.ParentType,
+			ParentId:               spec.ParentId,
+			BillingAccountName:     spec.BillingAccountName,
+			ServiceAccountName:     spec.ServiceAccountName,
+			Services:               spec.Services,
+			DisableRemovedServices: spec.DisableRemovedServices,
+			CredentialsPolicy:      spec.CredentialsPolicy,
+			KeyRotation:            spec.KeyRotation,
+			Bindings:               adminBindings(spec.ProjectId, spec.ServiceAccountName),
+			Mode:                   gcpv1alpha1.AdditiveMode,
+		},
+		Status: provisioner.Status{
+			Status:          &status.Status,
+			Operations:      &status.Operations,
+			Steps:           &status.Steps,
+			EnabledServices: &status.EnabledServices,
+			Conditions:      &status.Conditions,
+			LabelKeys:       &status.LabelKeys,
+			CredentialsRef:  &status.CredentialsRef,
+			Keys:            &status.Keys,
+		},
+	}
+}
+
+// adminBindings returns the role bindings granted to the admin service account on the admin
+// project. The organization roles it needs to provision other projects are out of scope for the
+// operator, as granting them needs an organization administrator: they are granted once by hand
+// and checked when the generated GCPCredentials are verified
+func adminBindings(projectId, serviceAccountName string) []gcpv1alpha1.IAMBinding {
+	return []gcpv1alpha1.IAMBinding{
+		{Role: "roles/viewer", Members: []string{"serviceAccount:" + gcp.ServiceAccountEmail(projectId, serviceAccountName)}},
+	}
+}
+
+// owner returns the owner stamped on the admin project
+func (r *ReconcileGCPAdminProject) owner(adminProjectInstance *gcpv1alpha1.GCPAdminProject) ownership.Owner {
+	// admin projects take over unlabelled projects, and those left by deleted resources, without an
+	// adoption policy
+	return ownership.Owner{ClusterID: r.config.ClusterID, Object: adminProjectInstance, Adopt: true, Reader: r.reader}
+}
+
+// reporter returns the reporter recording the progress of the admin project in its status, along
+// with whether GCP accepted the token
+func (r *ReconcileGCPAdminProject) reporter(adminProjectInstance *gcpv1alpha1.GCPAdminProject) *provisioner.Reporter {
+	return &provisioner.Reporter{
+		Client:   r.client,
+		Recorder: r.recorder,
+		Logger:   Logger,
+		OnUpdate: func(err error) {
+			setTokenExpired(adminProjectInstance, err)
+		},
+	}
+}
+
+// waitForOperations records the operations in the status and requeues the request to poll them
+func (r *ReconcileGCPAdminProject) waitForOperations(ctx context.Context, adminProjectInstance *gcpv1alpha1.GCPAdminProject, ops ...gcpv1alpha1.Operation) (reconcile.Result, error) {
+	return r.reporter(adminProjectInstance).WaitForOperations(ctx, resource(adminProjectInstance), r.config.OperationPollInterval, ops...)
+}
+
+// conflict reports the admin project is managed by another resource or cluster, and requeues
+// the request to check again later without running any of the steps
+func (r *ReconcileGCPAdminProject) conflict(ctx context.Context, adminProjectInstance *gcpv1alpha1.GCPAdminProject, err error, interval time.Duration) (reconcile.Result, error) {
+	return r.reporter(adminProjectInstance).Conflict(ctx, resource(adminProjectInstance), err, interval)
+}
+
+// failed records the failure of the step in the status
+func (r *ReconcileGCPAdminProject) failed(ctx context.Context, adminProjectInstance *gcpv1alpha1.GCPAdminProject, step string, err error) (reconcile.Result, error) {
+	return r.reporter(adminProjectInstance).Failed(ctx, resource(adminProjectInstance), step, err)
+}
+
+// updateStatus derives the conditions from the progress of the steps and updates the status
+func (r *ReconcileGCPAdminProject) updateStatus(ctx context.Context, adminProjectInstance *gcpv1alpha1.GCPAdminProject) error {
+	return r.reporter(adminProjectInstance).UpdateStatus(ctx, resource(adminProjectInstance))
+}
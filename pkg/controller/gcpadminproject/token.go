@@ -0,0 +1,94 @@
+package gcpadminproject
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/conditions"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// tokenSecretKey is the default key in the token Secret holding the bearer token
+const tokenSecretKey = "token"
+
+// token returns the bearer token of the admin project, reading it from the referenced Secret
+// or the spec
+func (r *ReconcileGCPAdminProject) token(ctx context.Context, adminProjectInstance *gcpv1alpha1.GCPAdminProject) (string, error) {
+	ref := adminProjectInstance.Spec.TokenRef
+
+	if ref == nil {
+		if adminProjectInstance.Spec.Token == "" {
+			return "", errors.New("the admin project has neither a token nor a tokenRef")
+		}
+		return adminProjectInstance.Spec.Token, nil
+	}
+
+	key := ref.Key
+	if key == "" {
+		key = tokenSecretKey
+	}
+
+	secret := &corev1.Secret{}
+
+	reference := types.NamespacedName{Namespace: adminProjectInstance.Namespace, Name: ref.Name}
+
+	if err := r.client.Get(ctx, reference, secret); err != nil {
+		return "", err
+	}
+
+	token, found := secret.Data[key]
+	if !found {
+		return "", fmt.Errorf("secret %s has no key %q", reference, key)
+	}
+
+	// tokens are usually piped straight from gcloud and carry a trailing newline
+	return strings.TrimSpace(string(token)), nil
+}
+
+// deleteToken removes the token Secret once the admin credentials have been issued, if requested.
+// It is only deleted once, a token provided again after bootstrap is left for the user to remove
+func (r *ReconcileGCPAdminProject) deleteToken(ctx context.Context, adminProjectInstance *gcpv1alpha1.GCPAdminProject) error {
+	ref := adminProjectInstance.Spec.TokenRef
+
+	if ref == nil || !adminProjectInstance.Spec.DeleteTokenAfterBootstrap || adminProjectInstance.Status.TokenDeleted {
+		return nil
+	}
+
+	secret := &corev1.Secret{}
+	secret.Name = ref.Name
+	secret.Namespace = adminProjectInstance.Namespace
+
+	Logger.Info("Deleting the token Secret after bootstrap", "Secret", ref.Name)
+
+	if err := r.client.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	adminProjectInstance.Status.TokenDeleted = true
+
+	return nil
+}
+
+// setTokenExpired records whether GCP rejected the bearer token
+func setTokenExpired(adminProjectInstance *gcpv1alpha1.GCPAdminProject, err error) {
+	condition := gcpv1alpha1.Condition{
+		Type:               gcpv1alpha1.TokenExpiredCondition,
+		Status:             gcpv1alpha1.ConditionFalse,
+		Reason:             "TokenAccepted",
+		ObservedGeneration: adminProjectInstance.Generation,
+	}
+
+	if gcp.IsUnauthorized(err) {
+		condition.Status = gcpv1alpha1.ConditionTrue
+		condition.Reason = "Unauthorized"
+		condition.Message = "GCP rejected the bearer token, update it with 'gcloud auth print-access-token'"
+	}
+
+	conditions.Set(&adminProjectInstance.Status.Conditions, condition)
+}
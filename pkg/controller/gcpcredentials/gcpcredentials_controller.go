@@ -0,0 +1,218 @@
+package gcpcredentials
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/conditions"
+	"github.com/appvia/gcp-operator/pkg/config"
+	"github.com/appvia/gcp-operator/pkg/events"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/indexes"
+	"github.com/appvia/gcp-operator/pkg/keys"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var logger = logf.Log.WithName("controller_gcpcredentials")
+
+// verifyInterval is how often valid credentials are re-verified
+var verifyInterval = time.Hour
+
+// Add creates a new GCPCredentials Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, options config.Config) error {
+	r, err := newReconciler(mgr, options)
+	if err != nil {
+		return err
+	}
+
+	return add(mgr, r)
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, options config.Config) (reconcile.Reconciler, error) {
+	clients, err := gcp.NewFactory(options.GCP)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReconcileGCPCredentials{client: mgr.GetClient(), scheme: mgr.GetScheme(), clients: clients, recorder: mgr.GetEventRecorderFor("gcpcredentials-controller")}, nil
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("gcpcredentials-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource GCPCredentials, ignoring our own status updates
+	err = c.Watch(&source.Kind{Type: &gcpv1alpha1.GCPCredentials{}}, &handler.EnqueueRequestForObject{}, predicate.GenerationChangedPredicate{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the Secrets holding the keys, so a rotated or fixed key is verified straight away
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: credentialsUsingSecret(mgr.GetClient())})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// credentialsUsingSecret enqueues the GCPCredentials whose spec.keyRef refers to the Secret
+func credentialsUsingSecret(c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		credentials := &gcpv1alpha1.GCPCredentialsList{}
+
+		err := c.List(context.TODO(), credentials, client.MatchingField(indexes.KeySecretField, indexes.Key(a.Meta.GetNamespace(), a.Meta.GetName())))
+
+		if err != nil {
+			logger.Error(err, "failed to list the credentials using the secret", "Namespace", a.Meta.GetNamespace(), "Name", a.Meta.GetName())
+
+			return nil
+		}
+
+		requests := make([]reconcile.Request, 0, len(credentials.Items))
+
+		for _, item := range credentials.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: item.Namespace, Name: item.Name}})
+		}
+
+		return requests
+	}
+}
+
+// blank assignment to verify that ReconcileGCPCredentials implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileGCPCredentials{}
+
+// ReconcileGCPCredentials reconciles a GCPCredentials object
+type ReconcileGCPCredentials struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+	// clients creates the GCP clients, a fake can be injected for testing
+	clients gcp.Factory
+	// recorder records events on the resources
+	recorder record.EventRecorder
+}
+
+// Reconcile verifies the GCPCredentials against GCP and records the outcome in the status
+// Note:
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileGCPCredentials) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := logger.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling GCPCredentials")
+
+	// Fetch the GCPCredentials instance
+	credentials := &gcpv1alpha1.GCPCredentials{}
+
+	if err := r.client.Get(context.TODO(), request.NamespacedName, credentials); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	ctx := context.Background()
+
+	var missing []string
+
+	auth, verifyErr := keys.Auth(ctx, r.client, credentials)
+
+	if verifyErr == nil {
+		missing, verifyErr = VerifyCredentials(ctx, r.client, r.clients, auth, credentials)
+	}
+
+	requeueAfter := verifyInterval
+
+	// consumers which cannot impersonate the service account are handed a token, replaced before it
+	// expires, only once the credentials are verified
+	if verifyErr == nil && len(missing) == 0 && credentials.Spec.TokenSecretRef != nil && auth.Impersonate != "" {
+		expiry, err := r.issueToken(ctx, credentials, auth)
+
+		if err != nil {
+			verifyErr = err
+		} else {
+			credentials.Status.TokenExpiryTime = &expiry
+
+			if after := refreshAfter(expiry); after < requeueAfter {
+				requeueAfter = after
+			}
+		}
+	}
+
+	wasVerified := credentials.Status.Verified
+
+	now := metav1.Now()
+	credentials.Status.LastVerified = &now
+	credentials.Status.MissingPermissions = missing
+	credentials.Status.Verified = verifyErr == nil && len(missing) == 0
+
+	ready := gcpv1alpha1.Condition{
+		Type:               gcpv1alpha1.ReadyCondition,
+		Status:             gcpv1alpha1.ConditionTrue,
+		Reason:             "Verified",
+		ObservedGeneration: credentials.Generation,
+	}
+
+	switch {
+	case verifyErr != nil:
+		ready.Status = gcpv1alpha1.ConditionFalse
+		ready.Reason = "VerificationFailed"
+		ready.Message = verifyErr.Error()
+	case len(missing) > 0:
+		ready.Status = gcpv1alpha1.ConditionFalse
+		ready.Reason = "MissingPermissions"
+		ready.Message = "missing permissions on the organization: " + strings.Join(missing, ", ")
+	}
+
+	conditions.Set(&credentials.Status.Conditions, ready)
+
+	if credentials.Status.Verified {
+		reqLogger.Info("Credentials verified")
+		credentials.Status.Status = "Success"
+
+		// credentials are re-verified periodically, only the first success is recorded
+		if !wasVerified {
+			r.recorder.Event(credentials, corev1.EventTypeNormal, events.Verified, "The credentials were verified")
+		}
+	} else {
+		reqLogger.Info("Credentials failed verification", "MissingPermissions", missing)
+		credentials.Status.Status = "Failure"
+
+		r.recorder.Event(credentials, corev1.EventTypeWarning, events.VerificationFailed, ready.Message)
+	}
+
+	if err := r.client.Status().Update(ctx, credentials); err != nil {
+		logger.Error(err, "failed to update the resource status")
+
+		return reconcile.Result{}, err
+	}
+
+	if verifyErr != nil {
+		reqLogger.Error(verifyErr, "failed to verify the credentials")
+
+		return reconcile.Result{}, verifyErr
+	}
+
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
@@ -0,0 +1,236 @@
+package gcpcredentials
+
+import (
+	"context"
+	"testing"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/credentials"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/gcp/fake"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v1"
+	iam "google.golang.org/api/iam/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	testNamespace      = "team"
+	testProjectId      = "demo-project"
+	testOrganizationId = "123456789012"
+)
+
+func init() {
+	// the fake client decodes with the client-go scheme, so the types are registered there
+	if err := gcpv1alpha1.SchemeBuilder.AddToScheme(scheme.Scheme); err != nil {
+		panic(err)
+	}
+}
+
+// newTestReconciler returns a reconciler backed by fake clients holding the objects
+func newTestReconciler(objs ...runtime.Object) (*ReconcileGCPCredentials, *fake.Fake) {
+	clients := fake.New()
+
+	return &ReconcileGCPCredentials{
+		client:   fakeclient.NewFakeClientWithScheme(scheme.Scheme, objs...),
+		scheme:   scheme.Scheme,
+		clients:  clients,
+		recorder: record.NewFakeRecorder(100),
+	}, clients
+}
+
+// reconcileCredentials reconciles the credentials and returns them as updated
+func reconcileCredentials(t *testing.T, r *ReconcileGCPCredentials, name string) (*gcpv1alpha1.GCPCredentials, error) {
+	t.Helper()
+
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: name}}
+
+	_, err := r.Reconcile(request)
+
+	updated := &gcpv1alpha1.GCPCredentials{}
+
+	if getErr := r.client.Get(context.TODO(), request.NamespacedName, updated); getErr != nil {
+		t.Fatalf("failed to get the credentials: %v", getErr)
+	}
+
+	return updated, err
+}
+
+// newGenerated returns the keyless credentials generated for the project, impersonating its
+// service account
+func newGenerated(t *testing.T, project *gcpv1alpha1.GCPProject) *gcpv1alpha1.GCPCredentials {
+	t.Helper()
+
+	generated := &gcpv1alpha1.GCPCredentials{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      credentials.Name(testProjectId),
+			Namespace: testNamespace,
+			Labels:    credentials.Labels("GCPProject", project),
+		},
+		Spec: credentials.Spec(gcpv1alpha1.ImpersonateCredentialsPolicy, credentials.Name(testProjectId), testProjectId, testOrganizationId, project.Spec.ServiceAccountName),
+	}
+
+	if err := controllerutil.SetControllerReference(project, generated, scheme.Scheme); err != nil {
+		t.Fatalf("failed to set the owner: %v", err)
+	}
+
+	return generated
+}
+
+func newProject() *gcpv1alpha1.GCPProject {
+	return &gcpv1alpha1.GCPProject{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "demo",
+			Namespace: testNamespace,
+			UID:       "4b1e7e52-0001",
+		},
+		Spec: gcpv1alpha1.GCPProjectSpec{
+			ProjectId:          testProjectId,
+			ServiceAccountName: "robot-account",
+		},
+	}
+}
+
+// addProject creates the GCP project and its service account in the fake
+func addProject(f *fake.Fake, project *gcpv1alpha1.GCPProject) {
+	email := gcp.ServiceAccountEmail(testProjectId, project.Spec.ServiceAccountName)
+
+	f.Projects[testProjectId] = &cloudresourcemanager.Project{ProjectId: testProjectId, LifecycleState: "ACTIVE"}
+	f.ServiceAccounts[email] = &iam.ServiceAccount{Email: email, ProjectId: testProjectId}
+}
+
+func TestReconcileRecordsMissingPermissions(t *testing.T) {
+	r, f := newTestReconciler(&gcpv1alpha1.GCPCredentials{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin", Namespace: testNamespace},
+		Spec: gcpv1alpha1.GCPCredentialsSpec{
+			Key:            "e30=",
+			ProjectId:      "admin-project",
+			OrganizationId: testOrganizationId,
+		},
+	})
+	f.DeniedPermissions["billing.resourceAssociations.create"] = true
+
+	updated, err := reconcileCredentials(t, r, "admin")
+
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if updated.Status.Verified {
+		t.Error("credentials missing permissions were verified")
+	}
+	if len(updated.Status.MissingPermissions) != 1 || updated.Status.MissingPermissions[0] != "billing.resourceAssociations.create" {
+		t.Errorf("expected billing.resourceAssociations.create to be missing, got %v", updated.Status.MissingPermissions)
+	}
+}
+
+func TestReconcileVerifiesGeneratedCredentialsAgainstTheirProject(t *testing.T) {
+	project := newProject()
+	generated := newGenerated(t, project)
+
+	r, f := newTestReconciler(project, generated)
+	addProject(f, project)
+
+	// the project service account holds no organization roles
+	for _, permission := range RequiredPermissions {
+		f.DeniedPermissions[permission] = true
+	}
+
+	updated, err := reconcileCredentials(t, r, generated.Name)
+
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if !updated.Status.Verified {
+		t.Errorf("the generated credentials were not verified: %+v", updated.Status.Conditions)
+	}
+	if f.Calls["Organizations.TestIamPermissions"] != 0 {
+		t.Error("the generated credentials were checked against the organization")
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: testNamespace, Name: credentials.TokenName(testProjectId)}, secret); err != nil {
+		t.Fatalf("no token was issued: %v", err)
+	}
+	if len(secret.Data[DefaultTokenSecretKey]) == 0 {
+		t.Error("the token Secret is empty")
+	}
+}
+
+func TestReconcileChecksLabelledCredentialsAgainstTheOrganization(t *testing.T) {
+	project := newProject()
+
+	// the labels alone do not make the credentials generated for the project
+	r, f := newTestReconciler(project, &gcpv1alpha1.GCPCredentials{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "labelled",
+			Namespace: testNamespace,
+			Labels:    credentials.Labels("GCPProject", project),
+		},
+		Spec: gcpv1alpha1.GCPCredentialsSpec{
+			Key:            "e30=",
+			ProjectId:      testProjectId,
+			OrganizationId: testOrganizationId,
+		},
+	})
+	addProject(f, project)
+	f.DeniedPermissions["resourcemanager.projects.create"] = true
+
+	updated, err := reconcileCredentials(t, r, "labelled")
+
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if updated.Status.Verified {
+		t.Error("the labelled credentials were verified without the organization permissions")
+	}
+	if f.Calls["Organizations.TestIamPermissions"] != 1 {
+		t.Error("the labelled credentials were not checked against the organization")
+	}
+}
+
+func TestReconcileRefusesKeylessImpersonation(t *testing.T) {
+	tests := map[string]func(*gcpv1alpha1.GCPCredentials){
+		"not generated for a project": func(c *gcpv1alpha1.GCPCredentials) {
+			c.OwnerReferences = nil
+		},
+		"another service account": func(c *gcpv1alpha1.GCPCredentials) {
+			c.Spec.ImpersonateServiceAccount = gcp.ServiceAccountEmail("admin-project", "hub-admin")
+		},
+	}
+
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			project := newProject()
+			generated := newGenerated(t, project)
+			change(generated)
+
+			r, f := newTestReconciler(project, generated)
+			addProject(f, project)
+
+			updated, err := reconcileCredentials(t, r, generated.Name)
+
+			if err == nil {
+				t.Error("expected the reconcile to fail")
+			}
+			if updated.Status.Verified {
+				t.Error("the credentials were verified")
+			}
+			if f.Calls["Tokens.GenerateAccessToken"] != 0 {
+				t.Error("a token was issued")
+			}
+
+			err = r.client.Get(context.TODO(), types.NamespacedName{Namespace: testNamespace, Name: credentials.TokenName(testProjectId)}, &corev1.Secret{})
+			if !apierrors.IsNotFound(err) {
+				t.Errorf("expected no token Secret, got %v", err)
+			}
+		})
+	}
+}
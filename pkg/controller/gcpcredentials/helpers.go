@@ -0,0 +1,67 @@
+package gcpcredentials
+
+import (
+	"context"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/keys"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RequiredPermissions are the organization level permissions granted by the
+// resourcemanager.projectCreator and billing.user roles which the operator
+// needs in order to provision projects
+var RequiredPermissions = []string{
+	"resourcemanager.projects.create",
+	"billing.resourceAssociations.create",
+}
+
+// VerifyCredentials is responsible for verifying the JSON key or impersonated service account of
+// GCP creds, it returns the required permissions which are missing on the organization. A revoked
+// key fails when it is exchanged for a token, as does impersonation without the token creator role.
+// Credentials generated for a GCPProject are only used within that project and never provision
+// others, so they are checked against their project and require no permissions
+func VerifyCredentials(ctx context.Context, reader client.Reader, clients gcp.Factory, auth gcp.Auth, creds *gcpv1alpha1.GCPCredentials) (missing []string, err error) {
+	source, err := keys.GeneratedFor(ctx, reader, creds)
+
+	if err != nil {
+		return missing, err
+	}
+
+	c, err := clients.New(ctx, auth)
+
+	if err != nil {
+		return missing, err
+	}
+
+	if source != nil && source.Kind == "GCPProject" && source.ProjectId == creds.Spec.ProjectId {
+		_, err := c.Projects.TestIamPermissions(ctx, creds.Spec.ProjectId, []string{"resourcemanager.projects.get"})
+
+		return missing, err
+	}
+
+	return TestOrganizationPermissions(ctx, c.Organizations, creds.Spec.OrganizationId, RequiredPermissions)
+}
+
+// TestOrganizationPermissions returns the permissions the caller does not hold on the organization
+func TestOrganizationPermissions(ctx context.Context, organizations gcp.Organizations, organizationId string, permissions []string) (missing []string, err error) {
+	held, err := organizations.TestIamPermissions(ctx, organizationId, permissions)
+
+	if err != nil {
+		return missing, err
+	}
+
+	granted := make(map[string]bool)
+	for _, p := range held {
+		granted[p] = true
+	}
+
+	for _, p := range permissions {
+		if !granted[p] {
+			missing = append(missing, p)
+		}
+	}
+
+	return missing, nil
+}
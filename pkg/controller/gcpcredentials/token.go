@@ -0,0 +1,71 @@
+package gcpcredentials
+
+import (
+	"context"
+	"time"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// DefaultTokenSecretKey is the key in the Secret of tokenSecretRef holding the access token
+const DefaultTokenSecretKey = "token"
+
+// tokenRefreshMargin is how long before it expires the access token is replaced, leaving
+// consumers time to read the new one
+var tokenRefreshMargin = 10 * time.Minute
+
+// issueToken writes an access token of the impersonated service account to the Secret of
+// tokenSecretRef, owned by the credentials, and returns when the token expires
+func (r *ReconcileGCPCredentials) issueToken(ctx context.Context, credentials *gcpv1alpha1.GCPCredentials, auth gcp.Auth) (metav1.Time, error) {
+	// the token is issued to the source identity rather than the impersonated service account
+	source := auth
+	source.Impersonate = ""
+
+	c, err := r.clients.New(ctx, source)
+
+	if err != nil {
+		return metav1.Time{}, err
+	}
+
+	token, err := c.Tokens.GenerateAccessToken(ctx, auth.Impersonate, gcp.ImpersonatedTokenLifetime)
+
+	if err != nil {
+		return metav1.Time{}, err
+	}
+
+	ref := credentials.Spec.TokenSecretRef
+
+	secretKey := ref.Key
+	if secretKey == "" {
+		secretKey = DefaultTokenSecretKey
+	}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: ref.Name, Namespace: credentials.Namespace}}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, r.client, secret, func() error {
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+		secret.Data[secretKey] = []byte(token.AccessToken)
+
+		return controllerutil.SetControllerReference(credentials, secret, r.scheme)
+	})
+
+	if err != nil {
+		return metav1.Time{}, err
+	}
+
+	return metav1.NewTime(token.Expiry), nil
+}
+
+// refreshAfter returns how long until the access token expiring at expiry should be replaced
+func refreshAfter(expiry metav1.Time) time.Duration {
+	if after := time.Until(expiry.Time) - tokenRefreshMargin; after > 0 {
+		return after
+	}
+	return time.Minute
+}
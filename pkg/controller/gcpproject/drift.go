@@ -0,0 +1,69 @@
+package gcpproject
+
+import (
+	"context"
+	"strings"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/conditions"
+	"github.com/appvia/gcp-operator/pkg/drift"
+	"github.com/appvia/gcp-operator/pkg/events"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/policy"
+	"github.com/appvia/gcp-operator/pkg/services"
+	"github.com/appvia/gcp-operator/pkg/steps"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// detectDrift checks the project against the spec, recording any drift in the status and, unless
+// the drift policy is Report, marking the steps which correct it as pending so they run again
+func (r *ReconcileGCPProject) detectDrift(ctx context.Context, c *gcp.Client, projectInstance *gcpv1alpha1.GCPProject, desiredLabels map[string]string, driftPolicy gcpv1alpha1.DriftPolicy) error {
+	spec := projectInstance.Spec
+	projectId := projectIdOf(projectInstance)
+
+	found, err := drift.Detect(ctx, c, drift.Desired{
+		ProjectId:          projectId,
+		ProjectName:        spec.ProjectName,
+		ParentType:         spec.ParentType,
+		ParentId:           spec.ParentId,
+		BillingAccountName: spec.BillingAccountName,
+		ServiceAccountName: spec.ServiceAccountName,
+		Services:           services.Desired(spec.Services),
+		Bindings:           policy.Bindings(spec.IAM, gcp.ServiceAccountEmail(projectId, spec.ServiceAccountName)),
+		Mode:               policy.Mode(spec.IAM),
+		ManagedRoles:       projectInstance.Status.ManagedRoles,
+		Labels:             desiredLabels,
+		LabelKeys:          projectInstance.Status.LabelKeys,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	now := metav1.Now()
+	projectInstance.Status.LastSyncTime = &now
+	projectInstance.Status.Drift = drift.Messages(found)
+
+	conditions.Set(&projectInstance.Status.Conditions, drift.Condition(found, driftPolicy, projectInstance.Generation))
+
+	if len(found) == 0 {
+		return nil
+	}
+
+	logger.Info("Project has drifted from the spec", "Drift", projectInstance.Status.Drift, "DriftPolicy", driftPolicy)
+
+	r.recorder.Event(projectInstance, corev1.EventTypeWarning, events.DriftDetected, strings.Join(projectInstance.Status.Drift, "; "))
+
+	if driftPolicy == gcpv1alpha1.ReportDriftPolicy {
+		return nil
+	}
+
+	for _, d := range found {
+		if d.Step != "" {
+			steps.SetPending(&projectInstance.Status.Steps, d.Step)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package gcpproject
+
+import (
+	"context"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/credentials"
+	"github.com/appvia/gcp-operator/pkg/events"
+	"github.com/appvia/gcp-operator/pkg/finalizers"
+	"github.com/appvia/gcp-operator/pkg/keys"
+	"github.com/appvia/gcp-operator/pkg/ownership"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// finalizerName is the finalizer placed on GCPProjects to clean up the GCP project
+const finalizerName = "gcpproject.gcp.compute.hub.appvia.io"
+
+// finalize is responsible for cleaning up a deleted GCPProject according to its deletion policy
+func (r *ReconcileGCPProject) finalize(ctx context.Context, projectInstance *gcpv1alpha1.GCPProject) (reconcile.Result, error) {
+	reqLogger := logger.WithValues("Request.Namespace", projectInstance.Namespace, "Request.Name", projectInstance.Name)
+
+	if !finalizers.Has(projectInstance, finalizerName) {
+		return reconcile.Result{}, nil
+	}
+
+	policy := projectInstance.Spec.DeletionPolicy
+	if policy == "" {
+		policy = gcpv1alpha1.DeletePolicy
+	}
+
+	reqLogger.Info("Deleting GCPProject", "DeletionPolicy", policy)
+
+	if policy != gcpv1alpha1.OrphanPolicy {
+		if err := r.cleanup(ctx, projectInstance, policy); err != nil {
+			reqLogger.Error(err, "failed to clean up the project")
+
+			r.recorder.Event(projectInstance, corev1.EventTypeWarning, events.DeletionFailed, err.Error())
+
+			return reconcile.Result{}, err
+		}
+	}
+
+	finalizers.Remove(projectInstance, finalizerName)
+
+	if err := r.client.Update(ctx, projectInstance); err != nil {
+		reqLogger.Error(err, "failed to remove the finalizer")
+
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// cleanup revokes the generated credentials and then disables billing or deletes the project
+func (r *ReconcileGCPProject) cleanup(ctx context.Context, projectInstance *gcpv1alpha1.GCPProject, policy gcpv1alpha1.DeletionPolicy) error {
+	reqLogger := logger.WithValues("Request.Namespace", projectInstance.Namespace, "Request.Name", projectInstance.Name)
+	projectId := projectIdOf(projectInstance)
+
+	// No project was created before an ID was generated from the prefix
+	if projectId == "" {
+		reqLogger.Info("No project ID has been chosen, there is nothing to clean up")
+
+		return nil
+	}
+
+	use := &gcpv1alpha1.GCPCredentials{}
+
+	reference := types.NamespacedName{
+		Namespace: projectInstance.Spec.Use.Namespace,
+		Name:      projectInstance.Spec.Use.Name,
+	}
+
+	err := r.client.Get(ctx, reference, use)
+
+	// without the credentials nothing can be cleaned up, and waiting on them would block the
+	// deletion of the resource and its namespace
+	if errors.IsNotFound(err) {
+		reqLogger.Info("The GCPCredentials in spec.use no longer exist, skipping the clean up")
+
+		r.recorder.Eventf(projectInstance, corev1.EventTypeWarning, events.CleanupSkipped, "The GCPCredentials %s no longer exist, project %s was left in place", reference, projectId)
+
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	auth, err := keys.Auth(ctx, r.client, use)
+
+	if err != nil {
+		return err
+	}
+
+	c, err := r.clients.New(ctx, auth)
+
+	if err != nil {
+		return err
+	}
+
+	// Revoke the key held by the generated credentials and remove them
+	generated := &gcpv1alpha1.GCPCredentials{}
+
+	err = r.client.Get(ctx, types.NamespacedName{Namespace: projectInstance.Namespace, Name: credentials.Name(projectId)}, generated)
+
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if err == nil && !keys.HasKey(generated) {
+		reqLogger.Info("The generated credentials impersonate the service account, there is no key to revoke")
+	}
+
+	if err == nil && keys.HasKey(generated) {
+		key, err := keys.Get(ctx, r.client, generated)
+
+		switch {
+		case errors.IsNotFound(err):
+			reqLogger.Info("The Secret holding the generated key no longer exists, skipping revocation")
+		case err != nil:
+			return err
+		default:
+			keyName, err := keys.Name(key)
+
+			if err != nil {
+				return err
+			}
+
+			reqLogger.Info("Revoking service account key: " + keyName)
+
+			if err := c.IAM.DeleteServiceAccountKey(ctx, keyName); err != nil {
+				return err
+			}
+
+			r.recorder.Eventf(projectInstance, corev1.EventTypeNormal, events.CredentialsRevoked, "Revoked service account key %s", keyName)
+		}
+
+		if err := r.client.Delete(ctx, generated); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	// Revoke the replaced keys still within the overlap of the key rotation
+	if err := credentials.Revoke(ctx, c.IAM, projectInstance.Status.Keys); err != nil {
+		return err
+	}
+
+	// Only a project labelled as managed by this resource is cleaned up, leaving alone projects
+	// managed elsewhere as well as unlabelled projects it was refused or never adopted
+	owner := ownership.Owner{ClusterID: r.config.ClusterID, Object: projectInstance}
+
+	owned, err := owner.Owns(ctx, c.Projects, projectId)
+
+	if err != nil {
+		return err
+	}
+
+	if !owned {
+		reqLogger.Info("The project does not exist or is not managed by this resource, skipping its clean up")
+
+		r.recorder.Eventf(projectInstance, corev1.EventTypeWarning, events.CleanupSkipped, "Project %s does not exist or is not managed by this resource, it was left in place", projectId)
+
+		return nil
+	}
+
+	if policy == gcpv1alpha1.DisableBillingPolicy {
+		reqLogger.Info("Disabling billing for project: " + projectId)
+
+		if err := c.Billing.DisableBilling(ctx, projectId); err != nil {
+			return err
+		}
+
+		r.recorder.Eventf(projectInstance, corev1.EventTypeNormal, events.BillingDisabled, "Disabled billing for project %s", projectId)
+
+		return nil
+	}
+
+	reqLogger.Info("Deleting service account: " + projectInstance.Spec.ServiceAccountName)
+
+	if err := c.IAM.DeleteServiceAccount(ctx, projectId, projectInstance.Spec.ServiceAccountName); err != nil {
+		return err
+	}
+
+	reqLogger.Info("Deleting project: " + projectId)
+
+	if err := c.Projects.Delete(ctx, projectId); err != nil {
+		return err
+	}
+
+	r.recorder.Eventf(projectInstance, corev1.EventTypeNormal, events.ProjectDeleted, "Deleted project %s", projectId)
+
+	return nil
+}
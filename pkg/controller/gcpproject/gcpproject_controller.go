@@ -2,14 +2,31 @@ package gcpproject
 
 import (
 	"context"
-	"encoding/base64"
+	"reflect"
+	"strings"
+	"time"
 
 	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/conditions"
+	"github.com/appvia/gcp-operator/pkg/config"
+	"github.com/appvia/gcp-operator/pkg/drift"
+	"github.com/appvia/gcp-operator/pkg/events"
+	"github.com/appvia/gcp-operator/pkg/finalizers"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/keys"
+	"github.com/appvia/gcp-operator/pkg/labels"
+	"github.com/appvia/gcp-operator/pkg/metrics"
+	"github.com/appvia/gcp-operator/pkg/operations"
+	"github.com/appvia/gcp-operator/pkg/ownership"
+	"github.com/appvia/gcp-operator/pkg/provisioner"
+	"github.com/appvia/gcp-operator/pkg/steps"
+	"github.com/appvia/gcp-operator/pkg/validation"
 	core "github.com/appvia/hub-apis/pkg/apis/core/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -21,15 +38,28 @@ import (
 
 var logger = logf.Log.WithName("controller_gcpproject")
 
+// credentialsRequeueInterval is how long to wait for unverified credentials
+var credentialsRequeueInterval = 30 * time.Second
+
 // Add creates a new GCPProject Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+func Add(mgr manager.Manager, options config.Config) error {
+	r, err := newReconciler(mgr, options)
+	if err != nil {
+		return err
+	}
+
+	return add(mgr, r)
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileGCPProject{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+func newReconciler(mgr manager.Manager, options config.Config) (reconcile.Reconciler, error) {
+	clients, err := gcp.NewFactory(options.GCP)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReconcileGCPProject{client: mgr.GetClient(), scheme: mgr.GetScheme(), config: options, clients: clients, recorder: mgr.GetEventRecorderFor("gcpproject-controller"), reader: mgr.GetAPIReader()}, nil
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -45,6 +75,18 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	if err != nil {
 		return err
 	}
+
+	// Watch for changes to the GCPCredentials used by the projects, including their verification status
+	err = c.Watch(&source.Kind{Type: &gcpv1alpha1.GCPCredentials{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: projectsUsingCredentials(mgr.GetClient())})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the Secrets holding the keys of those GCPCredentials
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: projectsUsingSecret(mgr.GetClient())})
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -57,6 +99,13 @@ type ReconcileGCPProject struct {
 	// that reads objects from the cache and writes to the apiserver
 	client client.Client
 	scheme *runtime.Scheme
+	config config.Config
+	// clients creates the GCP clients, a fake can be injected for testing
+	clients gcp.Factory
+	// recorder records events on the resources
+	recorder record.EventRecorder
+	// reader reads directly from the api server, for resources which are not cached
+	reader client.Reader
 }
 
 // Reconcile reads that state of the cluster for a GCPProject object and makes changes based on the state read
@@ -80,6 +129,57 @@ func (r *ReconcileGCPProject) Reconcile(request reconcile.Request) (reconcile.Re
 
 	reqLogger.Info("Found GCPProject CR")
 
+	ctx := context.Background()
+
+	// Clean up the project according to the deletion policy
+	if projectInstance.GetDeletionTimestamp() != nil {
+		return r.finalize(ctx, projectInstance)
+	}
+
+	// Ensure the project is cleaned up when the resource is deleted
+	if !finalizers.Has(projectInstance, finalizerName) {
+		finalizers.Add(projectInstance, finalizerName)
+
+		// resources created before deletion policies existed were never cleaned up, so they keep
+		// their GCP project rather than taking on the destructive default
+		if projectInstance.Spec.DeletionPolicy == "" && projectInstance.Status.Status != "" {
+			reqLogger.Info("Defaulting the deletion policy of an existing resource to Orphan")
+
+			projectInstance.Spec.DeletionPolicy = gcpv1alpha1.OrphanPolicy
+		}
+
+		if err := r.client.Update(ctx, projectInstance); err != nil {
+			logger.Error(err, "failed to add the finalizer")
+
+			return reconcile.Result{}, err
+		}
+	}
+
+	// The fields left out are defaulted here as well as by the webhook, which may not be enabled
+	defaulted := projectInstance.DeepCopy()
+
+	if err := r.config.Defaulter(r.reader).Project(ctx, projectInstance.Namespace, defaulted); err != nil {
+		reqLogger.Error(err, "failed to default the spec")
+
+		return reconcile.Result{}, err
+	}
+
+	if !reflect.DeepEqual(defaulted.Spec, projectInstance.Spec) {
+		reqLogger.Info("Defaulting the spec")
+
+		if err := r.client.Update(ctx, defaulted); err != nil {
+			reqLogger.Error(err, "failed to update the defaulted spec")
+
+			return reconcile.Result{}, err
+		}
+
+		projectInstance = defaulted
+	}
+
+	if err := validation.Defaulted(projectInstance.Spec.ParentType, projectInstance.Spec.ParentId, projectInstance.Spec.ServiceAccountName); err != nil {
+		return r.failed(ctx, projectInstance, gcpv1alpha1.CreateProjectStep, err)
+	}
+
 	credentials := &gcpv1alpha1.GCPCredentials{}
 
 	reference := types.NamespacedName{
@@ -87,8 +187,6 @@ func (r *ReconcileGCPProject) Reconcile(request reconcile.Request) (reconcile.Re
 		Name:      projectInstance.Spec.Use.Name,
 	}
 
-	ctx := context.Background()
-
 	err := r.client.Get(ctx, reference, credentials)
 
 	if err != nil {
@@ -97,232 +195,194 @@ func (r *ReconcileGCPProject) Reconcile(request reconcile.Request) (reconcile.Re
 
 	reqLogger.Info("Found GCPCredentials CR")
 
-	decoded, err := base64.StdEncoding.DecodeString(credentials.Spec.Key)
+	if !credentials.Status.Verified {
+		reqLogger.Info("GCPCredentials have not been verified yet, requeuing")
 
-	keyString := string(decoded)
+		conditions.Set(&projectInstance.Status.Conditions, gcpv1alpha1.Condition{
+			Type:               gcpv1alpha1.ReadyCondition,
+			Status:             gcpv1alpha1.ConditionFalse,
+			Reason:             "CredentialsNotVerified",
+			Message:            "waiting on GCPCredentials " + reference.String() + " to be verified",
+			ObservedGeneration: projectInstance.Generation,
+		})
 
-	// Authenticate to cloudresourcemanager
-	crm, err := GoogleCRMClient(ctx, keyString)
+		if err := r.client.Status().Update(ctx, projectInstance); err != nil {
+			logger.Error(err, "failed to update the resource status")
+
+			return reconcile.Result{}, err
+		}
+
+		return reconcile.Result{RequeueAfter: credentialsRequeueInterval}, nil
+	}
+
+	auth, err := keys.Auth(ctx, r.client, credentials)
 
 	if err != nil {
-		logger.Error(err, "Failed to obtain CRM client")
+		reqLogger.Error(err, "failed to read the key of the GCPCredentials")
+
 		return reconcile.Result{}, err
 	}
 
-	reqLogger.Info("Authenticated to CRM")
-
-	// Authenticate to cloudbilling
-	cb, err := GoogleCloudBillingClient(ctx, keyString)
+	c, err := r.clients.New(ctx, auth)
 
 	if err != nil {
-		logger.Error(err, "Failed to obtain CB client")
+		logger.Error(err, "Failed to obtain GCP client")
 		return reconcile.Result{}, err
 	}
 
-	reqLogger.Info("Authenticated to CB")
+	if err := r.ensureProjectId(ctx, c, projectInstance); err != nil {
+		return r.failed(ctx, projectInstance, gcpv1alpha1.CreateProjectStep, err)
+	}
 
-	// Get project details from spec
-	projectId, projectName, parentType, parentId := projectInstance.Spec.ProjectId, projectInstance.Spec.ProjectName, projectInstance.Spec.ParentType, projectInstance.Spec.ParentId
+	p := &provisioner.Provisioner{
+		Resource: resource(projectInstance),
+		Client:   r.client,
+		Scheme:   r.scheme,
+		GCP:      c,
+		Recorder: r.recorder,
+		Owner:    r.owner(projectInstance),
+	}
 
-	// Check if project already exists
-	projectExists, err := ProjectExists(ctx, crm, projectId)
+	// Resume from any long running operations started by a previous reconcile
+	if len(projectInstance.Status.Operations) > 0 {
+		step := projectInstance.Status.Operations[0].Step
 
-	if err != nil {
-		return reconcile.Result{}, err
-	}
+		pending, err := operations.Poll(projectInstance.Status.Operations, r.config.OperationTimeout, func(op gcpv1alpha1.Operation) (bool, error, error) {
+			return c.Operations.Done(ctx, op)
+		})
 
-	if projectExists {
-		project, err := GetProject(ctx, crm, projectId)
+		// the operations after a failed one are still tracked
+		projectInstance.Status.Operations = pending
 
-		if err != nil {
-			return reconcile.Result{}, err
+		if operations.IsFailed(err) {
+			return r.failed(ctx, projectInstance, step, err)
 		}
 
-		billingAccount, err := GetProjectBilling(ctx, cb, projectId)
-
+		// the operations could not be checked, they are polled again rather than failing the step
 		if err != nil {
+			reqLogger.Error(err, "failed to poll the operations", "Step", step)
+
 			return reconcile.Result{}, err
 		}
 
-		if projectName != project.Name || parentType != project.Parent.Type || parentId != project.Parent.Id {
-			// Exists but state differs
-			updateOperationName, err := UpdateProject(ctx, crm, projectId, projectName, parentId, parentType)
-
-			if err != nil {
-				return reconcile.Result{}, err
-			}
-
-			// Set status to pending
-			projectInstance.Status.Status = core.PendingStatus
+		if len(pending) > 0 {
+			reqLogger.Info("Waiting on operations", "Step", step, "Pending", len(pending))
 
-			if err := r.client.Status().Update(ctx, projectInstance); err != nil {
+			if err := r.updateStatus(ctx, projectInstance); err != nil {
 				logger.Error(err, "failed to update the resource status")
 
 				return reconcile.Result{}, err
 			}
 
-			// Wait for operation to complete
-			_, err = WaitForOperationCRM(ctx, crm, updateOperationName)
-
-			if err != nil {
-				return reconcile.Result{}, err
-			}
-
-			if err := r.client.Status().Update(ctx, projectInstance); err != nil {
-				logger.Error(err, "failed to update the resource status")
-
-				return reconcile.Result{}, err
-			}
-		} else {
-			reqLogger.Info("Project exists and state matches")
+			return reconcile.Result{RequeueAfter: r.config.OperationPollInterval}, nil
 		}
 
-		if billingAccount.Name != "billingAccounts/"+projectInstance.Spec.BillingAccountName {
-			reqLogger.Info("Project exists but billing account doesnt match, updating")
+		reqLogger.Info("Operations completed", "Step", step)
 
-			err = UpdateProjectBilling(ctx, cb, projectInstance.Spec.BillingAccountName, projectId)
-
-			if err != nil {
-				return reconcile.Result{}, err
+		// a step whose operations partly failed is run again rather than completed
+		if !steps.IsFailed(projectInstance.Status.Steps, step) {
+			if step == gcpv1alpha1.CreateProjectStep {
+				r.recorder.Eventf(projectInstance, corev1.EventTypeNormal, events.ProjectCreated, "Created project %s", projectIdOf(projectInstance))
 			}
-			reqLogger.Info("Project billing updated")
-		} else {
-			reqLogger.Info("Project exists and billing account matches")
-		}
-
-		// Set status to success
-		projectInstance.Status.Status = core.SuccessStatus
 
-		if err := r.client.Status().Update(ctx, projectInstance); err != nil {
-			logger.Error(err, "failed to update the resource status")
-			return reconcile.Result{}, err
+			steps.SetComplete(&projectInstance.Status.Steps, step, projectInstance.Generation)
 		}
-
-		return reconcile.Result{}, nil
 	}
 
-	// Project doesnt exist yet, create it
-	operationName, err := CreateProject(ctx, crm, projectId, projectName, parentId, parentType)
+	interval := drift.Interval(projectInstance.Spec.ResyncInterval, r.config.ResyncInterval)
+	driftPolicy := drift.Policy(projectInstance.Spec.DriftPolicy)
 
-	if err != nil {
-		return reconcile.Result{}, err
-	}
-
-	// Set status to pending
-	projectInstance.Status.Status = core.PendingStatus
+	// Never touch a project managed by another resource or cluster
+	err = p.Owner.Verify(ctx, c.Projects, projectIdOf(projectInstance))
 
-	if err := r.client.Status().Update(ctx, projectInstance); err != nil {
-		logger.Error(err, "failed to update the resource status")
+	if err != nil && !ownership.IsConflict(err) {
+		reqLogger.Error(err, "failed to check the ownership of the project")
 
 		return reconcile.Result{}, err
 	}
 
-	// Wait for operation to complete
-	_, err = WaitForOperationCRM(ctx, crm, operationName)
+	conditions.Set(&projectInstance.Status.Conditions, ownership.Condition(err, projectInstance.Generation))
 
 	if err != nil {
-		return reconcile.Result{}, err
+		return r.conflict(ctx, projectInstance, err, interval)
 	}
 
-	// Get a service management client for enabling required APIs
-	sm, err := GoogleServiceManagementClient(ctx, credentials.Spec.Key)
+	// The labels set on the project from the spec and the namespace
+	desiredLabels, skippedLabels, err := labels.Desired(ctx, r.reader, projectInstance.Namespace, r.config.NamespaceLabels, projectInstance.Spec.Labels, p.Owner.Labels())
 
 	if err != nil {
-		return reconcile.Result{}, err
+		return r.failed(ctx, projectInstance, gcpv1alpha1.CreateProjectStep, err)
 	}
 
-	servicesToEnable := []string{
-		"cloudresourcemanager.googleapis.com",
-		"cloudbilling.googleapis.com",
-		"iam.googleapis.com",
-		"compute.googleapis.com",
-		"serviceusage.googleapis.com",
+	if len(skippedLabels) > 0 {
+		r.recorder.Event(projectInstance, corev1.EventTypeWarning, events.LabelsSkipped, strings.Join(skippedLabels, "; "))
 	}
 
-	// Enable each API in the new project
-	for _, s := range servicesToEnable {
-		err := func() error {
-			name, err := EnableAPI(ctx, sm, projectId, s)
-			if err != nil {
-				return err
-			}
-			reqLogger.Info("Waiting for operation:", name)
-			if _, err = WaitForOperationSM(ctx, sm, name); err != nil {
-				return err
-			}
-			reqLogger.Info("Enabled service:", s)
+	p.Labels = desiredLabels
 
-			return nil
-		}()
-		if err != nil {
-			logger.Error(err, "failed to enable the service:", err)
+	// Once provisioned, periodically check the project still matches the spec
+	if steps.AllComplete(projectInstance.Status.Steps, provisioner.StepNames(), projectInstance.Generation) && drift.Due(projectInstance.Status.LastSyncTime, interval) {
+		if err := r.detectDrift(ctx, c, projectInstance, desiredLabels, driftPolicy); err != nil {
+			reqLogger.Error(err, "failed to check the project for drift")
 
 			return reconcile.Result{}, err
 		}
 	}
 
-	// Set billing
-	err = UpdateProjectBilling(ctx, cb, projectInstance.Spec.BillingAccountName, projectId)
+	// Run each of the provisioning steps which has not completed for this generation, along
+	// with the continuous steps unless drift is only being reported
+	for _, s := range p.Steps() {
+		if steps.IsComplete(projectInstance.Status.Steps, s.Name, projectInstance.Generation) && (!s.Continuous || driftPolicy == gcpv1alpha1.ReportDriftPolicy) {
+			continue
+		}
 
-	if err := r.client.Status().Update(ctx, projectInstance); err != nil {
-		logger.Error(err, "failed to update the resource status")
+		reqLogger.Info("Running step: " + s.Name)
 
-		return reconcile.Result{}, err
-	}
+		start := time.Now()
 
-	iam, err := GoogleIAMClient(ctx, keyString)
+		ops, err := s.Run(ctx)
 
-	if err != nil {
-		return reconcile.Result{}, err
-	}
+		metrics.ObserveStep(p.Kind, s.Name, start, err)
 
-	// Create service account and then create a key
-	reqLogger.Info("Creating service account: " + projectInstance.Spec.ServiceAccountName)
-	serviceAccount, err := CreateServiceAccount(ctx, iam, projectId, projectInstance.Spec.ServiceAccountName, "Created by the Appvia Hub")
+		if err != nil {
+			return r.failed(ctx, projectInstance, s.Name, err)
+		}
 
-	if err != nil {
-		return reconcile.Result{}, err
-	}
+		if len(ops) > 0 {
+			steps.SetPending(&projectInstance.Status.Steps, s.Name)
 
-	reqLogger.Info("Creating service account key for: " + projectInstance.Spec.ServiceAccountName)
-	key, err := CreateServiceAccountKey(ctx, iam, projectId, projectInstance.Spec.ServiceAccountName)
+			return r.waitForOperations(ctx, projectInstance, ops...)
+		}
 
-	if err != nil {
-		return reconcile.Result{}, err
+		steps.SetComplete(&projectInstance.Status.Steps, s.Name, projectInstance.Generation)
+
+		if err := r.updateStatus(ctx, projectInstance); err != nil {
+			logger.Error(err, "failed to update the resource status")
+
+			return reconcile.Result{}, err
+		}
 	}
 
-	err = MakeProjectAdmin(ctx, crm, projectId, serviceAccount.Name, serviceAccount.Email)
+	// Keep track of the key of the generated credentials and replace it when due
+	rotateAfter, err := p.RotateKey(ctx)
 
 	if err != nil {
-		return reconcile.Result{}, err
+		return r.failed(ctx, projectInstance, gcpv1alpha1.IssueCredentialsStep, err)
 	}
 
-	// Create the credential as a CR
-	reqLogger.Info("Creating the GCPCredentials CR:" + projectId + "-gcpcreds in namespace: " + request.Namespace)
-
-	serviceAccountCredential := &gcpv1alpha1.GCPCredentials{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      projectId + "-gcpcreds",
-			Namespace: request.Namespace,
-		},
-		Spec: gcpv1alpha1.GCPCredentialsSpec{
-			Key:            key,
-			ProjectId:      projectId,
-			OrganizationId: parentId,
-		},
-		Status: gcpv1alpha1.GCPCredentialsStatus{
-			Status: "Success",
-		},
+	requeueAfter := interval
+	if rotateAfter > 0 && rotateAfter < requeueAfter {
+		requeueAfter = rotateAfter
 	}
 
-	err = r.client.Create(ctx, serviceAccountCredential)
+	// Set status to success
+	projectInstance.Status.Status = core.SuccessStatus
+
+	if err := r.updateStatus(ctx, projectInstance); err != nil {
+		logger.Error(err, "failed to update the resource status")
 
-	if err != nil {
 		return reconcile.Result{}, err
 	}
 
-	// Set status to success
-	projectInstance.Status.Status = core.SuccessStatus
-
-	return reconcile.Result{}, nil
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
 }
@@ -0,0 +1,482 @@
+package gcpproject
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/config"
+	"github.com/appvia/gcp-operator/pkg/credentials"
+	"github.com/appvia/gcp-operator/pkg/events"
+	"github.com/appvia/gcp-operator/pkg/finalizers"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/gcp/fake"
+	"github.com/appvia/gcp-operator/pkg/keys"
+	"github.com/appvia/gcp-operator/pkg/ownership"
+	core "github.com/appvia/hub-apis/pkg/apis/core/v1"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	testNamespace      = "team"
+	testProjectId      = "demo-project"
+	testOrganizationId = "123456789012"
+	testBillingAccount = "012345-567890-ABCDEF"
+)
+
+var testRequest = reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: "demo"}}
+
+func init() {
+	// the fake client decodes with the client-go scheme, so the types are registered there
+	if err := gcpv1alpha1.SchemeBuilder.AddToScheme(scheme.Scheme); err != nil {
+		panic(err)
+	}
+}
+
+// newProject returns a GCPProject using the verified admin credentials
+func newProject() *gcpv1alpha1.GCPProject {
+	return &gcpv1alpha1.GCPProject{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "demo",
+			Namespace: testNamespace,
+			UID:       "4b1e7e52-0001",
+		},
+		Spec: gcpv1alpha1.GCPProjectSpec{
+			ProjectId:          testProjectId,
+			ProjectName:        "Demo",
+			ParentType:         "organization",
+			ParentId:           testOrganizationId,
+			BillingAccountName: testBillingAccount,
+			ServiceAccountName: "robot-account",
+			Use:                core.Ownership{Namespace: testNamespace, Name: "admin"},
+			Services:           []string{"compute.googleapis.com"},
+		},
+	}
+}
+
+// newAdminCredentials returns verified credentials holding a key, which the fake ignores
+func newAdminCredentials() *gcpv1alpha1.GCPCredentials {
+	return &gcpv1alpha1.GCPCredentials{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin", Namespace: testNamespace},
+		Spec: gcpv1alpha1.GCPCredentialsSpec{
+			Key:            "e30=",
+			ProjectId:      "admin-project",
+			OrganizationId: testOrganizationId,
+		},
+		Status: gcpv1alpha1.GCPCredentialsStatus{Verified: true},
+	}
+}
+
+// newTestReconciler returns a reconciler backed by fake clients holding the objects
+func newTestReconciler(objs ...runtime.Object) (*ReconcileGCPProject, *fake.Fake, *record.FakeRecorder) {
+	c := fakeclient.NewFakeClientWithScheme(scheme.Scheme, objs...)
+	clients := fake.New()
+	recorder := record.NewFakeRecorder(100)
+
+	return &ReconcileGCPProject{
+		client:   c,
+		scheme:   scheme.Scheme,
+		config:   config.New(),
+		clients:  clients,
+		recorder: recorder,
+		reader:   c,
+	}, clients, recorder
+}
+
+// reconcileUntilDone reconciles the project until it is provisioned or fails
+func reconcileUntilDone(t *testing.T, r *ReconcileGCPProject) *gcpv1alpha1.GCPProject {
+	t.Helper()
+
+	for i := 0; i < 20; i++ {
+		if _, err := r.Reconcile(testRequest); err != nil {
+			t.Fatalf("reconcile failed: %v", err)
+		}
+
+		project := getProject(t, r.client)
+
+		switch project.Status.Status {
+		case core.SuccessStatus:
+			return project
+		case core.FailureStatus:
+			t.Fatalf("provisioning failed: %+v", project.Status.Steps)
+		}
+	}
+
+	t.Fatal("the project was not provisioned after 20 reconciles")
+
+	return nil
+}
+
+func getProject(t *testing.T, c client.Client) *gcpv1alpha1.GCPProject {
+	t.Helper()
+
+	project := &gcpv1alpha1.GCPProject{}
+
+	if err := c.Get(context.TODO(), testRequest.NamespacedName, project); err != nil {
+		t.Fatalf("failed to get the project: %v", err)
+	}
+
+	return project
+}
+
+// recorded returns the reasons of the events recorded so far
+func recorded(recorder *record.FakeRecorder) []string {
+	var reasons []string
+
+	for {
+		select {
+		case event := <-recorder.Events:
+			reasons = append(reasons, strings.Fields(event)[1])
+		default:
+			return reasons
+		}
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// markDeleted sets the deletion timestamp, which the fake client leaves to the caller
+func markDeleted(t *testing.T, c client.Client, policy gcpv1alpha1.DeletionPolicy) {
+	t.Helper()
+
+	project := getProject(t, c)
+	now := metav1.Now()
+	project.DeletionTimestamp = &now
+	project.Spec.DeletionPolicy = policy
+
+	if err := c.Update(context.TODO(), project); err != nil {
+		t.Fatalf("failed to mark the project deleted: %v", err)
+	}
+}
+
+func TestReconcileProvisionsProject(t *testing.T) {
+	r, f, recorder := newTestReconciler(newProject(), newAdminCredentials())
+
+	project := reconcileUntilDone(t, r)
+
+	if !finalizers.Has(project, finalizerName) {
+		t.Error("the finalizer was not added")
+	}
+
+	created, found := f.Projects[testProjectId]
+	if !found {
+		t.Fatal("the project was not created")
+	}
+	if created.Labels[ownership.UIDLabel] != ownership.LabelValue(string(project.UID)) {
+		t.Errorf("the project is not labelled for the resource: %v", created.Labels)
+	}
+	if f.BillingAccounts[testProjectId] != "billingAccounts/"+testBillingAccount {
+		t.Errorf("expected billing account %s to be linked, got %q", testBillingAccount, f.BillingAccounts[testProjectId])
+	}
+	if !f.EnabledServices[testProjectId]["compute.googleapis.com"] {
+		t.Error("compute.googleapis.com was not enabled")
+	}
+	if _, found := f.ServiceAccounts[gcp.ServiceAccountEmail(testProjectId, "robot-account")]; !found {
+		t.Error("the service account was not created")
+	}
+
+	generated := &gcpv1alpha1.GCPCredentials{}
+	name := types.NamespacedName{Namespace: testNamespace, Name: credentials.Name(testProjectId)}
+
+	if err := r.client.Get(context.TODO(), name, generated); err != nil {
+		t.Fatalf("the credentials were not generated: %v", err)
+	}
+	if generated.Spec.OrganizationId != testOrganizationId {
+		t.Errorf("expected the credentials to record organization %s, got %q", testOrganizationId, generated.Spec.OrganizationId)
+	}
+
+	key, err := keys.Get(context.TODO(), r.client, generated)
+	if err != nil {
+		t.Fatalf("failed to read the generated key: %v", err)
+	}
+	keyName, err := keys.Name(key)
+	if err != nil {
+		t.Fatalf("the generated key is invalid: %v", err)
+	}
+	if !f.Keys[keyName] {
+		t.Errorf("the key %s in the Secret was not issued", keyName)
+	}
+
+	reasons := recorded(recorder)
+	for _, reason := range []string{events.ProjectCreated, events.CredentialsIssued} {
+		if !contains(reasons, reason) {
+			t.Errorf("expected a %s event, got %v", reason, reasons)
+		}
+	}
+}
+
+func TestReconcileLinksBillingBeforeEnablingServices(t *testing.T) {
+	r, f, _ := newTestReconciler(newProject(), newAdminCredentials())
+	f.Errors["Billing.SetBillingAccount"] = errors.New("billing account is closed")
+
+	// the project is created and then the failed step is retried on each reconcile
+	for i := 0; i < 5; i++ {
+		_, _ = r.Reconcile(testRequest)
+	}
+
+	if project := getProject(t, r.client); project.Status.Status != core.FailureStatus {
+		t.Errorf("expected the project to fail, got %q", project.Status.Status)
+	}
+	if calls := f.Calls["Services.Enable"]; calls != 0 {
+		t.Errorf("services were enabled %d times before billing was linked", calls)
+	}
+}
+
+func TestReconcileKeepsOperationsWhenPollingFails(t *testing.T) {
+	r, f, _ := newTestReconciler(newProject(), newAdminCredentials())
+	f.OperationPolls = 1
+
+	// reconcile until the project creation is waiting on its operation
+	for i := 0; i < 5 && len(getProject(t, r.client).Status.Operations) == 0; i++ {
+		if _, err := r.Reconcile(testRequest); err != nil {
+			t.Fatalf("reconcile failed: %v", err)
+		}
+	}
+
+	f.Errors["Operations.Done"] = errors.New("backend error")
+
+	if _, err := r.Reconcile(testRequest); err == nil {
+		t.Error("expected the reconcile to fail while the operations cannot be checked")
+	}
+
+	project := getProject(t, r.client)
+	if project.Status.Status == core.FailureStatus {
+		t.Errorf("the project failed on an error checking its operations: %+v", project.Status.Steps)
+	}
+	if len(project.Status.Operations) == 0 {
+		t.Error("the operation was dropped")
+	}
+
+	delete(f.Errors, "Operations.Done")
+
+	reconcileUntilDone(t, r)
+}
+
+func TestReconcileDeletesProject(t *testing.T) {
+	r, f, recorder := newTestReconciler(newProject(), newAdminCredentials())
+
+	reconcileUntilDone(t, r)
+	markDeleted(t, r.client, gcpv1alpha1.DeletePolicy)
+
+	if _, err := r.Reconcile(testRequest); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	if finalizers.Has(getProject(t, r.client), finalizerName) {
+		t.Error("the finalizer was not removed")
+	}
+	if _, found := f.Projects[testProjectId]; found {
+		t.Error("the project was not deleted")
+	}
+	if _, found := f.ServiceAccounts[gcp.ServiceAccountEmail(testProjectId, "robot-account")]; found {
+		t.Error("the service account was not deleted")
+	}
+	if len(f.Keys) > 0 {
+		t.Errorf("the keys were not revoked: %v", f.Keys)
+	}
+
+	err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: testNamespace, Name: credentials.Name(testProjectId)}, &gcpv1alpha1.GCPCredentials{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected the generated credentials to be removed, got %v", err)
+	}
+
+	if reasons := recorded(recorder); !contains(reasons, events.ProjectDeleted) {
+		t.Errorf("expected a %s event, got %v", events.ProjectDeleted, reasons)
+	}
+}
+
+func TestReconcileOrphansProject(t *testing.T) {
+	r, f, _ := newTestReconciler(newProject(), newAdminCredentials())
+
+	reconcileUntilDone(t, r)
+	markDeleted(t, r.client, gcpv1alpha1.OrphanPolicy)
+
+	if _, err := r.Reconcile(testRequest); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	if finalizers.Has(getProject(t, r.client), finalizerName) {
+		t.Error("the finalizer was not removed")
+	}
+	if _, found := f.Projects[testProjectId]; !found {
+		t.Error("the orphaned project was deleted")
+	}
+	if len(f.Keys) == 0 {
+		t.Error("the key of the orphaned project was revoked")
+	}
+}
+
+func TestReconcileLeavesUnmanagedProject(t *testing.T) {
+	project := newProject()
+	now := metav1.Now()
+	project.DeletionTimestamp = &now
+	project.Spec.DeletionPolicy = gcpv1alpha1.DeletePolicy
+	finalizers.Add(project, finalizerName)
+
+	r, f, recorder := newTestReconciler(project, newAdminCredentials())
+
+	// a project of the same ID which the operator never labelled
+	f.Projects[testProjectId] = &cloudresourcemanager.Project{ProjectId: testProjectId, LifecycleState: "ACTIVE"}
+
+	if _, err := r.Reconcile(testRequest); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	if finalizers.Has(getProject(t, r.client), finalizerName) {
+		t.Error("the finalizer was not removed")
+	}
+	if _, found := f.Projects[testProjectId]; !found {
+		t.Error("the unmanaged project was deleted")
+	}
+	if calls := f.Calls["Projects.Delete"] + f.Calls["Billing.DisableBilling"]; calls != 0 {
+		t.Errorf("the unmanaged project was cleaned up with %d calls", calls)
+	}
+	if reasons := recorded(recorder); !contains(reasons, events.CleanupSkipped) {
+		t.Errorf("expected a %s event, got %v", events.CleanupSkipped, reasons)
+	}
+}
+
+func TestReconcileReleasesProjectWithoutCredentials(t *testing.T) {
+	r, f, recorder := newTestReconciler(newProject(), newAdminCredentials())
+
+	reconcileUntilDone(t, r)
+	markDeleted(t, r.client, gcpv1alpha1.DeletePolicy)
+
+	if err := r.client.Delete(context.TODO(), newAdminCredentials()); err != nil {
+		t.Fatalf("failed to delete the credentials: %v", err)
+	}
+
+	if _, err := r.Reconcile(testRequest); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	if finalizers.Has(getProject(t, r.client), finalizerName) {
+		t.Error("the finalizer was not removed")
+	}
+	if _, found := f.Projects[testProjectId]; !found {
+		t.Error("the project was deleted without credentials")
+	}
+	if reasons := recorded(recorder); !contains(reasons, events.CleanupSkipped) {
+		t.Errorf("expected a %s event, got %v", events.CleanupSkipped, reasons)
+	}
+}
+
+func TestReconcileRotatesKey(t *testing.T) {
+	project := newProject()
+	project.Spec.KeyRotation = &gcpv1alpha1.KeyRotation{Interval: metav1.Duration{Duration: time.Hour}}
+
+	r, f, recorder := newTestReconciler(project, newAdminCredentials())
+
+	reconcileUntilDone(t, r)
+
+	// backdate the key so it is due to be replaced
+	secret := &corev1.Secret{}
+	name := types.NamespacedName{Namespace: testNamespace, Name: credentials.Name(testProjectId)}
+
+	if err := r.client.Get(context.TODO(), name, secret); err != nil {
+		t.Fatalf("failed to get the Secret: %v", err)
+	}
+	previous, err := keys.Name(secret.Data[keys.DefaultSecretKey])
+	if err != nil {
+		t.Fatalf("the generated key is invalid: %v", err)
+	}
+
+	project = getProject(t, r.client)
+	if len(project.Status.Keys) != 1 || project.Status.Keys[0].Name != previous {
+		t.Fatalf("expected the key in the Secret to be tracked, got %+v", project.Status.Keys)
+	}
+	project.Status.Keys[0].CreationTime = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+
+	if err := r.client.Status().Update(context.TODO(), project); err != nil {
+		t.Fatalf("failed to backdate the key: %v", err)
+	}
+
+	if _, err := r.Reconcile(testRequest); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	if err := r.client.Get(context.TODO(), name, secret); err != nil {
+		t.Fatalf("failed to get the Secret: %v", err)
+	}
+	current, err := keys.Name(secret.Data[keys.DefaultSecretKey])
+	if err != nil {
+		t.Fatalf("the replacement key is invalid: %v", err)
+	}
+
+	if current == previous {
+		t.Fatal("the key was not replaced")
+	}
+	if f.Keys[previous] {
+		t.Error("the replaced key was not revoked")
+	}
+	if !f.Keys[current] {
+		t.Error("the replacement key was not issued")
+	}
+
+	tracked := getProject(t, r.client).Status.Keys
+	if len(tracked) != 1 || tracked[0].Name != current {
+		t.Errorf("expected only the replacement key to be tracked, got %+v", tracked)
+	}
+
+	if reasons := recorded(recorder); !contains(reasons, events.KeyRotated) {
+		t.Errorf("expected a %s event, got %v", events.KeyRotated, reasons)
+	}
+}
+
+func TestReconcileRemovesRolesDroppedFromAuthoritativePolicy(t *testing.T) {
+	project := newProject()
+	project.Spec.IAM = &gcpv1alpha1.IAMPolicy{
+		Mode: gcpv1alpha1.AuthoritativeMode,
+		Bindings: []gcpv1alpha1.IAMBinding{
+			{Role: "roles/owner", Members: []string{gcpv1alpha1.ServiceAccountMember}},
+			{Role: "roles/viewer", Members: []string{"group:auditors@example.com"}},
+		},
+	}
+
+	r, f, _ := newTestReconciler(project, newAdminCredentials())
+
+	project = reconcileUntilDone(t, r)
+
+	if len(project.Status.ManagedRoles) != 2 {
+		t.Fatalf("expected both roles to be managed, got %v", project.Status.ManagedRoles)
+	}
+
+	// the fake client leaves the generation to the caller
+	project.Spec.IAM.Bindings = project.Spec.IAM.Bindings[:1]
+	project.Generation++
+
+	if err := r.client.Update(context.TODO(), project); err != nil {
+		t.Fatalf("failed to update the project: %v", err)
+	}
+
+	project = reconcileUntilDone(t, r)
+
+	for _, b := range f.Policies[testProjectId].Bindings {
+		if b.Role == "roles/viewer" {
+			t.Errorf("the dropped role still has members %v", b.Members)
+		}
+	}
+	if len(project.Status.ManagedRoles) != 1 || project.Status.ManagedRoles[0] != "roles/owner" {
+		t.Errorf("expected only roles/owner to be managed, got %v", project.Status.ManagedRoles)
+	}
+}
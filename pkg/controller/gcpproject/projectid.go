@@ -0,0 +1,68 @@
+package gcpproject
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"k8s.io/apimachinery/pkg/util/rand"
+)
+
+// generateAttempts is how many generated project IDs are tried before giving up
+const generateAttempts = 5
+
+// suffixLength is the length of the random suffix of generated project IDs
+const suffixLength = 6
+
+// projectIdOf returns the ID of the GCP project, as given in the spec or generated from the prefix
+func projectIdOf(projectInstance *gcpv1alpha1.GCPProject) string {
+	if projectInstance.Spec.ProjectId != "" {
+		return projectInstance.Spec.ProjectId
+	}
+	return projectInstance.Status.ProjectId
+}
+
+// ensureProjectId records the ID of the project in the status, generating an unused ID from the
+// prefix when the spec gives none. A generated ID is saved straight away so the same project is
+// used on every reconcile
+func (r *ReconcileGCPProject) ensureProjectId(ctx context.Context, c *gcp.Client, projectInstance *gcpv1alpha1.GCPProject) error {
+	spec := projectInstance.Spec
+
+	if spec.ProjectId != "" {
+		projectInstance.Status.ProjectId = spec.ProjectId
+		return nil
+	}
+
+	if projectInstance.Status.ProjectId != "" {
+		return nil
+	}
+
+	if spec.ProjectIdPrefix == "" {
+		return errors.New("either spec.projectId or spec.projectIdPrefix must be set")
+	}
+
+	for i := 0; i < generateAttempts; i++ {
+		projectId := spec.ProjectIdPrefix + "-" + rand.String(suffixLength)
+
+		exists, err := c.Projects.Exists(ctx, projectId)
+
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			logger.Info("Generated project ID is taken, trying another", "ProjectId", projectId)
+			continue
+		}
+
+		logger.Info("Generated project ID", "ProjectId", projectId)
+
+		projectInstance.Status.ProjectId = projectId
+
+		return r.client.Status().Update(ctx, projectInstance)
+	}
+
+	return fmt.Errorf("no unused project ID found for prefix %s after %d attempts", spec.ProjectIdPrefix, generateAttempts)
+}
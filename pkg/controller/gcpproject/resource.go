@@ -0,0 +1,82 @@
+package gcpproject
+
+import (
+	"context"
+	"time"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/ownership"
+	"github.com/appvia/gcp-operator/pkg/policy"
+	"github.com/appvia/gcp-operator/pkg/provisioner"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// resource describes the GCPProject to the shared provisioner
+func resource(projectInstance *gcpv1alpha1.GCPProject) provisioner.Resource {
+	spec, status := projectInstance.Spec, &projectInstance.Status
+	projectId := projectIdOf(projectInstance)
+
+	return provisioner.Resource{
+		Object:    projectInstance,
+		Kind:      "GCPProject",
+		ProjectId: projectId,
+		Spec: provisioner.Spec{
+			ProjectName:            spec.ProjectName,
+			ParentType:             spec.ParentType,
+			ParentId:               spec.ParentId,
+			BillingAccountName:     spec.BillingAccountName,
+			ServiceAccountName:     spec.ServiceAccountName,
+			Services:               spec.Services,
+			DisableRemovedServices: spec.DisableRemovedServices,
+			CredentialsPolicy:      spec.CredentialsPolicy,
+			KeyRotation:            spec.KeyRotation,
+			GeneratedProjectId:     spec.ProjectId == "",
+			Bindings:               policy.Bindings(spec.IAM, gcp.ServiceAccountEmail(projectId, spec.ServiceAccountName)),
+			Mode:                   policy.Mode(spec.IAM),
+		},
+		Status: provisioner.Status{
+			Status:          &status.Status,
+			Operations:      &status.Operations,
+			Steps:           &status.Steps,
+			EnabledServices: &status.EnabledServices,
+			Conditions:      &status.Conditions,
+			LabelKeys:       &status.LabelKeys,
+			CredentialsRef:  &status.CredentialsRef,
+			Keys:            &status.Keys,
+			ProjectId:       &status.ProjectId,
+			ManagedRoles:    &status.ManagedRoles,
+		},
+	}
+}
+
+// owner returns the owner stamped on the project
+func (r *ReconcileGCPProject) owner(projectInstance *gcpv1alpha1.GCPProject) ownership.Owner {
+	return ownership.Owner{ClusterID: r.config.ClusterID, Object: projectInstance, Adopt: projectInstance.Spec.AdoptionPolicy == gcpv1alpha1.AdoptPolicy, Reader: r.reader}
+}
+
+// reporter returns the reporter recording the progress of the project in its status
+func (r *ReconcileGCPProject) reporter() *provisioner.Reporter {
+	return &provisioner.Reporter{Client: r.client, Recorder: r.recorder, Logger: logger}
+}
+
+// waitForOperations records the operations in the status and requeues the request to poll them
+func (r *ReconcileGCPProject) waitForOperations(ctx context.Context, projectInstance *gcpv1alpha1.GCPProject, ops ...gcpv1alpha1.Operation) (reconcile.Result, error) {
+	return r.reporter().WaitForOperations(ctx, resource(projectInstance), r.config.OperationPollInterval, ops...)
+}
+
+// conflict reports the project is managed by another resource or cluster, and requeues the
+// request to check again later without running any of the steps
+func (r *ReconcileGCPProject) conflict(ctx context.Context, projectInstance *gcpv1alpha1.GCPProject, err error, interval time.Duration) (reconcile.Result, error) {
+	return r.reporter().Conflict(ctx, resource(projectInstance), err, interval)
+}
+
+// failed records the failure of the step in the status
+func (r *ReconcileGCPProject) failed(ctx context.Context, projectInstance *gcpv1alpha1.GCPProject, step string, err error) (reconcile.Result, error) {
+	return r.reporter().Failed(ctx, resource(projectInstance), step, err)
+}
+
+// updateStatus derives the conditions from the progress of the steps and updates the status
+func (r *ReconcileGCPProject) updateStatus(ctx context.Context, projectInstance *gcpv1alpha1.GCPProject) error {
+	return r.reporter().UpdateStatus(ctx, resource(projectInstance))
+}
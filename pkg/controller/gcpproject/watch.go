@@ -0,0 +1,64 @@
+package gcpproject
+
+import (
+	"context"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/indexes"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// projectsUsingCredentials enqueues the GCPProjects whose spec.use refers to the GCPCredentials,
+// so projects waiting on missing or unverified credentials carry on once they are fixed
+func projectsUsingCredentials(c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		return projectRequests(c, a.Meta.GetNamespace(), a.Meta.GetName())
+	}
+}
+
+// projectsUsingSecret enqueues the GCPProjects using the GCPCredentials whose key is in the Secret
+func projectsUsingSecret(c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		credentials := &gcpv1alpha1.GCPCredentialsList{}
+
+		err := c.List(context.TODO(), credentials, client.MatchingField(indexes.KeySecretField, indexes.Key(a.Meta.GetNamespace(), a.Meta.GetName())))
+
+		if err != nil {
+			logger.Error(err, "failed to list the credentials using the secret", "Namespace", a.Meta.GetNamespace(), "Name", a.Meta.GetName())
+
+			return nil
+		}
+
+		var requests []reconcile.Request
+
+		for _, item := range credentials.Items {
+			requests = append(requests, projectRequests(c, item.Namespace, item.Name)...)
+		}
+
+		return requests
+	}
+}
+
+// projectRequests returns a request for every GCPProject using the named GCPCredentials
+func projectRequests(c client.Client, namespace, name string) []reconcile.Request {
+	projects := &gcpv1alpha1.GCPProjectList{}
+
+	err := c.List(context.TODO(), projects, client.MatchingField(indexes.CredentialsField, indexes.Key(namespace, name)))
+
+	if err != nil {
+		logger.Error(err, "failed to list the projects using the credentials", "Namespace", namespace, "Name", name)
+
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(projects.Items))
+
+	for _, item := range projects.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: item.Namespace, Name: item.Name}})
+	}
+
+	return requests
+}
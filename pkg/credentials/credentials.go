@@ -0,0 +1,166 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/keys"
+	"github.com/appvia/gcp-operator/pkg/ownership"
+	core "github.com/appvia/hub-apis/pkg/apis/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// SourceKindLabel is the kind of the resource the credentials were generated for
+	SourceKindLabel = "gcp.compute.hub.appvia.io/source-kind"
+	// SourceNameLabel is the name of the resource the credentials were generated for
+	SourceNameLabel = "gcp.compute.hub.appvia.io/source-name"
+	// SourceUIDLabel is the UID of the resource the credentials were generated for
+	SourceUIDLabel = "gcp.compute.hub.appvia.io/source-uid"
+)
+
+// Source is a resource the credentials are generated for, it owns the GCPCredentials and the
+// Secret so they are garbage collected along with it
+type Source interface {
+	metav1.Object
+	runtime.Object
+}
+
+// Name returns the name of the GCPCredentials and Secret generated for the project
+func Name(projectId string) string {
+	return projectId + "-gcpcreds"
+}
+
+// TokenName returns the name of the Secret holding the access token of the generated credentials
+// which impersonate the service account of the project
+func TokenName(projectId string) string {
+	return projectId + "-gcptoken"
+}
+
+// OrganizationId returns the organization the project belongs to, the parent of a project may be
+// a folder so its ancestry is walked rather than taking the parent ID
+func OrganizationId(ctx context.Context, projects gcp.Projects, projectId string) (string, error) {
+	organizationId, err := projects.Organization(ctx, projectId)
+
+	if err != nil {
+		return "", err
+	}
+
+	if organizationId == "" {
+		return "", fmt.Errorf("project %s does not belong to an organization", projectId)
+	}
+
+	return organizationId, nil
+}
+
+// Spec returns the spec of the GCPCredentials generated for the project. With the Impersonate
+// policy they act as the service account with tokens kept in the Secret named by TokenName,
+// otherwise they refer to the key in the Secret of the given name
+func Spec(policy gcpv1alpha1.CredentialsPolicy, name, projectId, organizationId, serviceAccountName string) gcpv1alpha1.GCPCredentialsSpec {
+	spec := gcpv1alpha1.GCPCredentialsSpec{
+		ProjectId:      projectId,
+		OrganizationId: organizationId,
+	}
+
+	if policy == gcpv1alpha1.ImpersonateCredentialsPolicy {
+		spec.ImpersonateServiceAccount = gcp.ServiceAccountEmail(projectId, serviceAccountName)
+		spec.TokenSecretRef = &gcpv1alpha1.SecretKeyReference{Name: TokenName(projectId)}
+
+		return spec
+	}
+
+	spec.KeyRef = &gcpv1alpha1.SecretKeyReference{
+		Name: name,
+		Key:  keys.DefaultSecretKey,
+	}
+
+	return spec
+}
+
+// Labels returns the labels linking the generated resources to their source
+func Labels(kind string, source metav1.Object) map[string]string {
+	return map[string]string{
+		SourceKindLabel: kind,
+		SourceNameLabel: ownership.LabelValue(source.GetName()),
+		SourceUIDLabel:  string(source.GetUID()),
+	}
+}
+
+// Reference returns a reference to the credentials suitable for spec.use
+func Reference(credentials *gcpv1alpha1.GCPCredentials) *core.Ownership {
+	return &core.Ownership{
+		Group:     gcpv1alpha1.SchemeGroupVersion.Group,
+		Version:   gcpv1alpha1.SchemeGroupVersion.Version,
+		Kind:      "GCPCredentials",
+		Namespace: credentials.Namespace,
+		Name:      credentials.Name,
+	}
+}
+
+// EnsureSecret creates the Secret holding the service account key, calling mint for a base64
+// encoded key only when the Secret holds none, and makes sure it is owned by and labelled with
+// the source
+func EnsureSecret(ctx context.Context, c client.Client, scheme *runtime.Scheme, kind string, source Source, name string, mint func() (string, error)) (controllerutil.OperationResult, error) {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: source.GetNamespace()}}
+
+	return controllerutil.CreateOrUpdate(ctx, c, secret, func() error {
+		// A key is only minted if a previous attempt did not get as far as storing one
+		if len(secret.Data[keys.DefaultSecretKey]) == 0 {
+			key, err := mint()
+
+			if err != nil {
+				return err
+			}
+
+			minted, err := keys.NewSecret(name, source.GetNamespace(), key)
+
+			if err != nil {
+				return err
+			}
+
+			secret.Type = minted.Type
+			secret.Data = minted.Data
+
+			if secret.Annotations == nil {
+				secret.Annotations = make(map[string]string)
+			}
+			secret.Annotations[CreationTimeAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		}
+
+		setLabels(&secret.ObjectMeta, Labels(kind, source))
+
+		return controllerutil.SetControllerReference(source, secret, scheme)
+	})
+}
+
+// Ensure creates or updates the GCPCredentials with the spec, owned by and labelled with the source
+func Ensure(ctx context.Context, c client.Client, scheme *runtime.Scheme, kind string, source Source, name string, spec gcpv1alpha1.GCPCredentialsSpec) (*gcpv1alpha1.GCPCredentials, controllerutil.OperationResult, error) {
+	credentials := &gcpv1alpha1.GCPCredentials{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: source.GetNamespace()}}
+
+	result, err := controllerutil.CreateOrUpdate(ctx, c, credentials, func() error {
+		credentials.Spec = spec
+
+		setLabels(&credentials.ObjectMeta, Labels(kind, source))
+
+		return controllerutil.SetControllerReference(source, credentials, scheme)
+	})
+
+	return credentials, result, err
+}
+
+// setLabels adds the labels to the object, keeping any others
+func setLabels(meta *metav1.ObjectMeta, labels map[string]string) {
+	if meta.Labels == nil {
+		meta.Labels = make(map[string]string)
+	}
+	for k, v := range labels {
+		meta.Labels[k] = v
+	}
+}
@@ -0,0 +1,277 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/keys"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CreationTimeAnnotation on the Secret of generated credentials is when the key it holds was issued
+const CreationTimeAnnotation = "gcp.compute.hub.appvia.io/key-creation-time"
+
+// Rotation tracks the keys of the generated credentials and replaces them on schedule
+type Rotation struct {
+	Client client.Client
+	IAM    gcp.IAM
+	// Secret is the Secret of the generated credentials holding the current key
+	Secret types.NamespacedName
+	// ProjectId and ServiceAccountName identify the service account the keys are issued for
+	ProjectId          string
+	ServiceAccountName string
+	// Policy is the key rotation in the spec, nil when keys are never replaced
+	Policy *gcpv1alpha1.KeyRotation
+}
+
+// RotationResult describes the changes made to the keys
+type RotationResult struct {
+	// Issued is the key which replaced the current key, if any
+	Issued *gcpv1alpha1.ServiceAccountKey
+	// Deleted are the replaced keys deleted once their overlap had passed
+	Deleted []gcpv1alpha1.ServiceAccountKey
+	// RequeueAfter is how long until the next key is due to be replaced or deleted, zero if none is
+	RequeueAfter time.Duration
+}
+
+// Rotate records the key held by the Secret in the keys, replaces it once the interval has passed
+// and deletes the replaced keys once the overlap has passed. The keys are updated as far as the
+// rotation got, even if it fails
+func (r Rotation) Rotate(ctx context.Context, status *[]gcpv1alpha1.ServiceAccountKey, now time.Time) (RotationResult, error) {
+	result := RotationResult{}
+
+	secret := &corev1.Secret{}
+
+	if err := r.Client.Get(ctx, r.Secret, secret); err != nil {
+		return result, err
+	}
+
+	current, err := track(secret, status, now)
+
+	if err != nil {
+		return result, err
+	}
+
+	if r.Policy != nil {
+		due := current.CreationTime.Add(r.Policy.Interval.Duration)
+
+		if !now.Before(due) {
+			issued, err := r.replace(ctx, secret, now)
+
+			if err != nil {
+				return result, err
+			}
+
+			replaced(*status, now)
+			*status = append(*status, issued)
+
+			result.Issued = &issued
+			due = now.Add(r.Policy.Interval.Duration)
+		}
+
+		result.RequeueAfter = due.Sub(now)
+	}
+
+	for i := 0; i < len(*status); {
+		key := (*status)[i]
+
+		if key.ReplacedTime == nil {
+			i++
+			continue
+		}
+
+		expiry := key.ReplacedTime.Add(r.overlap())
+
+		if now.Before(expiry) {
+			result.RequeueAfter = earliest(result.RequeueAfter, expiry.Sub(now))
+			i++
+			continue
+		}
+
+		if err := r.IAM.DeleteServiceAccountKey(ctx, key.Name); err != nil {
+			return result, err
+		}
+
+		*status = append((*status)[:i], (*status)[i+1:]...)
+		result.Deleted = append(result.Deleted, key)
+	}
+
+	return result, nil
+}
+
+// Revoke deletes every key recorded in the keys
+func Revoke(ctx context.Context, iam gcp.IAM, status []gcpv1alpha1.ServiceAccountKey) error {
+	for _, key := range status {
+		if err := iam.DeleteServiceAccountKey(ctx, key.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Drop revokes every key of the generated credentials, including the one held by the Secret if it
+// is missing from the keys, and deletes the Secret. It returns the keys revoked, which are removed
+// from the keys even if a later one fails
+func Drop(ctx context.Context, c client.Client, iam gcp.IAM, name types.NamespacedName, status *[]gcpv1alpha1.ServiceAccountKey, now time.Time) ([]gcpv1alpha1.ServiceAccountKey, error) {
+	secret := &corev1.Secret{}
+
+	err := c.Get(ctx, name, secret)
+
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	found := err == nil
+
+	if found && len(secret.Data[keys.DefaultSecretKey]) > 0 {
+		if _, err := track(secret, status, now); err != nil {
+			return nil, err
+		}
+	}
+
+	var revoked []gcpv1alpha1.ServiceAccountKey
+
+	for len(*status) > 0 {
+		key := (*status)[0]
+
+		if err := iam.DeleteServiceAccountKey(ctx, key.Name); err != nil && !gcp.IsNotFound(err) {
+			return revoked, err
+		}
+
+		*status = (*status)[1:]
+		revoked = append(revoked, key)
+	}
+
+	if found {
+		if err := c.Delete(ctx, secret); err != nil && !errors.IsNotFound(err) {
+			return revoked, err
+		}
+	}
+
+	return revoked, nil
+}
+
+// overlap returns how long replaced keys stay valid
+func (r Rotation) overlap() time.Duration {
+	if r.Policy == nil || r.Policy.Overlap == nil {
+		return 0
+	}
+	return r.Policy.Overlap.Duration
+}
+
+// replace issues a new key and writes it to the Secret, returning the record of the new key
+func (r Rotation) replace(ctx context.Context, secret *corev1.Secret, now time.Time) (gcpv1alpha1.ServiceAccountKey, error) {
+	encoded, err := r.IAM.CreateServiceAccountKey(ctx, r.ProjectId, r.ServiceAccountName)
+
+	if err != nil {
+		return gcpv1alpha1.ServiceAccountKey{}, err
+	}
+
+	minted, err := keys.NewSecret(secret.Name, secret.Namespace, encoded)
+
+	if err != nil {
+		return gcpv1alpha1.ServiceAccountKey{}, err
+	}
+
+	issued, err := record(minted.Data[keys.DefaultSecretKey], metav1.NewTime(now))
+
+	if err != nil {
+		return gcpv1alpha1.ServiceAccountKey{}, err
+	}
+
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	secret.Data[keys.DefaultSecretKey] = minted.Data[keys.DefaultSecretKey]
+
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[CreationTimeAnnotation] = now.UTC().Format(time.RFC3339)
+
+	if err := r.Client.Update(ctx, secret); err != nil {
+		// the new key was never stored, so it is deleted rather than left valid
+		if deleteErr := r.IAM.DeleteServiceAccountKey(ctx, issued.Name); deleteErr != nil {
+			return gcpv1alpha1.ServiceAccountKey{}, fmt.Errorf("%w, and the unused key %s could not be deleted: %v", err, issued.Name, deleteErr)
+		}
+		return gcpv1alpha1.ServiceAccountKey{}, err
+	}
+
+	return issued, nil
+}
+
+// track records the key held by the Secret in the keys if it is missing and marks any other
+// current key as replaced, returning the record of the key in the Secret
+func track(secret *corev1.Secret, status *[]gcpv1alpha1.ServiceAccountKey, now time.Time) (gcpv1alpha1.ServiceAccountKey, error) {
+	key, found := secret.Data[keys.DefaultSecretKey]
+	if !found {
+		return gcpv1alpha1.ServiceAccountKey{}, fmt.Errorf("secret %s/%s has no key %q", secret.Namespace, secret.Name, keys.DefaultSecretKey)
+	}
+
+	created := secret.CreationTimestamp
+
+	if value, found := secret.Annotations[CreationTimeAnnotation]; found {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			created = metav1.NewTime(t)
+		}
+	}
+
+	current, err := record(key, created)
+
+	if err != nil {
+		return gcpv1alpha1.ServiceAccountKey{}, err
+	}
+
+	for i := range *status {
+		if (*status)[i].Name == current.Name {
+			return (*status)[i], nil
+		}
+	}
+
+	// the Secret was given a new key without the status being updated
+	replaced(*status, now)
+	*status = append(*status, current)
+
+	return current, nil
+}
+
+// replaced marks the current keys as replaced
+func replaced(status []gcpv1alpha1.ServiceAccountKey, now time.Time) {
+	for i := range status {
+		if status[i].ReplacedTime == nil {
+			t := metav1.NewTime(now)
+			status[i].ReplacedTime = &t
+		}
+	}
+}
+
+// record returns the record of the JSON service account key
+func record(key []byte, created metav1.Time) (gcpv1alpha1.ServiceAccountKey, error) {
+	name, err := keys.Name(key)
+
+	if err != nil {
+		return gcpv1alpha1.ServiceAccountKey{}, err
+	}
+
+	return gcpv1alpha1.ServiceAccountKey{
+		ID:           path.Base(name),
+		Name:         name,
+		CreationTime: created,
+	}, nil
+}
+
+// earliest returns the shorter of the durations, ignoring a zero duration
+func earliest(a, b time.Duration) time.Duration {
+	if a == 0 || b < a {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,219 @@
+package credentials
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/gcp/fake"
+	"github.com/appvia/gcp-operator/pkg/keys"
+	iam "google.golang.org/api/iam/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testProjectId      = "demo-project"
+	testServiceAccount = "robot-account"
+)
+
+var testSecret = types.NamespacedName{Namespace: "team", Name: Name(testProjectId)}
+
+// newKeySecret issues a key from the fake and returns the Secret holding it, issued at the time
+func newKeySecret(t *testing.T, c *gcp.Client, issued time.Time) *corev1.Secret {
+	t.Helper()
+
+	encoded, err := c.IAM.CreateServiceAccountKey(context.TODO(), testProjectId, testServiceAccount)
+	if err != nil {
+		t.Fatalf("failed to issue a key: %v", err)
+	}
+
+	secret, err := keys.NewSecret(testSecret.Name, testSecret.Namespace, encoded)
+	if err != nil {
+		t.Fatalf("failed to create the secret: %v", err)
+	}
+	secret.Annotations = map[string]string{CreationTimeAnnotation: issued.UTC().Format(time.RFC3339)}
+
+	return secret
+}
+
+// newFakeIAM returns a fake holding the service account and a client using it
+func newFakeIAM(t *testing.T) (*fake.Fake, *gcp.Client) {
+	t.Helper()
+
+	f := fake.New()
+	f.ServiceAccounts[gcp.ServiceAccountEmail(testProjectId, testServiceAccount)] = &iam.ServiceAccount{}
+
+	c, err := f.New(context.TODO(), gcp.Auth{})
+	if err != nil {
+		t.Fatalf("failed to create the client: %v", err)
+	}
+
+	return f, c
+}
+
+func TestRotate(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	daily := &gcpv1alpha1.KeyRotation{Interval: metav1.Duration{Duration: 24 * time.Hour}}
+	overlapping := &gcpv1alpha1.KeyRotation{Interval: metav1.Duration{Duration: 24 * time.Hour}, Overlap: &metav1.Duration{Duration: time.Hour}}
+
+	tests := []struct {
+		name   string
+		policy *gcpv1alpha1.KeyRotation
+		// age is how long ago the key in the Secret was issued
+		age time.Duration
+		// replacedAgo is how long ago a key recorded in the status was replaced, zero if none was
+		replacedAgo  time.Duration
+		issued       bool
+		deleted      int
+		requeueAfter time.Duration
+	}{
+		{name: "never rotated", age: 48 * time.Hour},
+		{name: "not due", policy: daily, age: 10 * time.Hour, requeueAfter: 14 * time.Hour},
+		{name: "due", policy: daily, age: 24 * time.Hour, issued: true, deleted: 1, requeueAfter: 24 * time.Hour},
+		{name: "due with overlap", policy: overlapping, age: 30 * time.Hour, issued: true, requeueAfter: time.Hour},
+		{name: "replaced key within overlap", policy: overlapping, age: 10 * time.Hour, replacedAgo: 30 * time.Minute, requeueAfter: 30 * time.Minute},
+		{name: "replaced key past overlap", policy: overlapping, age: 10 * time.Hour, replacedAgo: 2 * time.Hour, deleted: 1, requeueAfter: 14 * time.Hour},
+		{name: "replaced key without a policy", age: 10 * time.Hour, replacedAgo: time.Minute, deleted: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, c := newFakeIAM(t)
+			secret := newKeySecret(t, c, now.Add(-test.age))
+
+			var status []gcpv1alpha1.ServiceAccountKey
+
+			if test.replacedAgo > 0 {
+				old, err := record(newKeySecret(t, c, now.Add(-test.age-time.Hour)).Data[keys.DefaultSecretKey], metav1.NewTime(now.Add(-test.age-time.Hour)))
+				if err != nil {
+					t.Fatalf("failed to record the replaced key: %v", err)
+				}
+				replacedTime := metav1.NewTime(now.Add(-test.replacedAgo))
+				old.ReplacedTime = &replacedTime
+				status = append(status, old)
+			}
+
+			rotation := Rotation{
+				Client:             fakeclient.NewFakeClientWithScheme(scheme.Scheme, secret),
+				IAM:                c.IAM,
+				Secret:             testSecret,
+				ProjectId:          testProjectId,
+				ServiceAccountName: testServiceAccount,
+				Policy:             test.policy,
+			}
+
+			result, err := rotation.Rotate(context.TODO(), &status, now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if (result.Issued != nil) != test.issued {
+				t.Errorf("expected issued %t, got %+v", test.issued, result.Issued)
+			}
+			if len(result.Deleted) != test.deleted {
+				t.Errorf("expected %d keys deleted, got %+v", test.deleted, result.Deleted)
+			}
+			if result.RequeueAfter != test.requeueAfter {
+				t.Errorf("expected requeue after %s, got %s", test.requeueAfter, result.RequeueAfter)
+			}
+
+			for _, key := range result.Deleted {
+				if f.Keys[key.Name] {
+					t.Errorf("expected key %s to be revoked", key.Name)
+				}
+			}
+
+			current := 0
+			for _, key := range status {
+				if key.ReplacedTime == nil {
+					current++
+				}
+				if !f.Keys[key.Name] {
+					t.Errorf("expected key %s in the status to be valid", key.Name)
+				}
+			}
+			if current != 1 {
+				t.Errorf("expected one current key, got %+v", status)
+			}
+		})
+	}
+}
+
+func TestRotateWritesTheNewKeyToTheSecret(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	_, c := newFakeIAM(t)
+	client := fakeclient.NewFakeClientWithScheme(scheme.Scheme, newKeySecret(t, c, now.Add(-48*time.Hour)))
+
+	rotation := Rotation{
+		Client:             client,
+		IAM:                c.IAM,
+		Secret:             testSecret,
+		ProjectId:          testProjectId,
+		ServiceAccountName: testServiceAccount,
+		Policy:             &gcpv1alpha1.KeyRotation{Interval: metav1.Duration{Duration: 24 * time.Hour}},
+	}
+
+	var status []gcpv1alpha1.ServiceAccountKey
+
+	result, err := rotation.Rotate(context.TODO(), &status, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Issued == nil {
+		t.Fatal("expected the key to be replaced")
+	}
+
+	secret := &corev1.Secret{}
+	if err := client.Get(context.TODO(), testSecret, secret); err != nil {
+		t.Fatalf("failed to get the secret: %v", err)
+	}
+
+	name, err := keys.Name(secret.Data[keys.DefaultSecretKey])
+	if err != nil {
+		t.Fatalf("failed to read the key: %v", err)
+	}
+	if name != result.Issued.Name {
+		t.Errorf("expected the secret to hold key %s, got %s", result.Issued.Name, name)
+	}
+	if secret.Annotations[CreationTimeAnnotation] != now.UTC().Format(time.RFC3339) {
+		t.Errorf("expected the creation time annotation to be updated, got %q", secret.Annotations[CreationTimeAnnotation])
+	}
+}
+
+func TestDrop(t *testing.T) {
+	now := time.Now()
+	f, c := newFakeIAM(t)
+	secret := newKeySecret(t, c, now)
+	client := fakeclient.NewFakeClientWithScheme(scheme.Scheme, secret)
+
+	// the key in the Secret was never recorded in the status
+	recorded, err := record(newKeySecret(t, c, now).Data[keys.DefaultSecretKey], metav1.NewTime(now))
+	if err != nil {
+		t.Fatalf("failed to record the key: %v", err)
+	}
+	status := []gcpv1alpha1.ServiceAccountKey{recorded}
+
+	revoked, err := Drop(context.TODO(), client, c.IAM, testSecret, &status, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(revoked) != 2 || len(status) != 0 {
+		t.Errorf("expected both keys to be revoked and removed from the status, got %+v and %+v", revoked, status)
+	}
+	if len(f.Keys) != 0 {
+		t.Errorf("expected no valid keys, got %v", f.Keys)
+	}
+
+	err = client.Get(context.TODO(), testSecret, &corev1.Secret{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected the secret to be deleted, got %v", err)
+	}
+}
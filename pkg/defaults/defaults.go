@@ -0,0 +1,123 @@
+package defaults
+
+import (
+	"context"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ParentTypeAnnotation on a namespace is the default parent type of the projects in it
+	ParentTypeAnnotation = "gcp.compute.hub.appvia.io/parent-type"
+	// ParentIdAnnotation on a namespace is the default parent ID of the projects in it
+	ParentIdAnnotation = "gcp.compute.hub.appvia.io/parent-id"
+)
+
+// Defaulter fills in the fields left out of GCP projects. It is used by the defaulting webhook and
+// by the controllers, so the defaults apply when the webhooks are not enabled
+type Defaulter struct {
+	// Reader reads directly from the api server, namespaces and credentials are not cached
+	Reader client.Reader
+	// ServiceAccountName is the default name of the service account created in the projects
+	ServiceAccountName string
+	// Labels are added to the labels in the spec unless already set
+	Labels map[string]string
+}
+
+// Project fills in the service account name and the parent from the namespace annotations or the
+// organization of the credentials
+func (d Defaulter) Project(ctx context.Context, namespace string, project *gcpv1alpha1.GCPProject) error {
+	spec := &project.Spec
+
+	if spec.ServiceAccountName == "" {
+		spec.ServiceAccountName = d.ServiceAccountName
+	}
+
+	if spec.ParentType == "" && spec.ParentId == "" {
+		parentType, parentId, err := d.namespaceParent(ctx, namespace)
+
+		if err != nil {
+			return err
+		}
+
+		if parentType == "" && spec.Use.Name != "" && spec.Use.Namespace != "" {
+			credentials := &gcpv1alpha1.GCPCredentials{}
+
+			err := d.Reader.Get(ctx, types.NamespacedName{Namespace: spec.Use.Namespace, Name: spec.Use.Name}, credentials)
+
+			// missing credentials are reported by the validating webhook or the controller
+			if err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+
+			if err == nil {
+				parentType, parentId = "organization", credentials.Spec.OrganizationId
+			}
+		}
+
+		spec.ParentType, spec.ParentId = parentType, parentId
+	}
+
+	return nil
+}
+
+// AdminProject fills in the service account name and the parent from the namespace annotations
+func (d Defaulter) AdminProject(ctx context.Context, namespace string, project *gcpv1alpha1.GCPAdminProject) error {
+	spec := &project.Spec
+
+	if spec.ServiceAccountName == "" {
+		spec.ServiceAccountName = d.ServiceAccountName
+	}
+
+	if spec.ParentType == "" && spec.ParentId == "" {
+		parentType, parentId, err := d.namespaceParent(ctx, namespace)
+
+		if err != nil {
+			return err
+		}
+
+		spec.ParentType, spec.ParentId = parentType, parentId
+	}
+
+	return nil
+}
+
+// WithLabels adds the default labels which are not already set
+func (d Defaulter) WithLabels(labels map[string]string) map[string]string {
+	if len(d.Labels) == 0 {
+		return labels
+	}
+
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+
+	for k, v := range d.Labels {
+		if _, found := labels[k]; !found {
+			labels[k] = v
+		}
+	}
+
+	return labels
+}
+
+// namespaceParent returns the parent given by the annotations of the namespace, if both are set
+func (d Defaulter) namespaceParent(ctx context.Context, namespace string) (string, string, error) {
+	ns := &corev1.Namespace{}
+
+	if err := d.Reader.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return "", "", err
+	}
+
+	parentType, parentId := ns.Annotations[ParentTypeAnnotation], ns.Annotations[ParentIdAnnotation]
+
+	if parentType == "" || parentId == "" {
+		return "", "", nil
+	}
+
+	return parentType, parentId, nil
+}
@@ -0,0 +1,185 @@
+package drift
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/labels"
+	"github.com/appvia/gcp-operator/pkg/policy"
+	"github.com/appvia/gcp-operator/pkg/services"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Desired is the declared state of a project the live state is checked against
+type Desired struct {
+	ProjectId          string
+	ProjectName        string
+	ParentType         string
+	ParentId           string
+	BillingAccountName string
+	ServiceAccountName string
+	// Services are the services which must be enabled, including the baseline
+	Services []string
+	// Bindings are the role bindings with the service account placeholder replaced
+	Bindings []v1alpha1.IAMBinding
+	Mode     v1alpha1.IAMPolicyMode
+	// ManagedRoles are the roles previously applied authoritatively, which must be cleared if
+	// no longer in the bindings
+	ManagedRoles []string
+	// Labels are the labels set on the project from the spec and namespace
+	Labels map[string]string
+	// LabelKeys are the keys of the labels previously set, which must be removed if not desired
+	LabelKeys []string
+}
+
+// Drift is a difference between the live and declared state
+type Drift struct {
+	// Step is the provisioning step which corrects the drift, empty if it cannot be corrected
+	Step string
+	// Message describes the difference
+	Message string
+}
+
+// Detect re-reads the project and its labels, billing, enabled services, service account and IAM
+// policy and returns how they differ from the declared state
+func Detect(ctx context.Context, c *gcp.Client, desired Desired) ([]Drift, error) {
+	projectId := desired.ProjectId
+
+	project, err := c.Projects.Get(ctx, projectId)
+
+	if gcp.IsNotFound(err) {
+		return []Drift{{Step: v1alpha1.CreateProjectStep, Message: "project " + projectId + " no longer exists"}}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// nothing else can be checked or corrected once the project is being deleted
+	if project.LifecycleState != "" && project.LifecycleState != "ACTIVE" {
+		return []Drift{{Message: fmt.Sprintf("project %s is %s", projectId, project.LifecycleState)}}, nil
+	}
+
+	var found []Drift
+
+	if project.Name != desired.ProjectName {
+		found = append(found, Drift{v1alpha1.CreateProjectStep, fmt.Sprintf("project name is %q, expected %q", project.Name, desired.ProjectName)})
+	}
+
+	if project.Parent == nil || project.Parent.Type != desired.ParentType || project.Parent.Id != desired.ParentId {
+		parent := "none"
+		if project.Parent != nil {
+			parent = project.Parent.Type + "/" + project.Parent.Id
+		}
+		found = append(found, Drift{v1alpha1.CreateProjectStep, fmt.Sprintf("project parent is %s, expected %s/%s", parent, desired.ParentType, desired.ParentId)})
+	}
+
+	for _, d := range labels.Diff(project.Labels, desired.Labels, desired.LabelKeys) {
+		found = append(found, Drift{v1alpha1.CreateProjectStep, d})
+	}
+
+	billingAccountName, err := c.Billing.GetBillingAccount(ctx, projectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if billingAccountName != "billingAccounts/"+desired.BillingAccountName {
+		linked := billingAccountName
+		if linked == "" {
+			linked = "none"
+		}
+		found = append(found, Drift{v1alpha1.LinkBillingStep, fmt.Sprintf("billing account is %s, expected billingAccounts/%s", linked, desired.BillingAccountName)})
+	}
+
+	enabled, err := c.Services.ListEnabled(ctx, projectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range services.Difference(desired.Services, enabled) {
+		found = append(found, Drift{v1alpha1.EnableServicesStep, "service " + s + " is not enabled"})
+	}
+
+	_, err = c.IAM.GetServiceAccount(ctx, projectId, desired.ServiceAccountName)
+
+	switch {
+	case gcp.IsNotFound(err):
+		email := gcp.ServiceAccountEmail(projectId, desired.ServiceAccountName)
+		found = append(found, Drift{v1alpha1.CreateServiceAccountStep, "service account " + email + " no longer exists"})
+	case err != nil:
+		return nil, err
+	}
+
+	current, err := c.Projects.GetIamPolicy(ctx, projectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range policy.Diff(current, desired.Bindings, desired.Mode, policy.Dropped(desired.ManagedRoles, desired.Bindings)) {
+		found = append(found, Drift{v1alpha1.GrantPermissionsStep, d})
+	}
+
+	return found, nil
+}
+
+// Messages returns the descriptions of the drift
+func Messages(found []Drift) []string {
+	var messages []string
+	for _, d := range found {
+		messages = append(messages, d.Message)
+	}
+	return messages
+}
+
+// Condition returns the Drifted condition reporting the drift
+func Condition(found []Drift, driftPolicy v1alpha1.DriftPolicy, generation int64) v1alpha1.Condition {
+	condition := v1alpha1.Condition{
+		Type:               v1alpha1.DriftedCondition,
+		Status:             v1alpha1.ConditionFalse,
+		Reason:             "InSync",
+		Message:            "the project matches the spec",
+		ObservedGeneration: generation,
+	}
+
+	if len(found) > 0 {
+		condition.Status = v1alpha1.ConditionTrue
+		condition.Reason = "DriftDetected"
+		if driftPolicy != v1alpha1.ReportDriftPolicy {
+			condition.Reason = "CorrectingDrift"
+		}
+		condition.Message = strings.Join(Messages(found), "; ")
+	}
+
+	return condition
+}
+
+// Policy returns the drift policy, defaulting to Correct
+func Policy(driftPolicy v1alpha1.DriftPolicy) v1alpha1.DriftPolicy {
+	if driftPolicy == "" {
+		return v1alpha1.CorrectDriftPolicy
+	}
+	return driftPolicy
+}
+
+// Interval returns the resync interval of the resource, falling back to the default
+func Interval(interval *metav1.Duration, defaultInterval time.Duration) time.Duration {
+	if interval == nil || interval.Duration <= 0 {
+		return defaultInterval
+	}
+	return interval.Duration
+}
+
+// Due checks if the project should be checked for drift, a zero interval disables resyncs
+func Due(lastSyncTime *metav1.Time, interval time.Duration) bool {
+	if interval <= 0 {
+		return false
+	}
+	return lastSyncTime == nil || time.Since(lastSyncTime.Time) >= interval
+}
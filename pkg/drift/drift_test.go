@@ -0,0 +1,247 @@
+package drift
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/gcp/fake"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v1"
+	iam "google.golang.org/api/iam/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testProjectId = "demo-project"
+
+// newDesired returns the declared state of the project set up by newFake
+func newDesired() Desired {
+	return Desired{
+		ProjectId:          testProjectId,
+		ProjectName:        "Demo",
+		ParentType:         "organization",
+		ParentId:           "123456789012",
+		BillingAccountName: "012345-567890-ABCDEF",
+		ServiceAccountName: "robot-account",
+		Services:           []string{"compute.googleapis.com"},
+		Bindings:           []v1alpha1.IAMBinding{{Role: "roles/viewer", Members: []string{"group:team@example.com"}}},
+		Mode:               v1alpha1.AdditiveMode,
+		Labels:             map[string]string{"team": "a"},
+		LabelKeys:          []string{"team"},
+	}
+}
+
+// newFake returns a fake holding the project matching newDesired
+func newFake() *fake.Fake {
+	f := fake.New()
+	f.Projects[testProjectId] = &cloudresourcemanager.Project{
+		ProjectId:      testProjectId,
+		Name:           "Demo",
+		Parent:         &cloudresourcemanager.ResourceId{Type: "organization", Id: "123456789012"},
+		Labels:         map[string]string{"team": "a"},
+		LifecycleState: "ACTIVE",
+	}
+	f.Policies[testProjectId] = &cloudresourcemanager.Policy{
+		Bindings: []*cloudresourcemanager.Binding{{Role: "roles/viewer", Members: []string{"group:team@example.com"}}},
+	}
+	f.BillingAccounts[testProjectId] = "billingAccounts/012345-567890-ABCDEF"
+	f.EnabledServices[testProjectId] = map[string]bool{"compute.googleapis.com": true}
+	f.ServiceAccounts[gcp.ServiceAccountEmail(testProjectId, "robot-account")] = &iam.ServiceAccount{}
+
+	return f
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(f *fake.Fake, desired *Desired)
+		steps  []string
+	}{
+		{name: "in sync", change: func(*fake.Fake, *Desired) {}},
+		{
+			name:   "deleted project",
+			change: func(f *fake.Fake, _ *Desired) { delete(f.Projects, testProjectId) },
+			steps:  []string{v1alpha1.CreateProjectStep},
+		},
+		{
+			name:   "project being deleted",
+			change: func(f *fake.Fake, _ *Desired) { f.Projects[testProjectId].LifecycleState = "DELETE_REQUESTED" },
+			steps:  []string{""},
+		},
+		{
+			name:   "renamed project",
+			change: func(f *fake.Fake, _ *Desired) { f.Projects[testProjectId].Name = "Renamed" },
+			steps:  []string{v1alpha1.CreateProjectStep},
+		},
+		{
+			name:   "moved project",
+			change: func(f *fake.Fake, _ *Desired) { f.Projects[testProjectId].Parent.Type = "folder" },
+			steps:  []string{v1alpha1.CreateProjectStep},
+		},
+		{
+			name:   "relabelled project",
+			change: func(f *fake.Fake, _ *Desired) { f.Projects[testProjectId].Labels["team"] = "b" },
+			steps:  []string{v1alpha1.CreateProjectStep},
+		},
+		{
+			name:   "unlinked billing",
+			change: func(f *fake.Fake, _ *Desired) { delete(f.BillingAccounts, testProjectId) },
+			steps:  []string{v1alpha1.LinkBillingStep},
+		},
+		{
+			name:   "disabled service",
+			change: func(f *fake.Fake, _ *Desired) { delete(f.EnabledServices[testProjectId], "compute.googleapis.com") },
+			steps:  []string{v1alpha1.EnableServicesStep},
+		},
+		{
+			name:   "extra service",
+			change: func(f *fake.Fake, _ *Desired) { f.EnabledServices[testProjectId]["sql.googleapis.com"] = true },
+		},
+		{
+			name: "deleted service account",
+			change: func(f *fake.Fake, _ *Desired) {
+				delete(f.ServiceAccounts, gcp.ServiceAccountEmail(testProjectId, "robot-account"))
+			},
+			steps: []string{v1alpha1.CreateServiceAccountStep},
+		},
+		{
+			name:   "removed binding",
+			change: func(f *fake.Fake, _ *Desired) { f.Policies[testProjectId].Bindings = nil },
+			steps:  []string{v1alpha1.GrantPermissionsStep},
+		},
+		{
+			name: "dropped managed role still bound",
+			change: func(f *fake.Fake, desired *Desired) {
+				f.Policies[testProjectId].Bindings = append(f.Policies[testProjectId].Bindings, &cloudresourcemanager.Binding{Role: "roles/editor", Members: []string{"group:team@example.com"}})
+				desired.ManagedRoles = []string{"roles/viewer", "roles/editor"}
+			},
+			steps: []string{v1alpha1.GrantPermissionsStep},
+		},
+		{
+			name: "several",
+			change: func(f *fake.Fake, _ *Desired) {
+				f.Projects[testProjectId].Name = "Renamed"
+				delete(f.BillingAccounts, testProjectId)
+			},
+			steps: []string{v1alpha1.CreateProjectStep, v1alpha1.LinkBillingStep},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, desired := newFake(), newDesired()
+			test.change(f, &desired)
+
+			c, err := f.New(context.TODO(), gcp.Auth{})
+			if err != nil {
+				t.Fatalf("failed to create the client: %v", err)
+			}
+
+			found, err := Detect(context.TODO(), c, desired)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var steps []string
+			for _, d := range found {
+				steps = append(steps, d.Step)
+			}
+
+			if !reflect.DeepEqual(steps, test.steps) {
+				t.Errorf("expected drift corrected by %v, got %v", test.steps, Messages(found))
+			}
+		})
+	}
+}
+
+func TestDetectFailsWhenReadingFails(t *testing.T) {
+	f := newFake()
+	f.Errors["Billing.GetBillingAccount"] = errors.New("billing unavailable")
+
+	c, err := f.New(context.TODO(), gcp.Auth{})
+	if err != nil {
+		t.Fatalf("failed to create the client: %v", err)
+	}
+
+	if _, err := Detect(context.TODO(), c, newDesired()); err == nil {
+		t.Error("expected the error reading the billing account to be returned")
+	}
+}
+
+func TestCondition(t *testing.T) {
+	found := []Drift{{Step: v1alpha1.LinkBillingStep, Message: "billing account is none"}}
+
+	tests := []struct {
+		name        string
+		found       []Drift
+		driftPolicy v1alpha1.DriftPolicy
+		status      v1alpha1.ConditionStatus
+		reason      string
+	}{
+		{name: "in sync", driftPolicy: v1alpha1.CorrectDriftPolicy, status: v1alpha1.ConditionFalse, reason: "InSync"},
+		{name: "correcting", found: found, driftPolicy: v1alpha1.CorrectDriftPolicy, status: v1alpha1.ConditionTrue, reason: "CorrectingDrift"},
+		{name: "reporting", found: found, driftPolicy: v1alpha1.ReportDriftPolicy, status: v1alpha1.ConditionTrue, reason: "DriftDetected"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			condition := Condition(test.found, test.driftPolicy, 3)
+
+			if condition.Type != v1alpha1.DriftedCondition || condition.Status != test.status || condition.Reason != test.reason {
+				t.Errorf("expected %s %s with reason %s, got %+v", v1alpha1.DriftedCondition, test.status, test.reason, condition)
+			}
+			if condition.ObservedGeneration != 3 {
+				t.Errorf("expected observed generation 3, got %d", condition.ObservedGeneration)
+			}
+		})
+	}
+}
+
+func TestInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval *metav1.Duration
+		expected time.Duration
+	}{
+		{name: "default", expected: time.Hour},
+		{name: "set", interval: &metav1.Duration{Duration: time.Minute}, expected: time.Minute},
+		{name: "zero", interval: &metav1.Duration{}, expected: time.Hour},
+		{name: "negative", interval: &metav1.Duration{Duration: -time.Minute}, expected: time.Hour},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if interval := Interval(test.interval, time.Hour); interval != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, interval)
+			}
+		})
+	}
+}
+
+func TestDue(t *testing.T) {
+	recent := metav1.NewTime(time.Now().Add(-time.Minute))
+	old := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+
+	tests := []struct {
+		name         string
+		lastSyncTime *metav1.Time
+		interval     time.Duration
+		due          bool
+	}{
+		{name: "never synced", interval: time.Hour, due: true},
+		{name: "synced recently", lastSyncTime: &recent, interval: time.Hour},
+		{name: "synced before the interval", lastSyncTime: &old, interval: time.Hour, due: true},
+		{name: "disabled", lastSyncTime: &old},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if due := Due(test.lastSyncTime, test.interval); due != test.due {
+				t.Errorf("expected due %t, got %t", test.due, due)
+			}
+		})
+	}
+}
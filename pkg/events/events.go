@@ -0,0 +1,52 @@
+package events
+
+// The reasons of the events recorded on the resources
+const (
+	// ProjectCreated is recorded once the project has been created
+	ProjectCreated = "ProjectCreated"
+	// ProjectAdopted is recorded when an existing project is taken over
+	ProjectAdopted = "ProjectAdopted"
+	// ProjectUpdated is recorded when the name or parent of the project is changed
+	ProjectUpdated = "ProjectUpdated"
+	// BillingLinked is recorded when the billing account is linked to the project
+	BillingLinked = "BillingLinked"
+	// ServiceEnabled is recorded when a service is enabled in the project
+	ServiceEnabled = "ServiceEnabled"
+	// ServiceDisabled is recorded when a service removed from the spec is disabled
+	ServiceDisabled = "ServiceDisabled"
+	// ServiceAccountCreated is recorded when the service account is created
+	ServiceAccountCreated = "ServiceAccountCreated"
+	// IAMPolicyUpdated is recorded when the bindings change the project policy
+	IAMPolicyUpdated = "IAMPolicyUpdated"
+	// CredentialsIssued is recorded when a key is minted and the credentials created
+	CredentialsIssued = "CredentialsIssued"
+	// CredentialsUpdated is recorded when existing generated credentials are brought up to date
+	CredentialsUpdated = "CredentialsUpdated"
+	// KeyRotated is recorded when the key of the generated credentials is replaced
+	KeyRotated = "KeyRotated"
+	// CredentialsRevoked is recorded when the generated key is revoked on deletion
+	CredentialsRevoked = "CredentialsRevoked"
+	// CleanupSkipped is recorded when a deleted resource is released without cleaning up GCP
+	CleanupSkipped = "CleanupSkipped"
+	// BillingDisabled is recorded when billing is disabled on deletion
+	BillingDisabled = "BillingDisabled"
+	// ProjectDeleted is recorded when the project is deleted
+	ProjectDeleted = "ProjectDeleted"
+	// Verified is recorded when credentials pass verification
+	Verified = "Verified"
+	// VerificationFailed is recorded when credentials fail verification
+	VerificationFailed = "VerificationFailed"
+	// DriftDetected is recorded when the project no longer matches the spec
+	DriftDetected = "DriftDetected"
+	// Conflict is recorded when the project is managed by another resource or cluster
+	Conflict = "Conflict"
+	// DeletionFailed is recorded when cleaning up a resource fails
+	DeletionFailed = "DeletionFailed"
+	// LabelsSkipped is recorded when namespace labels cannot be set on the project
+	LabelsSkipped = "LabelsSkipped"
+)
+
+// StepFailed returns the reason of the warning recorded when the provisioning step fails
+func StepFailed(step string) string {
+	return step + "Failed"
+}
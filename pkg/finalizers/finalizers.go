@@ -0,0 +1,33 @@
+package finalizers
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Has checks if the object carries the finalizer
+func Has(obj metav1.Object, name string) bool {
+	for _, x := range obj.GetFinalizers() {
+		if x == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Add adds the finalizer to the object if it does not carry it already
+func Add(obj metav1.Object, name string) {
+	if !Has(obj, name) {
+		obj.SetFinalizers(append(obj.GetFinalizers(), name))
+	}
+}
+
+// Remove removes the finalizer from the object
+func Remove(obj metav1.Object, name string) {
+	var result []string
+	for _, x := range obj.GetFinalizers() {
+		if x != name {
+			result = append(result, x)
+		}
+	}
+	obj.SetFinalizers(result)
+}
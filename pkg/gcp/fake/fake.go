@@ -0,0 +1,605 @@
+package fake
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"golang.org/x/oauth2"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v1"
+	"google.golang.org/api/googleapi"
+	iam "google.golang.org/api/iam/v1"
+)
+
+// Fake is an in-memory GCP implementing gcp.Factory. Long running operations complete after
+// being polled OperationPolls times, at which point their effect is applied. It is safe for
+// concurrent use
+type Fake struct {
+	sync.Mutex
+
+	// OperationPolls is the number of polls an operation takes to complete
+	OperationPolls int
+	// Errors are returned by the named methods e.g. "Projects.Create" until removed
+	Errors map[string]error
+	// OperationErrors fail the operations started by the named methods e.g. "Services.Enable"
+	OperationErrors map[string]error
+
+	// DeniedPermissions are the organization and project permissions the caller does not hold,
+	// all others are granted
+	DeniedPermissions map[string]bool
+	// Folders are the IDs of the organizations the folders belong to by folder id
+	Folders map[string]string
+	// Projects are the projects by id
+	Projects map[string]*cloudresourcemanager.Project
+	// Policies are the project policies by project id
+	Policies map[string]*cloudresourcemanager.Policy
+	// BillingAccounts are the linked billing account resource names by project id
+	BillingAccounts map[string]string
+	// EnabledServices are the enabled services by project id
+	EnabledServices map[string]map[string]bool
+	// ServiceAccounts are the service accounts by email
+	ServiceAccounts map[string]*iam.ServiceAccount
+	// Keys are the resource names of the service account keys which have not been revoked
+	Keys map[string]bool
+	// Calls counts the calls made to each method
+	Calls map[string]int
+
+	operations map[string]*operation
+	sequence   int
+}
+
+// operation is a long running operation in progress
+type operation struct {
+	polls int
+	err   error
+	apply func()
+}
+
+// New returns an empty fake
+func New() *Fake {
+	return &Fake{
+		Errors:            make(map[string]error),
+		OperationErrors:   make(map[string]error),
+		DeniedPermissions: make(map[string]bool),
+		Folders:           make(map[string]string),
+		Projects:          make(map[string]*cloudresourcemanager.Project),
+		Policies:          make(map[string]*cloudresourcemanager.Policy),
+		BillingAccounts:   make(map[string]string),
+		EnabledServices:   make(map[string]map[string]bool),
+		ServiceAccounts:   make(map[string]*iam.ServiceAccount),
+		Keys:              make(map[string]bool),
+		Calls:             make(map[string]int),
+		operations:        make(map[string]*operation),
+	}
+}
+
+// New returns a client backed by the fake, the credentials are ignored
+func (f *Fake) New(ctx context.Context, auth gcp.Auth) (*gcp.Client, error) {
+	if err := f.call("Factory.New"); err != nil {
+		return nil, err
+	}
+
+	return &gcp.Client{
+		Organizations: &organizations{f},
+		Projects:      &projects{f},
+		Billing:       &billing{f},
+		Services:      &serviceUsage{f},
+		IAM:           &iamClient{f},
+		Tokens:        &tokens{f},
+		Operations:    &operations{f},
+	}, nil
+}
+
+// NotFound returns the error the google apis return for a missing resource
+func NotFound() error {
+	return &googleapi.Error{Code: http.StatusNotFound, Message: "not found"}
+}
+
+// call records the call and returns the error set for the method, the lock must be held
+func (f *Fake) call(method string) error {
+	f.Calls[method]++
+
+	return f.Errors[method]
+}
+
+// start begins an operation for the method, applying the effect once it completes, the
+// lock must be held
+func (f *Fake) start(method string, apply func()) string {
+	f.sequence++
+	name := fmt.Sprintf("operations/fake-%d", f.sequence)
+
+	f.operations[name] = &operation{
+		polls: f.OperationPolls,
+		err:   f.OperationErrors[method],
+		apply: apply,
+	}
+
+	return name
+}
+
+// organizations implements gcp.Organizations
+type organizations struct{ f *Fake }
+
+func (o *organizations) TestIamPermissions(ctx context.Context, organizationId string, permissions []string) ([]string, error) {
+	o.f.Lock()
+	defer o.f.Unlock()
+
+	if err := o.f.call("Organizations.TestIamPermissions"); err != nil {
+		return nil, err
+	}
+
+	var granted []string
+	for _, p := range permissions {
+		if !o.f.DeniedPermissions[p] {
+			granted = append(granted, p)
+		}
+	}
+	return granted, nil
+}
+
+// projects implements gcp.Projects
+type projects struct{ f *Fake }
+
+func (p *projects) Exists(ctx context.Context, projectId string) (bool, error) {
+	p.f.Lock()
+	defer p.f.Unlock()
+
+	if err := p.f.call("Projects.Exists"); err != nil {
+		return false, err
+	}
+	if projectId == "" {
+		return false, errors.New("no project ID given")
+	}
+	_, found := p.f.Projects[projectId]
+
+	return found, nil
+}
+
+func (p *projects) Get(ctx context.Context, projectId string) (*cloudresourcemanager.Project, error) {
+	p.f.Lock()
+	defer p.f.Unlock()
+
+	if err := p.f.call("Projects.Get"); err != nil {
+		return nil, err
+	}
+	project, found := p.f.Projects[projectId]
+	if !found {
+		return nil, NotFound()
+	}
+	return copyProject(project), nil
+}
+
+func (p *projects) Create(ctx context.Context, project *cloudresourcemanager.Project) (string, error) {
+	p.f.Lock()
+	defer p.f.Unlock()
+
+	if err := p.f.call("Projects.Create"); err != nil {
+		return "", err
+	}
+	if _, found := p.f.Projects[project.ProjectId]; found {
+		return "", &googleapi.Error{Code: http.StatusConflict, Message: "project already exists"}
+	}
+	copied := copyProject(project)
+	copied.LifecycleState = "ACTIVE"
+
+	return p.f.start("Projects.Create", func() {
+		p.f.Projects[copied.ProjectId] = copied
+		p.f.Policies[copied.ProjectId] = &cloudresourcemanager.Policy{Etag: "0", Version: 1}
+	}), nil
+}
+
+func (p *projects) Update(ctx context.Context, project *cloudresourcemanager.Project) error {
+	p.f.Lock()
+	defer p.f.Unlock()
+
+	if err := p.f.call("Projects.Update"); err != nil {
+		return err
+	}
+	if _, found := p.f.Projects[project.ProjectId]; !found {
+		return NotFound()
+	}
+	p.f.Projects[project.ProjectId] = copyProject(project)
+
+	return nil
+}
+
+// copyProject returns a copy of the project which shares nothing with it
+func copyProject(project *cloudresourcemanager.Project) *cloudresourcemanager.Project {
+	copied := *project
+
+	if project.Parent != nil {
+		parent := *project.Parent
+		copied.Parent = &parent
+	}
+
+	if project.Labels != nil {
+		copied.Labels = make(map[string]string)
+		for k, v := range project.Labels {
+			copied.Labels[k] = v
+		}
+	}
+
+	return &copied
+}
+
+func (p *projects) Delete(ctx context.Context, projectId string) error {
+	p.f.Lock()
+	defer p.f.Unlock()
+
+	if err := p.f.call("Projects.Delete"); err != nil {
+		return err
+	}
+	delete(p.f.Projects, projectId)
+	delete(p.f.Policies, projectId)
+	delete(p.f.BillingAccounts, projectId)
+	delete(p.f.EnabledServices, projectId)
+
+	return nil
+}
+
+func (p *projects) GetIamPolicy(ctx context.Context, projectId string) (*cloudresourcemanager.Policy, error) {
+	p.f.Lock()
+	defer p.f.Unlock()
+
+	if err := p.f.call("Projects.GetIamPolicy"); err != nil {
+		return nil, err
+	}
+	policy, found := p.f.Policies[projectId]
+	if !found {
+		return nil, NotFound()
+	}
+
+	return copyPolicy(policy), nil
+}
+
+func (p *projects) SetIamPolicy(ctx context.Context, projectId string, policy *cloudresourcemanager.Policy) error {
+	p.f.Lock()
+	defer p.f.Unlock()
+
+	if err := p.f.call("Projects.SetIamPolicy"); err != nil {
+		return err
+	}
+	current, found := p.f.Policies[projectId]
+	if !found {
+		return NotFound()
+	}
+	if policy.Etag != current.Etag {
+		return &googleapi.Error{Code: http.StatusConflict, Message: "the policy etag is stale"}
+	}
+
+	updated := copyPolicy(policy)
+	updated.Etag = fmt.Sprintf("%s-", current.Etag)
+	p.f.Policies[projectId] = updated
+
+	return nil
+}
+
+func (p *projects) TestIamPermissions(ctx context.Context, projectId string, permissions []string) ([]string, error) {
+	p.f.Lock()
+	defer p.f.Unlock()
+
+	if err := p.f.call("Projects.TestIamPermissions"); err != nil {
+		return nil, err
+	}
+	if _, found := p.f.Projects[projectId]; !found {
+		return nil, NotFound()
+	}
+
+	var granted []string
+	for _, permission := range permissions {
+		if !p.f.DeniedPermissions[permission] {
+			granted = append(granted, permission)
+		}
+	}
+	return granted, nil
+}
+
+func (p *projects) Organization(ctx context.Context, projectId string) (string, error) {
+	p.f.Lock()
+	defer p.f.Unlock()
+
+	if err := p.f.call("Projects.Organization"); err != nil {
+		return "", err
+	}
+	project, found := p.f.Projects[projectId]
+	if !found {
+		return "", NotFound()
+	}
+	if project.Parent == nil {
+		return "", nil
+	}
+
+	switch project.Parent.Type {
+	case "organization":
+		return project.Parent.Id, nil
+	case "folder":
+		return p.f.Folders[project.Parent.Id], nil
+	}
+	return "", nil
+}
+
+// copyPolicy returns a deep copy of the policy
+func copyPolicy(policy *cloudresourcemanager.Policy) *cloudresourcemanager.Policy {
+	copied := *policy
+	copied.Bindings = nil
+
+	for _, b := range policy.Bindings {
+		binding := *b
+		binding.Members = append([]string{}, b.Members...)
+		copied.Bindings = append(copied.Bindings, &binding)
+	}
+
+	return &copied
+}
+
+// billing implements gcp.Billing
+type billing struct{ f *Fake }
+
+func (b *billing) GetBillingAccount(ctx context.Context, projectId string) (string, error) {
+	b.f.Lock()
+	defer b.f.Unlock()
+
+	if err := b.f.call("Billing.GetBillingAccount"); err != nil {
+		return "", err
+	}
+	if _, found := b.f.Projects[projectId]; !found {
+		return "", NotFound()
+	}
+
+	return b.f.BillingAccounts[projectId], nil
+}
+
+func (b *billing) SetBillingAccount(ctx context.Context, projectId, billingAccountName string) error {
+	b.f.Lock()
+	defer b.f.Unlock()
+
+	if err := b.f.call("Billing.SetBillingAccount"); err != nil {
+		return err
+	}
+	if _, found := b.f.Projects[projectId]; !found {
+		return NotFound()
+	}
+	b.f.BillingAccounts[projectId] = "billingAccounts/" + billingAccountName
+
+	return nil
+}
+
+func (b *billing) DisableBilling(ctx context.Context, projectId string) error {
+	b.f.Lock()
+	defer b.f.Unlock()
+
+	if err := b.f.call("Billing.DisableBilling"); err != nil {
+		return err
+	}
+	delete(b.f.BillingAccounts, projectId)
+
+	return nil
+}
+
+// serviceUsage implements gcp.ServiceUsage
+type serviceUsage struct{ f *Fake }
+
+func (s *serviceUsage) ListEnabled(ctx context.Context, projectId string) ([]string, error) {
+	s.f.Lock()
+	defer s.f.Unlock()
+
+	if err := s.f.call("Services.ListEnabled"); err != nil {
+		return nil, err
+	}
+	if _, found := s.f.Projects[projectId]; !found {
+		return nil, NotFound()
+	}
+
+	var enabled []string
+	for service := range s.f.EnabledServices[projectId] {
+		enabled = append(enabled, service)
+	}
+	sort.Strings(enabled)
+
+	return enabled, nil
+}
+
+func (s *serviceUsage) Enable(ctx context.Context, projectId, service string) (string, error) {
+	s.f.Lock()
+	defer s.f.Unlock()
+
+	if err := s.f.call("Services.Enable"); err != nil {
+		return "", err
+	}
+	if _, found := s.f.Projects[projectId]; !found {
+		return "", NotFound()
+	}
+
+	return s.f.start("Services.Enable", func() {
+		if s.f.EnabledServices[projectId] == nil {
+			s.f.EnabledServices[projectId] = make(map[string]bool)
+		}
+		s.f.EnabledServices[projectId][service] = true
+	}), nil
+}
+
+func (s *serviceUsage) Disable(ctx context.Context, projectId, service string) (string, error) {
+	s.f.Lock()
+	defer s.f.Unlock()
+
+	if err := s.f.call("Services.Disable"); err != nil {
+		return "", err
+	}
+	if _, found := s.f.Projects[projectId]; !found {
+		return "", NotFound()
+	}
+
+	return s.f.start("Services.Disable", func() {
+		delete(s.f.EnabledServices[projectId], service)
+	}), nil
+}
+
+// iamClient implements gcp.IAM
+type iamClient struct{ f *Fake }
+
+func (i *iamClient) GetServiceAccount(ctx context.Context, projectId, name string) (*iam.ServiceAccount, error) {
+	i.f.Lock()
+	defer i.f.Unlock()
+
+	if err := i.f.call("IAM.GetServiceAccount"); err != nil {
+		return nil, err
+	}
+	account, found := i.f.ServiceAccounts[gcp.ServiceAccountEmail(projectId, name)]
+	if !found {
+		return nil, NotFound()
+	}
+	copied := *account
+
+	return &copied, nil
+}
+
+func (i *iamClient) CreateServiceAccount(ctx context.Context, projectId, name, displayName string) (*iam.ServiceAccount, error) {
+	i.f.Lock()
+	defer i.f.Unlock()
+
+	if err := i.f.call("IAM.CreateServiceAccount"); err != nil {
+		return nil, err
+	}
+	if _, found := i.f.Projects[projectId]; !found {
+		return nil, NotFound()
+	}
+	email := gcp.ServiceAccountEmail(projectId, name)
+	if _, found := i.f.ServiceAccounts[email]; found {
+		return nil, &googleapi.Error{Code: http.StatusConflict, Message: "service account already exists"}
+	}
+
+	account := &iam.ServiceAccount{
+		Name:        "projects/" + projectId + "/serviceAccounts/" + email,
+		Email:       email,
+		ProjectId:   projectId,
+		DisplayName: displayName,
+	}
+	i.f.ServiceAccounts[email] = account
+	copied := *account
+
+	return &copied, nil
+}
+
+func (i *iamClient) DeleteServiceAccount(ctx context.Context, projectId, name string) error {
+	i.f.Lock()
+	defer i.f.Unlock()
+
+	if err := i.f.call("IAM.DeleteServiceAccount"); err != nil {
+		return err
+	}
+	email := gcp.ServiceAccountEmail(projectId, name)
+	delete(i.f.ServiceAccounts, email)
+
+	for key := range i.f.Keys {
+		if strings.Contains(key, "/serviceAccounts/"+email+"/") {
+			delete(i.f.Keys, key)
+		}
+	}
+
+	return nil
+}
+
+func (i *iamClient) CreateServiceAccountKey(ctx context.Context, projectId, name string) (string, error) {
+	i.f.Lock()
+	defer i.f.Unlock()
+
+	if err := i.f.call("IAM.CreateServiceAccountKey"); err != nil {
+		return "", err
+	}
+	email := gcp.ServiceAccountEmail(projectId, name)
+	if _, found := i.f.ServiceAccounts[email]; !found {
+		return "", NotFound()
+	}
+
+	i.f.sequence++
+	id := fmt.Sprintf("key-%d", i.f.sequence)
+
+	key, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     projectId,
+		"private_key_id": id,
+		"client_email":   email,
+	})
+	if err != nil {
+		return "", err
+	}
+	i.f.Keys["projects/-/serviceAccounts/"+email+"/keys/"+id] = true
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+func (i *iamClient) DeleteServiceAccountKey(ctx context.Context, keyName string) error {
+	i.f.Lock()
+	defer i.f.Unlock()
+
+	if err := i.f.call("IAM.DeleteServiceAccountKey"); err != nil {
+		return err
+	}
+	delete(i.f.Keys, keyName)
+
+	return nil
+}
+
+// tokens implements gcp.Tokens
+type tokens struct{ f *Fake }
+
+func (t *tokens) GenerateAccessToken(ctx context.Context, serviceAccount string, lifetime time.Duration) (*oauth2.Token, error) {
+	t.f.Lock()
+	defer t.f.Unlock()
+
+	if err := t.f.call("Tokens.GenerateAccessToken"); err != nil {
+		return nil, err
+	}
+	if _, found := t.f.ServiceAccounts[serviceAccount]; !found {
+		return nil, NotFound()
+	}
+
+	t.f.sequence++
+
+	return &oauth2.Token{
+		AccessToken: fmt.Sprintf("token-%d", t.f.sequence),
+		TokenType:   "Bearer",
+		Expiry:      time.Now().Add(lifetime),
+	}, nil
+}
+
+// operations implements gcp.Operations
+type operations struct{ f *Fake }
+
+func (o *operations) Done(ctx context.Context, op v1alpha1.Operation) (bool, error, error) {
+	o.f.Lock()
+	defer o.f.Unlock()
+
+	if err := o.f.call("Operations.Done"); err != nil {
+		return false, nil, err
+	}
+	current, found := o.f.operations[op.Name]
+	if !found {
+		return false, nil, NotFound()
+	}
+
+	if current.polls > 0 {
+		current.polls--
+		return false, nil, nil
+	}
+
+	if current.err != nil {
+		return true, current.err, nil
+	}
+
+	if current.apply != nil {
+		current.apply()
+		current.apply = nil
+	}
+
+	return true, nil, nil
+}
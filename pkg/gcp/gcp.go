@@ -0,0 +1,159 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"golang.org/x/oauth2"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v1"
+	"google.golang.org/api/googleapi"
+	iam "google.golang.org/api/iam/v1"
+)
+
+// Projects manages projects and their IAM policies
+type Projects interface {
+	// Exists checks if the project exists and is visible to the caller, the ID must not be empty
+	Exists(ctx context.Context, projectId string) (bool, error)
+	// Get retrieves the project
+	Get(ctx context.Context, projectId string) (*cloudresourcemanager.Project, error)
+	// Create starts creating the project, returning the operation name
+	Create(ctx context.Context, project *cloudresourcemanager.Project) (string, error)
+	// Update updates the name and parent of the project, this completes synchronously
+	Update(ctx context.Context, project *cloudresourcemanager.Project) error
+	// Delete marks the project for deletion, a missing project is not an error
+	Delete(ctx context.Context, projectId string) error
+	// GetIamPolicy retrieves the latest version of the project policy, including its etag
+	GetIamPolicy(ctx context.Context, projectId string) (*cloudresourcemanager.Policy, error)
+	// SetIamPolicy replaces the project policy, failing if the etag is stale
+	SetIamPolicy(ctx context.Context, projectId string, policy *cloudresourcemanager.Policy) error
+	// TestIamPermissions returns the permissions the caller holds on the project
+	TestIamPermissions(ctx context.Context, projectId string, permissions []string) ([]string, error)
+	// Organization returns the ID of the organization the project belongs to, walking up through
+	// the folders it is nested in, or an empty string if it has none
+	Organization(ctx context.Context, projectId string) (string, error)
+}
+
+// Billing manages the billing account linked to projects
+type Billing interface {
+	// GetBillingAccount returns the resource name of the linked billing account e.g.
+	// 'billingAccounts/012345-567890-ABCDEF', or an empty string if billing is disabled
+	GetBillingAccount(ctx context.Context, projectId string) (string, error)
+	// SetBillingAccount links the billing account, given without the 'billingAccounts/'
+	// prefix, to the project
+	SetBillingAccount(ctx context.Context, projectId, billingAccountName string) error
+	// DisableBilling unlinks the billing account, a missing project is not an error
+	DisableBilling(ctx context.Context, projectId string) error
+}
+
+// ServiceUsage manages the services enabled in projects
+type ServiceUsage interface {
+	// ListEnabled returns the names of the services enabled in the project
+	ListEnabled(ctx context.Context, projectId string) ([]string, error)
+	// Enable starts enabling the service, returning the operation name
+	Enable(ctx context.Context, projectId, service string) (string, error)
+	// Disable starts disabling the service, returning the operation name
+	Disable(ctx context.Context, projectId, service string) (string, error)
+}
+
+// IAM manages service accounts and their keys
+type IAM interface {
+	// GetServiceAccount retrieves the service account from the project
+	GetServiceAccount(ctx context.Context, projectId, name string) (*iam.ServiceAccount, error)
+	// CreateServiceAccount creates the service account in the project
+	CreateServiceAccount(ctx context.Context, projectId, name, displayName string) (*iam.ServiceAccount, error)
+	// DeleteServiceAccount removes the service account, a missing account is not an error
+	DeleteServiceAccount(ctx context.Context, projectId, name string) error
+	// CreateServiceAccountKey mints a key, returning the base64 encoded JSON key
+	CreateServiceAccountKey(ctx context.Context, projectId, name string) (string, error)
+	// DeleteServiceAccountKey revokes the key, a missing key is not an error
+	DeleteServiceAccountKey(ctx context.Context, keyName string) error
+}
+
+// Tokens issues short lived credentials for service accounts through the iam credentials api
+type Tokens interface {
+	// GenerateAccessToken returns an access token of the service account lasting for the
+	// lifetime, the caller must hold roles/iam.serviceAccountTokenCreator on the service account
+	GenerateAccessToken(ctx context.Context, serviceAccount string, lifetime time.Duration) (*oauth2.Token, error)
+}
+
+// Operations tracks long running operations
+type Operations interface {
+	// Done checks if the operation has completed, returning the error it failed with in failure
+	// and the error of the api call checking it in err
+	Done(ctx context.Context, op v1alpha1.Operation) (done bool, failure error, err error)
+}
+
+// Organizations queries organizations
+type Organizations interface {
+	// TestIamPermissions returns the permissions the caller holds on the organization, given with
+	// or without the 'organizations/' prefix
+	TestIamPermissions(ctx context.Context, organizationId string, permissions []string) ([]string, error)
+}
+
+// Client groups the apis used to provision projects
+type Client struct {
+	Organizations Organizations
+	Projects      Projects
+	Billing       Billing
+	Services      ServiceUsage
+	IAM           IAM
+	Tokens        Tokens
+	Operations    Operations
+}
+
+// Auth are the credentials a client is created with, either a JSON service account key or a
+// bearer token. With neither the client authenticates as the operator itself, with its root key
+// or the application default credentials
+type Auth struct {
+	// Key is a JSON service account key
+	Key []byte
+	// Token is an OAuth2 bearer token
+	Token string
+	// Impersonate is the email of a service account the client acts as, using short lived tokens
+	// issued to the identity given by the key, the token or the operator
+	Impersonate string
+}
+
+// Factory creates clients, it is injected into the reconcilers so they can be tested
+// against a fake
+type Factory interface {
+	// New returns a client authenticated with the credentials
+	New(ctx context.Context, auth Auth) (*Client, error)
+}
+
+// ServiceAccountEmail returns the email of a service account created in the project
+func ServiceAccountEmail(projectId, name string) string {
+	return name + "@" + projectId + ".iam.gserviceaccount.com"
+}
+
+// IsNotFound checks if the error is a not found from the google apis
+func IsNotFound(err error) bool {
+	var e *googleapi.Error
+	if errors.As(err, &e) {
+		return e.Code == http.StatusNotFound
+	}
+	return false
+}
+
+// IsUnauthorized checks if the google apis rejected the credentials, usually because a bearer
+// token has expired
+func IsUnauthorized(err error) bool {
+	var e *googleapi.Error
+	if errors.As(err, &e) {
+		return e.Code == http.StatusUnauthorized
+	}
+	return false
+}
+
+// IsAlreadyExists checks if the google apis refused to create a resource because one with the
+// same name exists
+func IsAlreadyExists(err error) bool {
+	var e *googleapi.Error
+	if errors.As(err, &e) {
+		return e.Code == http.StatusConflict
+	}
+	return false
+}
@@ -0,0 +1,543 @@
+package gcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"golang.org/x/oauth2"
+	"golang.org/x/time/rate"
+	cloudbilling "google.golang.org/api/cloudbilling/v1"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v1"
+	iam "google.golang.org/api/iam/v1"
+	iamcredentials "google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
+	servicemanagement "google.golang.org/api/servicemanagement/v1"
+	htransport "google.golang.org/api/transport/http"
+)
+
+// ImpersonatedTokenLifetime is how long the tokens of impersonated service accounts last
+const ImpersonatedTokenLifetime = time.Hour
+
+// limiterIdleTimeout is how long the rate limiter of a credential is kept after it was last used,
+// so the limiters of replaced keys and expired tokens are dropped
+const limiterIdleTimeout = 2 * time.Hour
+
+// factory creates clients backed by the google apis
+type factory struct {
+	options Options
+	// base is the transport shared by the clients, holding the connections to the apis
+	base *http.Transport
+
+	// limiters are the rate limiters shared by the clients of each identity
+	limiters map[string]*trackedLimiter
+	mutex    sync.Mutex
+}
+
+// trackedLimiter is a rate limiter along with when it was last handed to a client
+type trackedLimiter struct {
+	*rate.Limiter
+	lastUsed time.Time
+}
+
+// NewFactory returns a factory creating clients backed by the google apis, failing when the base
+// transport cannot be built from the options
+func NewFactory(options Options) (Factory, error) {
+	base, err := options.Transport()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &factory{options: options, base: base, limiters: make(map[string]*trackedLimiter)}, nil
+}
+
+// limiter returns the rate limiter of the identity the credentials act as, nil when rate limiting
+// is disabled
+func (f *factory) limiter(auth Auth) *rate.Limiter {
+	if f.options.RateLimit <= 0 {
+		return nil
+	}
+
+	id := identity(auth)
+	now := time.Now()
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for k, l := range f.limiters {
+		if now.Sub(l.lastUsed) > limiterIdleTimeout {
+			delete(f.limiters, k)
+		}
+	}
+
+	l, found := f.limiters[id]
+	if !found {
+		burst := f.options.RateBurst
+		if burst < 1 {
+			burst = 1
+		}
+		l = &trackedLimiter{Limiter: rate.NewLimiter(rate.Limit(f.options.RateLimit), burst)}
+		f.limiters[id] = l
+	}
+	l.lastUsed = now
+
+	return l.Limiter
+}
+
+// identity returns what the requests made with the credentials are limited by: the service account
+// of a key, so every key of the account shares a limit, a hash of a bearer token or the operator.
+// Impersonated service accounts are limited separately from the identity impersonating them
+func identity(auth Auth) string {
+	id := "operator"
+
+	switch {
+	case len(auth.Key) > 0:
+		key := struct {
+			ClientEmail string `json:"client_email"`
+		}{}
+
+		if err := json.Unmarshal(auth.Key, &key); err == nil && key.ClientEmail != "" {
+			id = "key:" + key.ClientEmail
+		} else {
+			sum := sha256.Sum256(auth.Key)
+			id = "key:" + hex.EncodeToString(sum[:])
+		}
+	case auth.Token != "":
+		sum := sha256.Sum256([]byte(auth.Token))
+		id = "token:" + hex.EncodeToString(sum[:])
+	}
+
+	if auth.Impersonate != "" {
+		id += "/" + auth.Impersonate
+	}
+
+	return id
+}
+
+// New returns a client backed by the google apis
+func (f *factory) New(ctx context.Context, auth Auth) (*Client, error) {
+	// keys are exchanged for tokens through the base transport, outside of the retries and rate limits
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: f.base})
+
+	retrying := &retryTransport{base: f.base, retry: f.options.Retry, limiter: f.limiter(auth)}
+
+	credentials := f.credentials(auth)
+
+	// the service account is impersonated with short lived tokens issued to the identity the
+	// client was given, which needs roles/iam.serviceAccountTokenCreator on the service account
+	if auth.Impersonate != "" {
+		issuer, err := htransport.NewTransport(ctx, retrying, append(credentials, option.WithScopes(cloudresourcemanager.CloudPlatformScope))...)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tokens, err := f.tokens(ctx, &http.Client{Transport: issuer})
+
+		if err != nil {
+			return nil, err
+		}
+
+		credentials = []option.ClientOption{option.WithTokenSource(oauth2.ReuseTokenSource(nil, &impersonatedTokenSource{
+			ctx:            ctx,
+			tokens:         &instrumentedTokens{tokens},
+			serviceAccount: auth.Impersonate,
+		}))}
+	}
+
+	// the clients share one authenticated transport over the configured base transport. The
+	// scope is explicit as keys would otherwise sign their own tokens for a single endpoint
+	transport, err := htransport.NewTransport(ctx, retrying, append(credentials, option.WithScopes(cloudresourcemanager.CloudPlatformScope))...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := option.WithHTTPClient(&http.Client{Transport: transport})
+
+	crm, err := cloudresourcemanager.NewService(ctx, httpClient, option.WithEndpoint(f.options.Endpoint(CloudResourceManagerEndpoint)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	cb, err := cloudbilling.NewService(ctx, httpClient, option.WithEndpoint(f.options.Endpoint(CloudBillingEndpoint)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	sm, err := servicemanagement.NewService(ctx, httpClient, option.WithEndpoint(f.options.Endpoint(ServiceManagementEndpoint)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	i, err := iam.NewService(ctx, httpClient, option.WithEndpoint(f.options.Endpoint(IAMEndpoint)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := f.tokens(ctx, &http.Client{Transport: transport})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Instrument(&Client{
+		Organizations: &organizations{crm: crm},
+		Projects:      &projects{crm: crm},
+		Billing:       &billing{cb: cb},
+		Services:      &serviceUsage{sm: sm},
+		IAM:           &iamClient{iam: i},
+		Tokens:        tokens,
+		Operations:    &operations{crm: crm, sm: sm},
+	}), nil
+}
+
+// credentials returns the options authenticating as the key or token, or as the operator itself
+// when given neither
+func (f *factory) credentials(auth Auth) []option.ClientOption {
+	switch {
+	case len(auth.Key) > 0:
+		return []option.ClientOption{option.WithCredentialsJSON(auth.Key)}
+	case auth.Token != "":
+		return []option.ClientOption{option.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: auth.Token}))}
+	case f.options.RootKeyFile != "":
+		return []option.ClientOption{option.WithCredentialsFile(f.options.RootKeyFile)}
+	default:
+		// the application default credentials are found by the transport
+		return nil
+	}
+}
+
+// tokens returns the iam credentials api over the authenticated http client
+func (f *factory) tokens(ctx context.Context, httpClient *http.Client) (*tokens, error) {
+	ic, err := iamcredentials.NewService(ctx, option.WithHTTPClient(httpClient), option.WithEndpoint(f.options.Endpoint(IAMCredentialsEndpoint)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokens{ic: ic}, nil
+}
+
+// organizations implements Organizations with the cloudresourcemanager api
+type organizations struct {
+	crm *cloudresourcemanager.Service
+}
+
+func (o *organizations) TestIamPermissions(ctx context.Context, organizationId string, permissions []string) ([]string, error) {
+	resource := "organizations/" + strings.TrimPrefix(organizationId, "organizations/")
+
+	resp, err := o.crm.Organizations.TestIamPermissions(resource, &cloudresourcemanager.TestIamPermissionsRequest{
+		Permissions: permissions,
+	}).Context(ctx).Do()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Permissions, nil
+}
+
+// projects implements Projects with the cloudresourcemanager api
+type projects struct {
+	crm *cloudresourcemanager.Service
+}
+
+func (p *projects) Exists(ctx context.Context, projectId string) (bool, error) {
+	// an empty ID would filter on nothing and match every project
+	if projectId == "" {
+		return false, errors.New("no project ID given")
+	}
+
+	resp, err := p.crm.Projects.List().Filter("id:" + projectId).Context(ctx).Do()
+
+	if err != nil {
+		return false, err
+	}
+
+	return len(resp.Projects) > 0, nil
+}
+
+func (p *projects) Get(ctx context.Context, projectId string) (*cloudresourcemanager.Project, error) {
+	return p.crm.Projects.Get(projectId).Context(ctx).Do()
+}
+
+func (p *projects) Create(ctx context.Context, project *cloudresourcemanager.Project) (string, error) {
+	resp, err := p.crm.Projects.Create(project).Context(ctx).Do()
+
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Name, nil
+}
+
+func (p *projects) Update(ctx context.Context, project *cloudresourcemanager.Project) error {
+	_, err := p.crm.Projects.Update(project.ProjectId, project).Context(ctx).Do()
+
+	return err
+}
+
+func (p *projects) Delete(ctx context.Context, projectId string) error {
+	_, err := p.crm.Projects.Delete(projectId).Context(ctx).Do()
+
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (p *projects) GetIamPolicy(ctx context.Context, projectId string) (*cloudresourcemanager.Policy, error) {
+	// request the latest policy version so conditional bindings are returned and preserved
+	return p.crm.Projects.GetIamPolicy(projectId, &cloudresourcemanager.GetIamPolicyRequest{
+		Options: &cloudresourcemanager.GetPolicyOptions{RequestedPolicyVersion: 3},
+	}).Context(ctx).Do()
+}
+
+func (p *projects) SetIamPolicy(ctx context.Context, projectId string, policy *cloudresourcemanager.Policy) error {
+	_, err := p.crm.Projects.SetIamPolicy(projectId, &cloudresourcemanager.SetIamPolicyRequest{
+		Policy: policy,
+	}).Context(ctx).Do()
+
+	return err
+}
+
+func (p *projects) TestIamPermissions(ctx context.Context, projectId string, permissions []string) ([]string, error) {
+	resp, err := p.crm.Projects.TestIamPermissions(projectId, &cloudresourcemanager.TestIamPermissionsRequest{
+		Permissions: permissions,
+	}).Context(ctx).Do()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Permissions, nil
+}
+
+func (p *projects) Organization(ctx context.Context, projectId string) (string, error) {
+	// the ancestry runs from the project up through its folders to the organization
+	resp, err := p.crm.Projects.GetAncestry(projectId, &cloudresourcemanager.GetAncestryRequest{}).Context(ctx).Do()
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, ancestor := range resp.Ancestor {
+		if ancestor.ResourceId != nil && ancestor.ResourceId.Type == "organization" {
+			return ancestor.ResourceId.Id, nil
+		}
+	}
+
+	return "", nil
+}
+
+// billing implements Billing with the cloudbilling api
+type billing struct {
+	cb *cloudbilling.APIService
+}
+
+func (b *billing) GetBillingAccount(ctx context.Context, projectId string) (string, error) {
+	info, err := b.cb.Projects.GetBillingInfo("projects/" + projectId).Context(ctx).Do()
+
+	if err != nil {
+		return "", err
+	}
+
+	return info.BillingAccountName, nil
+}
+
+func (b *billing) SetBillingAccount(ctx context.Context, projectId, billingAccountName string) error {
+	_, err := b.cb.Projects.UpdateBillingInfo("projects/"+projectId, &cloudbilling.ProjectBillingInfo{
+		BillingAccountName: "billingAccounts/" + billingAccountName,
+		BillingEnabled:     true,
+	}).Context(ctx).Do()
+
+	return err
+}
+
+func (b *billing) DisableBilling(ctx context.Context, projectId string) error {
+	_, err := b.cb.Projects.UpdateBillingInfo("projects/"+projectId, &cloudbilling.ProjectBillingInfo{
+		BillingAccountName: "",
+		ForceSendFields:    []string{"BillingAccountName"},
+	}).Context(ctx).Do()
+
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// serviceUsage implements ServiceUsage with the servicemanagement api
+type serviceUsage struct {
+	sm *servicemanagement.APIService
+}
+
+func (s *serviceUsage) ListEnabled(ctx context.Context, projectId string) ([]string, error) {
+	var enabled []string
+
+	err := s.sm.Services.List().ConsumerId("project:"+projectId).Pages(ctx, func(resp *servicemanagement.ListServicesResponse) error {
+		for _, service := range resp.Services {
+			enabled = append(enabled, service.ServiceName)
+		}
+		return nil
+	})
+
+	return enabled, err
+}
+
+func (s *serviceUsage) Enable(ctx context.Context, projectId, service string) (string, error) {
+	resp, err := s.sm.Services.Enable(service, &servicemanagement.EnableServiceRequest{
+		ConsumerId: "project:" + projectId,
+	}).Context(ctx).Do()
+
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Name, nil
+}
+
+func (s *serviceUsage) Disable(ctx context.Context, projectId, service string) (string, error) {
+	resp, err := s.sm.Services.Disable(service, &servicemanagement.DisableServiceRequest{
+		ConsumerId: "project:" + projectId,
+	}).Context(ctx).Do()
+
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Name, nil
+}
+
+// iamClient implements IAM with the iam api
+type iamClient struct {
+	iam *iam.Service
+}
+
+// serviceAccountResource returns the resource name of the service account
+func serviceAccountResource(projectId, name string) string {
+	return "projects/" + projectId + "/serviceAccounts/" + ServiceAccountEmail(projectId, name)
+}
+
+func (i *iamClient) GetServiceAccount(ctx context.Context, projectId, name string) (*iam.ServiceAccount, error) {
+	return i.iam.Projects.ServiceAccounts.Get(serviceAccountResource(projectId, name)).Context(ctx).Do()
+}
+
+func (i *iamClient) CreateServiceAccount(ctx context.Context, projectId, name, displayName string) (*iam.ServiceAccount, error) {
+	return i.iam.Projects.ServiceAccounts.Create("projects/"+projectId, &iam.CreateServiceAccountRequest{
+		AccountId: name,
+		ServiceAccount: &iam.ServiceAccount{
+			DisplayName: displayName,
+		},
+	}).Context(ctx).Do()
+}
+
+func (i *iamClient) DeleteServiceAccount(ctx context.Context, projectId, name string) error {
+	_, err := i.iam.Projects.ServiceAccounts.Delete(serviceAccountResource(projectId, name)).Context(ctx).Do()
+
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (i *iamClient) CreateServiceAccountKey(ctx context.Context, projectId, name string) (string, error) {
+	key, err := i.iam.Projects.ServiceAccounts.Keys.Create(serviceAccountResource(projectId, name), &iam.CreateServiceAccountKeyRequest{}).Context(ctx).Do()
+
+	if err != nil {
+		return "", err
+	}
+
+	return key.PrivateKeyData, nil
+}
+
+func (i *iamClient) DeleteServiceAccountKey(ctx context.Context, keyName string) error {
+	_, err := i.iam.Projects.ServiceAccounts.Keys.Delete(keyName).Context(ctx).Do()
+
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// tokens implements Tokens with the iam credentials api
+type tokens struct {
+	ic *iamcredentials.Service
+}
+
+func (t *tokens) GenerateAccessToken(ctx context.Context, serviceAccount string, lifetime time.Duration) (*oauth2.Token, error) {
+	resp, err := t.ic.Projects.ServiceAccounts.GenerateAccessToken("projects/-/serviceAccounts/"+serviceAccount, &iamcredentials.GenerateAccessTokenRequest{
+		Scope:    []string{cloudresourcemanager.CloudPlatformScope},
+		Lifetime: strconv.Itoa(int(lifetime.Seconds())) + "s",
+	}).Context(ctx).Do()
+
+	if err != nil {
+		return nil, err
+	}
+
+	expiry, err := time.Parse(time.RFC3339, resp.ExpireTime)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Token{AccessToken: resp.AccessToken, TokenType: "Bearer", Expiry: expiry}, nil
+}
+
+// impersonatedTokenSource issues the tokens of the service account a client acts as
+type impersonatedTokenSource struct {
+	ctx            context.Context
+	tokens         Tokens
+	serviceAccount string
+}
+
+func (s *impersonatedTokenSource) Token() (*oauth2.Token, error) {
+	return s.tokens.GenerateAccessToken(s.ctx, s.serviceAccount, ImpersonatedTokenLifetime)
+}
+
+// operations implements Operations with the api owning each operation
+type operations struct {
+	crm *cloudresourcemanager.Service
+	sm  *servicemanagement.APIService
+}
+
+func (o *operations) Done(ctx context.Context, op v1alpha1.Operation) (bool, error, error) {
+	if op.Service == v1alpha1.ServiceManagementService {
+		resp, err := o.sm.Operations.Get(op.Name).Context(ctx).Do()
+
+		if err != nil {
+			return false, nil, err
+		}
+
+		if resp.Error != nil {
+			return true, errors.New(resp.Error.Message), nil
+		}
+
+		return resp.Done, nil, nil
+	}
+
+	resp, err := o.crm.Operations.Get(op.Name).Context(ctx).Do()
+
+	if err != nil {
+		return false, nil, err
+	}
+
+	if resp.Error != nil {
+		return true, errors.New(resp.Error.Message), nil
+	}
+
+	return resp.Done, nil, nil
+}
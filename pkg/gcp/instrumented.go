@@ -0,0 +1,250 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/metrics"
+	"golang.org/x/oauth2"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v1"
+	"google.golang.org/api/googleapi"
+	iam "google.golang.org/api/iam/v1"
+)
+
+// Instrument returns a client recording the calls made through it in the metrics
+func Instrument(c *Client) *Client {
+	return &Client{
+		Organizations: &instrumentedOrganizations{c.Organizations},
+		Projects:      &instrumentedProjects{c.Projects},
+		Billing:       &instrumentedBilling{c.Billing},
+		Services:      &instrumentedServices{c.Services},
+		IAM:           &instrumentedIAM{c.IAM},
+		Tokens:        &instrumentedTokens{c.Tokens},
+		Operations:    &instrumentedOperations{c.Operations},
+	}
+}
+
+// observe records a call to the api method, the status code is taken from the error
+func observe(service, method string, start time.Time, err error) {
+	code := "200"
+	if err != nil {
+		code = "error"
+		var e *googleapi.Error
+		if errors.As(err, &e) {
+			code = strconv.Itoa(e.Code)
+		}
+	}
+
+	metrics.APIRequests.WithLabelValues(service, method, code).Inc()
+	metrics.APIRequestDuration.WithLabelValues(service, method).Observe(time.Since(start).Seconds())
+}
+
+type instrumentedOrganizations struct{ next Organizations }
+
+func (i *instrumentedOrganizations) TestIamPermissions(ctx context.Context, organizationId string, permissions []string) ([]string, error) {
+	start := time.Now()
+	granted, err := i.next.TestIamPermissions(ctx, organizationId, permissions)
+	observe("cloudresourcemanager", "Organizations.TestIamPermissions", start, err)
+
+	return granted, err
+}
+
+type instrumentedProjects struct{ next Projects }
+
+func (i *instrumentedProjects) Exists(ctx context.Context, projectId string) (bool, error) {
+	start := time.Now()
+	exists, err := i.next.Exists(ctx, projectId)
+	observe("cloudresourcemanager", "Projects.Exists", start, err)
+
+	return exists, err
+}
+
+func (i *instrumentedProjects) Get(ctx context.Context, projectId string) (*cloudresourcemanager.Project, error) {
+	start := time.Now()
+	project, err := i.next.Get(ctx, projectId)
+	observe("cloudresourcemanager", "Projects.Get", start, err)
+
+	return project, err
+}
+
+func (i *instrumentedProjects) Create(ctx context.Context, project *cloudresourcemanager.Project) (string, error) {
+	start := time.Now()
+	operationName, err := i.next.Create(ctx, project)
+	observe("cloudresourcemanager", "Projects.Create", start, err)
+
+	return operationName, err
+}
+
+func (i *instrumentedProjects) Update(ctx context.Context, project *cloudresourcemanager.Project) error {
+	start := time.Now()
+	err := i.next.Update(ctx, project)
+	observe("cloudresourcemanager", "Projects.Update", start, err)
+
+	return err
+}
+
+func (i *instrumentedProjects) Delete(ctx context.Context, projectId string) error {
+	start := time.Now()
+	err := i.next.Delete(ctx, projectId)
+	observe("cloudresourcemanager", "Projects.Delete", start, err)
+
+	return err
+}
+
+func (i *instrumentedProjects) GetIamPolicy(ctx context.Context, projectId string) (*cloudresourcemanager.Policy, error) {
+	start := time.Now()
+	policy, err := i.next.GetIamPolicy(ctx, projectId)
+	observe("cloudresourcemanager", "Projects.GetIamPolicy", start, err)
+
+	return policy, err
+}
+
+func (i *instrumentedProjects) SetIamPolicy(ctx context.Context, projectId string, policy *cloudresourcemanager.Policy) error {
+	start := time.Now()
+	err := i.next.SetIamPolicy(ctx, projectId, policy)
+	observe("cloudresourcemanager", "Projects.SetIamPolicy", start, err)
+
+	return err
+}
+
+func (i *instrumentedProjects) TestIamPermissions(ctx context.Context, projectId string, permissions []string) ([]string, error) {
+	start := time.Now()
+	granted, err := i.next.TestIamPermissions(ctx, projectId, permissions)
+	observe("cloudresourcemanager", "Projects.TestIamPermissions", start, err)
+
+	return granted, err
+}
+
+func (i *instrumentedProjects) Organization(ctx context.Context, projectId string) (string, error) {
+	start := time.Now()
+	organizationId, err := i.next.Organization(ctx, projectId)
+	observe("cloudresourcemanager", "Projects.GetAncestry", start, err)
+
+	return organizationId, err
+}
+
+type instrumentedBilling struct{ next Billing }
+
+func (i *instrumentedBilling) GetBillingAccount(ctx context.Context, projectId string) (string, error) {
+	start := time.Now()
+	name, err := i.next.GetBillingAccount(ctx, projectId)
+	observe("cloudbilling", "Billing.GetBillingAccount", start, err)
+
+	return name, err
+}
+
+func (i *instrumentedBilling) SetBillingAccount(ctx context.Context, projectId, billingAccountName string) error {
+	start := time.Now()
+	err := i.next.SetBillingAccount(ctx, projectId, billingAccountName)
+	observe("cloudbilling", "Billing.SetBillingAccount", start, err)
+
+	return err
+}
+
+func (i *instrumentedBilling) DisableBilling(ctx context.Context, projectId string) error {
+	start := time.Now()
+	err := i.next.DisableBilling(ctx, projectId)
+	observe("cloudbilling", "Billing.DisableBilling", start, err)
+
+	return err
+}
+
+type instrumentedServices struct{ next ServiceUsage }
+
+func (i *instrumentedServices) ListEnabled(ctx context.Context, projectId string) ([]string, error) {
+	start := time.Now()
+	enabled, err := i.next.ListEnabled(ctx, projectId)
+	observe("servicemanagement", "Services.ListEnabled", start, err)
+
+	return enabled, err
+}
+
+func (i *instrumentedServices) Enable(ctx context.Context, projectId, service string) (string, error) {
+	start := time.Now()
+	operationName, err := i.next.Enable(ctx, projectId, service)
+	observe("servicemanagement", "Services.Enable", start, err)
+
+	return operationName, err
+}
+
+func (i *instrumentedServices) Disable(ctx context.Context, projectId, service string) (string, error) {
+	start := time.Now()
+	operationName, err := i.next.Disable(ctx, projectId, service)
+	observe("servicemanagement", "Services.Disable", start, err)
+
+	return operationName, err
+}
+
+type instrumentedIAM struct{ next IAM }
+
+func (i *instrumentedIAM) GetServiceAccount(ctx context.Context, projectId, name string) (*iam.ServiceAccount, error) {
+	start := time.Now()
+	account, err := i.next.GetServiceAccount(ctx, projectId, name)
+	observe("iam", "IAM.GetServiceAccount", start, err)
+
+	return account, err
+}
+
+func (i *instrumentedIAM) CreateServiceAccount(ctx context.Context, projectId, name, displayName string) (*iam.ServiceAccount, error) {
+	start := time.Now()
+	account, err := i.next.CreateServiceAccount(ctx, projectId, name, displayName)
+	observe("iam", "IAM.CreateServiceAccount", start, err)
+
+	return account, err
+}
+
+func (i *instrumentedIAM) DeleteServiceAccount(ctx context.Context, projectId, name string) error {
+	start := time.Now()
+	err := i.next.DeleteServiceAccount(ctx, projectId, name)
+	observe("iam", "IAM.DeleteServiceAccount", start, err)
+
+	return err
+}
+
+func (i *instrumentedIAM) CreateServiceAccountKey(ctx context.Context, projectId, name string) (string, error) {
+	start := time.Now()
+	key, err := i.next.CreateServiceAccountKey(ctx, projectId, name)
+	observe("iam", "IAM.CreateServiceAccountKey", start, err)
+
+	return key, err
+}
+
+func (i *instrumentedIAM) DeleteServiceAccountKey(ctx context.Context, keyName string) error {
+	start := time.Now()
+	err := i.next.DeleteServiceAccountKey(ctx, keyName)
+	observe("iam", "IAM.DeleteServiceAccountKey", start, err)
+
+	return err
+}
+
+type instrumentedTokens struct{ next Tokens }
+
+func (i *instrumentedTokens) GenerateAccessToken(ctx context.Context, serviceAccount string, lifetime time.Duration) (*oauth2.Token, error) {
+	start := time.Now()
+	token, err := i.next.GenerateAccessToken(ctx, serviceAccount, lifetime)
+	observe("iamcredentials", "Tokens.GenerateAccessToken", start, err)
+
+	return token, err
+}
+
+type instrumentedOperations struct{ next Operations }
+
+func (i *instrumentedOperations) Done(ctx context.Context, op v1alpha1.Operation) (bool, error, error) {
+	start := time.Now()
+	done, failure, err := i.next.Done(ctx, op)
+	// the request succeeded even when the operation it checked has failed
+	observe(op.Service, "Operations.Get", start, err)
+
+	if err == nil && done {
+		if failure != nil {
+			metrics.ObserveOperationFailure(op)
+		} else {
+			metrics.ObserveOperation(op)
+		}
+	}
+
+	return done, failure, err
+}
@@ -0,0 +1,116 @@
+package gcp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// The default base URLs of the google apis
+const (
+	CloudResourceManagerEndpoint = "https://cloudresourcemanager.googleapis.com/"
+	CloudBillingEndpoint         = "https://cloudbilling.googleapis.com/"
+	ServiceManagementEndpoint    = "https://servicemanagement.googleapis.com/"
+	IAMEndpoint                  = "https://iam.googleapis.com/"
+	IAMCredentialsEndpoint       = "https://iamcredentials.googleapis.com/"
+)
+
+// Endpoints overrides the base URLs of the google apis, an empty endpoint uses the default
+type Endpoints struct {
+	CloudResourceManager string
+	CloudBilling         string
+	ServiceManagement    string
+	IAM                  string
+	IAMCredentials       string
+}
+
+// Options configures how the google apis are reached
+type Options struct {
+	// Endpoints overrides the base URLs of the apis e.g. for private google access or an emulator
+	Endpoints Endpoints
+	// CABundle is the path of a PEM bundle trusted in addition to the system roots
+	CABundle string
+	// Proxy is the URL of the HTTP proxy requests are sent through, when empty the proxy
+	// environment variables are used
+	Proxy string
+	// Retry configures how transient failures are retried
+	Retry Retry
+	// RateLimit is the number of requests per second allowed for each credential, zero
+	// disables rate limiting
+	RateLimit float64
+	// RateBurst is the number of requests allowed for each credential in a burst
+	RateBurst int
+	// RootKeyFile is the path of the JSON service account key the operator authenticates as when
+	// given no key or token, when empty the application default credentials are used
+	RootKeyFile string
+}
+
+// Endpoint returns the base URL of the api, the override when one is set or the default
+func (o Options) Endpoint(defaultEndpoint string) string {
+	override := ""
+
+	switch defaultEndpoint {
+	case CloudResourceManagerEndpoint:
+		override = o.Endpoints.CloudResourceManager
+	case CloudBillingEndpoint:
+		override = o.Endpoints.CloudBilling
+	case ServiceManagementEndpoint:
+		override = o.Endpoints.ServiceManagement
+	case IAMEndpoint:
+		override = o.Endpoints.IAM
+	case IAMCredentialsEndpoint:
+		override = o.Endpoints.IAMCredentials
+	}
+
+	if override == "" {
+		return defaultEndpoint
+	}
+
+	// the generated clients append paths directly to the base URL
+	if !strings.HasSuffix(override, "/") {
+		override += "/"
+	}
+	return override
+}
+
+// Transport returns the base transport for requests to the google apis, trusting the CA
+// bundle and using the proxy
+func (o Options) Transport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if o.Proxy != "" {
+		proxy, err := url.Parse(o.Proxy)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy: %v", err)
+		}
+
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if o.CABundle != "" {
+		pem, err := ioutil.ReadFile(o.CABundle)
+
+		if err != nil {
+			return nil, fmt.Errorf("reading the CA bundle: %v", err)
+		}
+
+		roots, err := x509.SystemCertPool()
+
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in the CA bundle %s", o.CABundle)
+		}
+
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+
+	return transport, nil
+}
@@ -0,0 +1,146 @@
+package gcp
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Retry configures how requests to the google apis are retried
+type Retry struct {
+	// MaxRetries is the number of times a request is retried, zero disables retries
+	MaxRetries int
+	// MinBackoff is the delay before the first retry, doubling on each retry
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between retries, including delays requested by Retry-After
+	MaxBackoff time.Duration
+}
+
+// retryable checks if the status code indicates a transient failure the request can be retried
+// after. A 5xx may be returned after the api has acted on the request, so only idempotent requests
+// are retried on one, while a 429 is returned before the request is processed
+func retryable(method string, code int) bool {
+	switch code {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent(method)
+	}
+	return false
+}
+
+// idempotent checks if repeating a request with the method has the same effect as sending it once,
+// creating projects or keys with a POST does not
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryTransport rate limits requests and retries those failing transiently with exponential
+// backoff and jitter
+type retryTransport struct {
+	base    http.RoundTripper
+	retry   Retry
+	limiter *rate.Limiter
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if t.limiter != nil {
+			if err := t.limiter.Wait(req.Context()); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.base.RoundTrip(req)
+
+		// a request whose body cannot be replayed is only sent once
+		if attempt >= t.retry.MaxRetries || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+
+		if err == nil && !retryable(req.Method, resp.StatusCode) {
+			return resp, nil
+		}
+
+		// only requests which never reached the api are retried after a transport error, as the
+		// api may have acted on the others
+		if err != nil && req.Method != http.MethodGet {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+
+		if resp != nil {
+			if after, ok := retryAfter(resp); ok {
+				delay = after
+			}
+
+			// drain the body so the connection can be reused
+			_, _ = ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+
+		if t.retry.MaxBackoff > 0 && delay > t.retry.MaxBackoff {
+			delay = t.retry.MaxBackoff
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+
+			if err != nil {
+				return nil, err
+			}
+
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// backoff returns the delay before the retry, growing exponentially with full jitter
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	max := t.retry.MinBackoff << uint(attempt)
+
+	if max <= 0 || (t.retry.MaxBackoff > 0 && max > t.retry.MaxBackoff) {
+		max = t.retry.MaxBackoff
+	}
+
+	if max <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// retryAfter returns the delay requested by the Retry-After header, given in seconds or as a date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,219 @@
+package gcp
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		method    string
+		code      int
+		retryable bool
+	}{
+		{method: http.MethodGet, code: http.StatusOK},
+		{method: http.MethodGet, code: http.StatusNotFound},
+		{method: http.MethodGet, code: http.StatusTooManyRequests, retryable: true},
+		{method: http.MethodPost, code: http.StatusTooManyRequests, retryable: true},
+		{method: http.MethodGet, code: http.StatusServiceUnavailable, retryable: true},
+		{method: http.MethodPut, code: http.StatusBadGateway, retryable: true},
+		{method: http.MethodDelete, code: http.StatusGatewayTimeout, retryable: true},
+		{method: http.MethodPost, code: http.StatusInternalServerError},
+		{method: http.MethodPatch, code: http.StatusServiceUnavailable},
+		{method: http.MethodGet, code: http.StatusNotImplemented},
+	}
+
+	for _, test := range tests {
+		t.Run(test.method+" "+http.StatusText(test.code), func(t *testing.T) {
+			if retryable := retryable(test.method, test.code); retryable != test.retryable {
+				t.Errorf("expected retryable %t, got %t", test.retryable, retryable)
+			}
+		})
+	}
+}
+
+// stubTransport returns the responses in turn, a zero status code returning a transport error
+type stubTransport struct {
+	codes    []int
+	headers  []http.Header
+	requests []string
+}
+
+func (s *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempt := len(s.requests)
+
+	body := ""
+	if req.Body != nil {
+		read, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = string(read)
+	}
+	s.requests = append(s.requests, body)
+
+	code := s.codes[len(s.codes)-1]
+	if attempt < len(s.codes) {
+		code = s.codes[attempt]
+	}
+
+	if code == 0 {
+		return nil, errors.New("connection reset")
+	}
+
+	header := http.Header{}
+	if attempt < len(s.headers) && s.headers[attempt] != nil {
+		header = s.headers[attempt]
+	}
+
+	return &http.Response{StatusCode: code, Header: header, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		codes    []int
+		retries  int
+		attempts int
+		code     int
+		err      bool
+	}{
+		{name: "success", method: http.MethodGet, codes: []int{200}, retries: 3, attempts: 1, code: 200},
+		{name: "not found", method: http.MethodGet, codes: []int{404}, retries: 3, attempts: 1, code: 404},
+		{name: "recovers", method: http.MethodGet, codes: []int{503, 503, 200}, retries: 3, attempts: 3, code: 200},
+		{name: "gives up", method: http.MethodGet, codes: []int{503}, retries: 3, attempts: 4, code: 503},
+		{name: "retries disabled", method: http.MethodGet, codes: []int{503}, attempts: 1, code: 503},
+		{name: "rate limited post", method: http.MethodPost, body: "{}", codes: []int{429, 200}, retries: 3, attempts: 2, code: 200},
+		{name: "failed post", method: http.MethodPost, body: "{}", codes: []int{500}, retries: 3, attempts: 1, code: 500},
+		{name: "transport error on get", method: http.MethodGet, codes: []int{0, 200}, retries: 3, attempts: 2, code: 200},
+		{name: "transport error on post", method: http.MethodPost, body: "{}", codes: []int{0, 200}, retries: 3, attempts: 1, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stub := &stubTransport{codes: test.codes}
+			transport := &retryTransport{base: stub, retry: Retry{MaxRetries: test.retries}}
+
+			// requests with a body are replayable, as GetBody is set for a strings.Reader
+			var body io.Reader
+			if test.body != "" {
+				body = strings.NewReader(test.body)
+			}
+
+			req, err := http.NewRequest(test.method, "https://cloudresourcemanager.googleapis.com/v1/projects", body)
+			if err != nil {
+				t.Fatalf("failed to create the request: %v", err)
+			}
+
+			resp, err := transport.RoundTrip(req)
+
+			if (err != nil) != test.err {
+				t.Fatalf("expected error %t, got %v", test.err, err)
+			}
+			if err == nil && resp.StatusCode != test.code {
+				t.Errorf("expected status %d, got %d", test.code, resp.StatusCode)
+			}
+			if len(stub.requests) != test.attempts {
+				t.Errorf("expected %d attempts, got %d", test.attempts, len(stub.requests))
+			}
+			for i, sent := range stub.requests {
+				if sent != test.body {
+					t.Errorf("expected attempt %d to send %q, got %q", i, test.body, sent)
+				}
+			}
+		})
+	}
+}
+
+func TestRoundTripHonoursRetryAfter(t *testing.T) {
+	stub := &stubTransport{codes: []int{429, 200}, headers: []http.Header{{"Retry-After": []string{"1"}}}}
+	transport := &retryTransport{base: stub, retry: Retry{MaxRetries: 1, MaxBackoff: 10 * time.Millisecond}}
+
+	req, err := http.NewRequest(http.MethodGet, "https://cloudresourcemanager.googleapis.com/v1/projects", nil)
+	if err != nil {
+		t.Fatalf("failed to create the request: %v", err)
+	}
+
+	start := time.Now()
+
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the delay requested by the api is capped by the maximum backoff
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("expected the retry after to be capped at the maximum backoff, waited %s", elapsed)
+	}
+	if len(stub.requests) != 2 {
+		t.Errorf("expected 2 attempts, got %d", len(stub.requests))
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		delay time.Duration
+		found bool
+	}{
+		{name: "missing"},
+		{name: "seconds", value: "30", delay: 30 * time.Second, found: true},
+		{name: "negative", value: "-1"},
+		{name: "invalid", value: "soon"},
+		{name: "past date", value: "Mon, 02 Jan 2006 15:04:05 GMT", found: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if test.value != "" {
+				resp.Header.Set("Retry-After", test.value)
+			}
+
+			delay, found := retryAfter(resp)
+
+			if found != test.found {
+				t.Fatalf("expected found %t, got %t", test.found, found)
+			}
+			if test.delay != 0 && delay != test.delay {
+				t.Errorf("expected a delay of %s, got %s", test.delay, delay)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		retry   Retry
+		attempt int
+		max     time.Duration
+	}{
+		{name: "disabled", attempt: 3},
+		{name: "first", retry: Retry{MinBackoff: time.Second, MaxBackoff: time.Minute}, max: time.Second},
+		{name: "doubling", retry: Retry{MinBackoff: time.Second, MaxBackoff: time.Minute}, attempt: 3, max: 8 * time.Second},
+		{name: "capped", retry: Retry{MinBackoff: time.Second, MaxBackoff: time.Minute}, attempt: 10, max: time.Minute},
+		{name: "overflow", retry: Retry{MinBackoff: time.Second, MaxBackoff: time.Minute}, attempt: 80, max: time.Minute},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transport := &retryTransport{retry: test.retry}
+
+			for i := 0; i < 20; i++ {
+				delay := transport.backoff(test.attempt)
+
+				if delay < 0 || (test.max == 0 && delay != 0) || (test.max > 0 && delay >= test.max) {
+					t.Fatalf("expected a delay below %s, got %s", test.max, delay)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package indexes
+
+import (
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// CredentialsField indexes GCPProjects by the namespace/name of the GCPCredentials in spec.use
+	CredentialsField = "spec.use"
+	// KeySecretField indexes GCPCredentials by the namespace/name of the Secret in spec.keyRef
+	KeySecretField = "spec.keyRef"
+)
+
+// AddToManager registers the field indexes used to find the resources depending on another
+func AddToManager(m manager.Manager) error {
+	indexer := m.GetFieldIndexer()
+
+	err := indexer.IndexField(&gcpv1alpha1.GCPProject{}, CredentialsField, func(obj runtime.Object) []string {
+		use := obj.(*gcpv1alpha1.GCPProject).Spec.Use
+		if use.Name == "" {
+			return nil
+		}
+		return []string{Key(use.Namespace, use.Name)}
+	})
+	if err != nil {
+		return err
+	}
+
+	return indexer.IndexField(&gcpv1alpha1.GCPCredentials{}, KeySecretField, func(obj runtime.Object) []string {
+		credentials := obj.(*gcpv1alpha1.GCPCredentials)
+		if credentials.Spec.KeyRef == nil {
+			return nil
+		}
+		return []string{Key(credentials.Namespace, credentials.Spec.KeyRef.Name)}
+	})
+}
+
+// Key returns the value indexed for a reference to the named resource
+func Key(namespace, name string) string {
+	return types.NamespacedName{Namespace: namespace, Name: name}.String()
+}
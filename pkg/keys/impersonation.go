@@ -0,0 +1,96 @@
+package keys
+
+import (
+	"context"
+
+	"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ImpersonationNotAllowed explains why credentials without a key were refused
+const ImpersonationNotAllowed = "credentials without a key can only impersonate the service account of the GCPProject or GCPAdminProject they were generated for"
+
+// Source is the GCPProject or GCPAdminProject credentials were generated for
+type Source struct {
+	// Kind is the kind of the resource
+	Kind string
+	// ProjectId is the ID of its project, empty until one is chosen
+	ProjectId string
+	// ServiceAccountName is the name of its service account
+	ServiceAccountName string
+}
+
+// GeneratedFor returns the GCPProject or GCPAdminProject in the namespace of the credentials which
+// controls them, or nil if none does. Anyone creating credentials can set labels and the owner
+// reference, so the owner must exist with the UID it is referred to by
+func GeneratedFor(ctx context.Context, reader client.Reader, credentials *v1alpha1.GCPCredentials) (*Source, error) {
+	owner := metav1.GetControllerOf(credentials)
+
+	if owner == nil || owner.APIVersion != v1alpha1.SchemeGroupVersion.String() {
+		return nil, nil
+	}
+
+	reference := types.NamespacedName{Namespace: credentials.Namespace, Name: owner.Name}
+
+	var object metav1.Object
+	source := &Source{Kind: owner.Kind}
+
+	switch owner.Kind {
+	case "GCPProject":
+		project := &v1alpha1.GCPProject{}
+		object = project
+
+		if err := reader.Get(ctx, reference, project); err != nil {
+			return nil, ignoreNotFound(err)
+		}
+
+		source.ProjectId, source.ServiceAccountName = project.Spec.ProjectId, project.Spec.ServiceAccountName
+		if source.ProjectId == "" {
+			source.ProjectId = project.Status.ProjectId
+		}
+	case "GCPAdminProject":
+		project := &v1alpha1.GCPAdminProject{}
+		object = project
+
+		if err := reader.Get(ctx, reference, project); err != nil {
+			return nil, ignoreNotFound(err)
+		}
+
+		source.ProjectId, source.ServiceAccountName = project.Spec.ProjectId, project.Spec.ServiceAccountName
+	default:
+		return nil, nil
+	}
+
+	// a resource recreated under the same name did not generate the credentials
+	if object.GetUID() != owner.UID {
+		return nil, nil
+	}
+
+	return source, nil
+}
+
+// OwnServiceAccount returns the email of the service account of the GCPProject or GCPAdminProject
+// in the namespace of the credentials which controls them, or an empty string if none does.
+// Credentials without a key impersonate through the operator's own identity, so they must not
+// name any other service account or the operator would lend its roles to whoever created them
+func OwnServiceAccount(ctx context.Context, reader client.Reader, credentials *v1alpha1.GCPCredentials) (string, error) {
+	source, err := GeneratedFor(ctx, reader, credentials)
+
+	if err != nil || source == nil || source.ProjectId == "" || source.ServiceAccountName == "" {
+		return "", err
+	}
+
+	return gcp.ServiceAccountEmail(source.ProjectId, source.ServiceAccountName), nil
+}
+
+// ignoreNotFound treats a missing resource as no error
+func ignoreNotFound(err error) error {
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,124 @@
+package keys
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultSecretKey is the key in a Secret holding the JSON service account key
+const DefaultSecretKey = "key.json"
+
+// Get returns the JSON service account key held by the credentials, reading it from the
+// referenced Secret or decoding it from the spec
+func Get(ctx context.Context, c client.Client, credentials *v1alpha1.GCPCredentials) ([]byte, error) {
+	if credentials.Spec.KeyRef == nil {
+		if credentials.Spec.Key == "" {
+			return nil, errors.New("the credentials have neither a key nor a keyRef")
+		}
+		return base64.StdEncoding.DecodeString(credentials.Spec.Key)
+	}
+
+	secretKey := credentials.Spec.KeyRef.Key
+	if secretKey == "" {
+		secretKey = DefaultSecretKey
+	}
+
+	secret := &corev1.Secret{}
+
+	reference := types.NamespacedName{Namespace: credentials.Namespace, Name: credentials.Spec.KeyRef.Name}
+
+	if err := c.Get(ctx, reference, secret); err != nil {
+		return nil, err
+	}
+
+	key, found := secret.Data[secretKey]
+	if !found {
+		return nil, fmt.Errorf("secret %s has no key %q", reference, secretKey)
+	}
+
+	return key, nil
+}
+
+// Auth returns the credentials GCP clients are created with, the key held by the credentials or
+// the service account they impersonate, using their key if they hold one or the operator itself.
+// The operator only impersonates the service accounts of the projects it generated credentials for
+func Auth(ctx context.Context, c client.Client, credentials *v1alpha1.GCPCredentials) (gcp.Auth, error) {
+	spec := credentials.Spec
+
+	if spec.ImpersonateServiceAccount == "" {
+		key, err := Get(ctx, c, credentials)
+
+		return gcp.Auth{Key: key}, err
+	}
+
+	auth := gcp.Auth{Impersonate: spec.ImpersonateServiceAccount}
+
+	if !HasKey(credentials) {
+		account, err := OwnServiceAccount(ctx, c, credentials)
+
+		if err != nil {
+			return auth, err
+		}
+
+		if account != spec.ImpersonateServiceAccount {
+			return auth, errors.New(ImpersonationNotAllowed)
+		}
+
+		return auth, nil
+	}
+
+	key, err := Get(ctx, c, credentials)
+	auth.Key = key
+
+	return auth, err
+}
+
+// HasKey checks if the credentials hold a key rather than only impersonating a service account
+func HasKey(credentials *v1alpha1.GCPCredentials) bool {
+	return credentials.Spec.Key != "" || credentials.Spec.KeyRef != nil
+}
+
+// NewSecret returns a Secret holding the base64 encoded JSON service account key as
+// returned by the IAM api
+func NewSecret(name, namespace, encodedKey string) (*corev1.Secret, error) {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			DefaultSecretKey: key,
+		},
+	}, nil
+}
+
+// Name returns the resource name of the service account key held in a JSON key
+func Name(key []byte) (string, error) {
+	var payload struct {
+		PrivateKeyId string `json:"private_key_id"`
+		ClientEmail  string `json:"client_email"`
+	}
+
+	if err := json.Unmarshal(key, &payload); err != nil {
+		return "", err
+	}
+
+	return "projects/-/serviceAccounts/" + payload.ClientEmail + "/keys/" + payload.PrivateKeyId, nil
+}
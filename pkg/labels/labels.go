@@ -0,0 +1,172 @@
+package labels
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/appvia/gcp-operator/pkg/ownership"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// MaxLabels is the most labels GCP allows on a project
+const MaxLabels = 64
+
+// keyPattern is the format GCP requires of label keys
+var keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,62}$`)
+
+// valuePattern is the format GCP requires of label values
+var valuePattern = regexp.MustCompile(`^[a-z0-9_-]{0,63}$`)
+
+// Validate checks the labels can be set on a GCP project, reporting every invalid label
+func Validate(labels map[string]string) error {
+	var problems []string
+
+	for _, k := range Keys(labels) {
+		if !keyPattern.MatchString(k) {
+			problems = append(problems, fmt.Sprintf("key %q must start with a lowercase letter and have at most 63 lowercase letters, digits, dashes or underscores", k))
+		}
+		if !valuePattern.MatchString(labels[k]) {
+			problems = append(problems, fmt.Sprintf("value %q of %s must have at most 63 lowercase letters, digits, dashes or underscores", labels[k], k))
+		}
+	}
+
+	if len(labels) > MaxLabels {
+		problems = append(problems, fmt.Sprintf("a project can have at most %d labels, found %d", MaxLabels, len(labels)))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid labels: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Desired returns the labels to set on a project, those in the spec overridden by the labels of
+// the namespace with the given keys. The reserved labels are those the operator sets itself, they
+// cannot be used and count towards the limit. Only the labels in the spec must be valid: the
+// values of namespace labels are converted to ones GCP accepts, and namespace labels which still
+// cannot be set are skipped and described in skipped
+func Desired(ctx context.Context, reader client.Reader, namespace string, keys []string, spec, reserved map[string]string) (desired map[string]string, skipped []string, err error) {
+	desired = make(map[string]string)
+	for k, v := range spec {
+		if _, found := reserved[k]; found {
+			return nil, nil, fmt.Errorf("label %s is reserved for the operator", k)
+		}
+		desired[k] = v
+	}
+
+	all := make(map[string]string)
+	for k, v := range desired {
+		all[k] = v
+	}
+	for k, v := range reserved {
+		all[k] = v
+	}
+
+	if err := Validate(all); err != nil {
+		return nil, nil, err
+	}
+
+	if len(keys) == 0 {
+		return desired, nil, nil
+	}
+
+	ns := &corev1.Namespace{}
+
+	if err := reader.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return nil, nil, err
+	}
+
+	for _, k := range keys {
+		v, found := ns.Labels[k]
+		if !found {
+			continue
+		}
+
+		_, isReserved := reserved[k]
+		_, isSet := all[k]
+
+		switch {
+		case !keyPattern.MatchString(k):
+			skipped = append(skipped, fmt.Sprintf("namespace label %s is not a valid GCP label key", k))
+		case isReserved:
+			skipped = append(skipped, fmt.Sprintf("namespace label %s is reserved for the operator", k))
+		case !isSet && len(all) >= MaxLabels:
+			skipped = append(skipped, fmt.Sprintf("namespace label %s would exceed the limit of %d labels", k, MaxLabels))
+		default:
+			desired[k] = ownership.LabelValue(v)
+			all[k] = desired[k]
+		}
+	}
+
+	return desired, skipped, nil
+}
+
+// Apply sets the desired labels and removes those previously set which are no longer desired,
+// returning true if the labels changed
+func Apply(labels *map[string]string, desired map[string]string, previous []string) bool {
+	if *labels == nil {
+		*labels = make(map[string]string)
+	}
+
+	changed := false
+	for _, k := range previous {
+		if _, found := desired[k]; found {
+			continue
+		}
+		if _, found := (*labels)[k]; found {
+			delete(*labels, k)
+			changed = true
+		}
+	}
+
+	for k, v := range desired {
+		if current, found := (*labels)[k]; !found || current != v {
+			(*labels)[k] = v
+			changed = true
+		}
+	}
+	return changed
+}
+
+// Diff describes how the labels differ from the desired labels, including any labels previously
+// set which are no longer desired
+func Diff(labels, desired map[string]string, previous []string) []string {
+	var found []string
+
+	for _, k := range Keys(desired) {
+		current, set := labels[k]
+		switch {
+		case !set:
+			found = append(found, fmt.Sprintf("label %s is missing, expected %q", k, desired[k]))
+		case current != desired[k]:
+			found = append(found, fmt.Sprintf("label %s is %q, expected %q", k, current, desired[k]))
+		}
+	}
+
+	for _, k := range previous {
+		if _, wanted := desired[k]; wanted {
+			continue
+		}
+		if _, set := labels[k]; set {
+			found = append(found, fmt.Sprintf("label %s is no longer wanted", k))
+		}
+	}
+
+	return found
+}
+
+// Keys returns the keys of the labels, sorted
+func Keys(labels map[string]string) []string {
+	var keys []string
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,211 @@
+package labels
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDesired(t *testing.T) {
+	reserved := map[string]string{"managed-by": "gcp-operator"}
+
+	tests := []struct {
+		name      string
+		keys      []string
+		namespace map[string]string
+		spec      map[string]string
+		expected  map[string]string
+		skipped   int
+		err       bool
+	}{
+		{
+			name:     "spec only",
+			spec:     map[string]string{"team": "payments"},
+			expected: map[string]string{"team": "payments"},
+		},
+		{
+			name:      "namespace overrides the spec",
+			keys:      []string{"team"},
+			namespace: map[string]string{"team": "billing"},
+			spec:      map[string]string{"team": "payments"},
+			expected:  map[string]string{"team": "billing"},
+		},
+		{
+			name:      "namespace values are converted",
+			keys:      []string{"owner"},
+			namespace: map[string]string{"owner": "Jane.Doe"},
+			expected:  map[string]string{"owner": "jane_doe"},
+		},
+		{
+			name:      "invalid namespace keys are skipped",
+			keys:      []string{"app.kubernetes.io/team", "team"},
+			namespace: map[string]string{"app.kubernetes.io/team": "payments", "team": "payments"},
+			expected:  map[string]string{"team": "payments"},
+			skipped:   1,
+		},
+		{
+			name:      "reserved namespace keys are skipped",
+			keys:      []string{"managed-by"},
+			namespace: map[string]string{"managed-by": "someone"},
+			expected:  map[string]string{},
+			skipped:   1,
+		},
+		{
+			name:      "missing namespace labels are ignored",
+			keys:      []string{"team"},
+			namespace: map[string]string{},
+			expected:  map[string]string{},
+		},
+		{
+			name: "invalid spec labels fail",
+			spec: map[string]string{"Team": "payments"},
+			err:  true,
+		},
+		{
+			name: "reserved spec labels fail",
+			spec: map[string]string{"managed-by": "someone"},
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := fake.NewFakeClientWithScheme(scheme.Scheme, &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "team", Labels: test.namespace},
+			})
+
+			desired, skipped, err := Desired(context.TODO(), reader, "team", test.keys, test.spec, reserved)
+
+			if test.err {
+				if err == nil {
+					t.Errorf("expected an error, got %v", desired)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(desired, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, desired)
+			}
+			if len(skipped) != test.skipped {
+				t.Errorf("expected %d labels to be skipped, got %v", test.skipped, skipped)
+			}
+		})
+	}
+}
+
+func TestDesiredSkipsNamespaceLabelsBeyondTheLimit(t *testing.T) {
+	spec := make(map[string]string)
+	for i := 0; i < MaxLabels-1; i++ {
+		spec[fmt.Sprintf("label-%d", i)] = "value"
+	}
+
+	reader := fake.NewFakeClientWithScheme(scheme.Scheme, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", Labels: map[string]string{"team": "payments"}},
+	})
+
+	desired, skipped, err := Desired(context.TODO(), reader, "team", []string{"team"}, spec, map[string]string{"managed-by": "gcp-operator"})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, found := desired["team"]; found {
+		t.Error("the namespace label was set beyond the limit")
+	}
+	if len(skipped) != 1 {
+		t.Errorf("expected the namespace label to be skipped, got %v", skipped)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tooMany := make(map[string]string)
+	for i := 0; i <= MaxLabels; i++ {
+		tooMany[fmt.Sprintf("label-%d", i)] = "x"
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		valid  bool
+	}{
+		{name: "none", valid: true},
+		{name: "valid", labels: map[string]string{"team": "a", "cost_center": "", "env-1": "prod_1"}, valid: true},
+		{name: "uppercase key", labels: map[string]string{"Team": "a"}},
+		{name: "key starting with a digit", labels: map[string]string{"1team": "a"}},
+		{name: "uppercase value", labels: map[string]string{"team": "A"}},
+		{name: "value with a dot", labels: map[string]string{"team": "a.b"}},
+		{name: "long value", labels: map[string]string{"team": fmt.Sprintf("%064d", 0)}},
+		{name: "too many", labels: tooMany},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := Validate(test.labels); (err == nil) != test.valid {
+				t.Errorf("expected valid %t, got %v", test.valid, err)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		desired  map[string]string
+		previous []string
+		expected map[string]string
+		changed  bool
+	}{
+		{name: "unchanged", labels: map[string]string{"team": "a"}, desired: map[string]string{"team": "a"}, previous: []string{"team"}, expected: map[string]string{"team": "a"}},
+		{name: "added", desired: map[string]string{"team": "a"}, expected: map[string]string{"team": "a"}, changed: true},
+		{name: "updated", labels: map[string]string{"team": "a"}, desired: map[string]string{"team": "b"}, previous: []string{"team"}, expected: map[string]string{"team": "b"}, changed: true},
+		{name: "removed", labels: map[string]string{"team": "a", "env": "prod"}, desired: map[string]string{"team": "a"}, previous: []string{"team", "env"}, expected: map[string]string{"team": "a"}, changed: true},
+		{name: "others kept", labels: map[string]string{"team": "a", "owner": "me"}, desired: map[string]string{"team": "a"}, previous: []string{"team"}, expected: map[string]string{"team": "a", "owner": "me"}},
+		{name: "removed already", labels: map[string]string{"team": "a"}, desired: map[string]string{"team": "a"}, previous: []string{"team", "env"}, expected: map[string]string{"team": "a"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			labels := test.labels
+
+			changed := Apply(&labels, test.desired, test.previous)
+
+			if changed != test.changed {
+				t.Errorf("expected changed %t, got %t", test.changed, changed)
+			}
+			if !reflect.DeepEqual(labels, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, labels)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		desired  map[string]string
+		previous []string
+		expected []string
+	}{
+		{name: "in sync", labels: map[string]string{"team": "a", "owner": "me"}, desired: map[string]string{"team": "a"}, previous: []string{"team"}},
+		{name: "missing", desired: map[string]string{"team": "a"}, expected: []string{`label team is missing, expected "a"`}},
+		{name: "changed", labels: map[string]string{"team": "b"}, desired: map[string]string{"team": "a"}, expected: []string{`label team is "b", expected "a"`}},
+		{name: "no longer wanted", labels: map[string]string{"env": "prod"}, previous: []string{"env"}, expected: []string{"label env is no longer wanted"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := Diff(test.labels, test.desired, test.previous); !reflect.DeepEqual(diff, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, diff)
+			}
+		})
+	}
+}
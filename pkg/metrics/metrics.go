@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/conditions"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// APIRequests counts the calls to the google apis by service, method and status code
+	APIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gcp_operator_api_requests_total",
+		Help: "Number of calls to the google apis by service, method and status code, after any retries",
+	}, []string{"service", "method", "code"})
+
+	// APIRequestDuration observes how long the calls to the google apis take
+	APIRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gcp_operator_api_request_duration_seconds",
+		Help:    "Duration of the calls to the google apis by service and method, including retries",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"service", "method"})
+
+	// StepDuration observes how long each run of a provisioning step takes
+	StepDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gcp_operator_step_duration_seconds",
+		Help:    "Duration of each run of a provisioning step by kind, step and result",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"kind", "step", "result"})
+
+	// OperationDuration observes how long the long running operations take to complete
+	OperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gcp_operator_operation_duration_seconds",
+		Help:    "Time from starting a long running operation until it was seen to complete, by service and step",
+		Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200},
+	}, []string{"service", "step"})
+
+	// OperationFailures counts the long running operations which completed with an error
+	OperationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gcp_operator_operation_failures_total",
+		Help: "Number of long running operations which completed with an error, by service and step",
+	}, []string{"service", "step"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(APIRequests, APIRequestDuration, StepDuration, OperationDuration, OperationFailures)
+}
+
+// ObserveStep records a run of a provisioning step
+func ObserveStep(kind, step string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	StepDuration.WithLabelValues(kind, step, result).Observe(time.Since(start).Seconds())
+}
+
+// ObserveOperation records the completion of a long running operation
+func ObserveOperation(op v1alpha1.Operation) {
+	OperationDuration.WithLabelValues(op.Service, op.Step).Observe(time.Since(op.StartTime.Time).Seconds())
+}
+
+// ObserveOperationFailure records a long running operation which completed with an error
+func ObserveOperationFailure(op v1alpha1.Operation) {
+	OperationFailures.WithLabelValues(op.Service, op.Step).Inc()
+}
+
+// projectsDesc describes the number of projects by Ready condition
+var projectsDesc = prometheus.NewDesc(
+	"gcp_operator_projects",
+	"Number of projects by kind and the status of their Ready condition",
+	[]string{"kind", "ready"}, nil,
+)
+
+// projectsCollector counts the projects by Ready condition from the cache when scraped
+type projectsCollector struct {
+	client client.Reader
+}
+
+// RegisterProjects registers the gauges of projects by Ready condition, read through the client
+func RegisterProjects(c client.Reader) error {
+	return metrics.Registry.Register(&projectsCollector{client: c})
+}
+
+func (p *projectsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- projectsDesc
+}
+
+func (p *projectsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx := context.Background()
+
+	projects := &v1alpha1.GCPProjectList{}
+	if err := p.client.List(ctx, projects); err == nil {
+		var statuses [][]v1alpha1.Condition
+		for _, project := range projects.Items {
+			statuses = append(statuses, project.Status.Conditions)
+		}
+		collect(ch, "GCPProject", statuses)
+	}
+
+	adminProjects := &v1alpha1.GCPAdminProjectList{}
+	if err := p.client.List(ctx, adminProjects); err == nil {
+		var statuses [][]v1alpha1.Condition
+		for _, project := range adminProjects.Items {
+			statuses = append(statuses, project.Status.Conditions)
+		}
+		collect(ch, "GCPAdminProject", statuses)
+	}
+}
+
+// collect sends the number of resources of the kind for each status of the Ready condition
+func collect(ch chan<- prometheus.Metric, kind string, statuses [][]v1alpha1.Condition) {
+	counts := map[v1alpha1.ConditionStatus]int{
+		v1alpha1.ConditionTrue:    0,
+		v1alpha1.ConditionFalse:   0,
+		v1alpha1.ConditionUnknown: 0,
+	}
+
+	for _, c := range statuses {
+		status := v1alpha1.ConditionUnknown
+		if ready := conditions.Get(c, v1alpha1.ReadyCondition); ready != nil {
+			status = ready.Status
+		}
+		counts[status]++
+	}
+
+	for status, count := range counts {
+		ch <- prometheus.MustNewConstMetric(projectsDesc, prometheus.GaugeValue, float64(count), kind, string(status))
+	}
+}
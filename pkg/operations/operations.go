@@ -0,0 +1,63 @@
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ErrFailed is wrapped by the errors of operations which failed or did not complete in time
+var ErrFailed = errors.New("operation failed")
+
+// Poller checks once whether an operation has completed, returning the error it failed with in
+// failure and any error checking it in err
+type Poller func(op v1alpha1.Operation) (done bool, failure error, err error)
+
+// New returns a record of a long running operation started now
+func New(name, service, step string) v1alpha1.Operation {
+	return v1alpha1.Operation{
+		Name:      name,
+		Service:   service,
+		Step:      step,
+		StartTime: metav1.Now(),
+	}
+}
+
+// Poll checks each of the operations and returns those still pending. An error wrapping ErrFailed
+// is returned if an operation failed or has been running for longer than the timeout, along with
+// the operations still pending and those after it which were not checked. When an operation cannot
+// be checked it is kept pending with the rest and the error of the check is returned
+func Poll(ops []v1alpha1.Operation, timeout time.Duration, poll Poller) (pending []v1alpha1.Operation, err error) {
+	for i, op := range ops {
+		done, failure, err := poll(op)
+
+		if err != nil {
+			return append(pending, ops[i:]...), fmt.Errorf("failed to check operation %s (%s): %w", op.Name, op.Step, err)
+		}
+
+		if failure != nil {
+			return append(pending, ops[i+1:]...), fmt.Errorf("%w: %s (%s): %v", ErrFailed, op.Name, op.Step, failure)
+		}
+
+		if done {
+			continue
+		}
+
+		if time.Since(op.StartTime.Time) > timeout {
+			return append(pending, ops[i+1:]...), fmt.Errorf("%w: %s (%s) did not complete within %s", ErrFailed, op.Name, op.Step, timeout)
+		}
+
+		pending = append(pending, op)
+	}
+
+	return pending, nil
+}
+
+// IsFailed checks if the error reports an operation which failed rather than one which could not
+// be checked
+func IsFailed(err error) bool {
+	return errors.Is(err, ErrFailed)
+}
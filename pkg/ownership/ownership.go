@@ -0,0 +1,247 @@
+package ownership
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The labels stamped on the GCP projects managed by the operator
+const (
+	// ManagedByLabel marks the project as managed by the operator
+	ManagedByLabel = "managed-by"
+	// ManagedByValue is the value of the managed-by label
+	ManagedByValue = "gcp-operator"
+	// ClusterLabel is the ID of the cluster the managing resource lives in
+	ClusterLabel = "gcp-operator-cluster"
+	// NamespaceLabel is the namespace of the managing resource
+	NamespaceLabel = "gcp-operator-namespace"
+	// NameLabel is the name of the managing resource
+	NameLabel = "gcp-operator-name"
+	// UIDLabel is the UID of the managing resource
+	UIDLabel = "gcp-operator-uid"
+)
+
+// maxLabelLength is the longest value GCP accepts for a label
+const maxLabelLength = 63
+
+// ErrUnmanaged is returned for an existing project which the operator did not create and has
+// not been asked to adopt
+var ErrUnmanaged = errors.New("the project already exists and is not managed by the operator, set spec.adoptionPolicy to Adopt to take it over")
+
+// ErrConflict is wrapped by the errors returned for projects managed by another resource
+var ErrConflict = errors.New("the project is managed by another resource")
+
+// clusterIDPattern is the format GCP requires of label values
+var clusterIDPattern = regexp.MustCompile(`^[a-z0-9_-]{0,63}$`)
+
+// invalidLabelCharacters are the characters GCP does not accept in label values
+var invalidLabelCharacters = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// ValidateClusterID checks the cluster ID can be used as a GCP label value
+func ValidateClusterID(clusterID string) error {
+	if !clusterIDPattern.MatchString(clusterID) {
+		return fmt.Errorf("cluster ID %q must be at most 63 lowercase letters, digits, dashes or underscores", clusterID)
+	}
+	return nil
+}
+
+// LabelValue converts the value to one GCP accepts, replacing invalid characters. Values which
+// are too long are truncated and suffixed with a hash of the full value so they stay distinct
+func LabelValue(value string) string {
+	converted := invalidLabelCharacters.ReplaceAllString(strings.ToLower(value), "_")
+
+	if len(converted) <= maxLabelLength {
+		return converted
+	}
+
+	sum := sha256.Sum256([]byte(value))
+	hash := hex.EncodeToString(sum[:])[:8]
+
+	return converted[:maxLabelLength-len(hash)-1] + "-" + hash
+}
+
+// Owner identifies the resource managing a project
+type Owner struct {
+	// ClusterID is the ID of the cluster the operator runs in, it may be empty
+	ClusterID string
+	// Object is the managing resource
+	Object metav1.Object
+	// Adopt takes over projects labelled for a resource in the cluster which no longer exists
+	Adopt bool
+	// Reader looks up the resources projects are labelled for, it is required to Adopt
+	Reader client.Reader
+}
+
+// Labels returns the labels identifying the owner
+func (o Owner) Labels() map[string]string {
+	labels := map[string]string{
+		ManagedByLabel: ManagedByValue,
+		NamespaceLabel: LabelValue(o.Object.GetNamespace()),
+		NameLabel:      LabelValue(o.Object.GetName()),
+		UIDLabel:       LabelValue(string(o.Object.GetUID())),
+	}
+	if o.ClusterID != "" {
+		labels[ClusterLabel] = o.ClusterID
+	}
+	return labels
+}
+
+// IsReserved checks if the label key is one of the labels set by the operator to mark ownership
+func IsReserved(key string) bool {
+	switch key {
+	case ManagedByLabel, ClusterLabel, NamespaceLabel, NameLabel, UIDLabel:
+		return true
+	}
+	return false
+}
+
+// IsManaged checks if the labels mark the project as managed by the operator
+func IsManaged(labels map[string]string) bool {
+	return labels[ManagedByLabel] == ManagedByValue
+}
+
+// Check returns an error wrapping ErrConflict if the labels show the project is managed by
+// another resource or another cluster. When adopting, projects left labelled for a resource in
+// this cluster which has since been deleted are not a conflict
+func (o Owner) Check(ctx context.Context, labels map[string]string) error {
+	if !IsManaged(labels) {
+		return nil
+	}
+
+	if cluster := labels[ClusterLabel]; cluster != o.ClusterID {
+		return fmt.Errorf("%w: it belongs to the operator in cluster %q", ErrConflict, cluster)
+	}
+
+	if labels[UIDLabel] != LabelValue(string(o.Object.GetUID())) {
+		if o.Adopt && o.Reader != nil {
+			deleted, err := o.deleted(ctx, labels[UIDLabel])
+
+			if err != nil || deleted {
+				return err
+			}
+		}
+
+		return fmt.Errorf("%w: it belongs to %s/%s with UID %s", ErrConflict, labels[NamespaceLabel], labels[NameLabel], labels[UIDLabel])
+	}
+
+	return nil
+}
+
+// IsOwner checks if the labels mark the project as managed by the owner
+func (o Owner) IsOwner(labels map[string]string) bool {
+	return IsManaged(labels) && labels[ClusterLabel] == o.ClusterID && labels[UIDLabel] == LabelValue(string(o.Object.GetUID()))
+}
+
+// deleted checks no GCPProject or GCPAdminProject in the cluster has the UID in the label
+func (o Owner) deleted(ctx context.Context, uid string) (bool, error) {
+	projects := &v1alpha1.GCPProjectList{}
+
+	if err := o.Reader.List(ctx, projects); err != nil {
+		return false, err
+	}
+
+	for _, item := range projects.Items {
+		if LabelValue(string(item.UID)) == uid {
+			return false, nil
+		}
+	}
+
+	adminProjects := &v1alpha1.GCPAdminProjectList{}
+
+	if err := o.Reader.List(ctx, adminProjects); err != nil {
+		return false, err
+	}
+
+	for _, item := range adminProjects.Items {
+		if LabelValue(string(item.UID)) == uid {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// IsConflict checks if the error reports a project managed by another resource or cluster
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+// Condition returns the Conflict condition reporting the result of verifying the ownership
+func Condition(err error, generation int64) v1alpha1.Condition {
+	condition := v1alpha1.Condition{
+		Type:               v1alpha1.ConflictCondition,
+		Status:             v1alpha1.ConditionFalse,
+		Reason:             "Owned",
+		Message:            "the project is not managed by another resource",
+		ObservedGeneration: generation,
+	}
+
+	if IsConflict(err) {
+		condition.Status = v1alpha1.ConditionTrue
+		condition.Reason = "OwnedElsewhere"
+		condition.Message = err.Error()
+	}
+
+	return condition
+}
+
+// Verify checks the project, if it exists, is not managed by another resource or cluster
+func (o Owner) Verify(ctx context.Context, projects gcp.Projects, projectId string) error {
+	project, err := o.get(ctx, projects, projectId)
+
+	if err != nil || project == nil {
+		return err
+	}
+
+	return o.Check(ctx, project.Labels)
+}
+
+// Owns checks the project exists and is labelled as managed by the owner
+func (o Owner) Owns(ctx context.Context, projects gcp.Projects, projectId string) (bool, error) {
+	project, err := o.get(ctx, projects, projectId)
+
+	if err != nil || project == nil {
+		return false, err
+	}
+
+	return o.IsOwner(project.Labels), nil
+}
+
+// get returns the project, or nil if it does not exist
+func (o Owner) get(ctx context.Context, projects gcp.Projects, projectId string) (*cloudresourcemanager.Project, error) {
+	// a missing project is reported as forbidden by get, so it is looked for first
+	exists, err := projects.Exists(ctx, projectId)
+
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	return projects.Get(ctx, projectId)
+}
+
+// Stamp adds the labels identifying the owner to the labels, returning true if any changed
+func (o Owner) Stamp(labels *map[string]string) bool {
+	if *labels == nil {
+		*labels = make(map[string]string)
+	}
+
+	changed := false
+	for k, v := range o.Labels() {
+		if (*labels)[k] != v {
+			(*labels)[k] = v
+			changed = true
+		}
+	}
+	return changed
+}
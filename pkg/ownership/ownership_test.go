@@ -0,0 +1,250 @@
+package ownership
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	gcpfake "github.com/appvia/gcp-operator/pkg/gcp/fake"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func init() {
+	// the fake client decodes with the client-go scheme, so the types are registered there
+	if err := v1alpha1.SchemeBuilder.AddToScheme(scheme.Scheme); err != nil {
+		panic(err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	owner := &v1alpha1.GCPProject{ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "demo", UID: "uid-owner"}}
+	other := &v1alpha1.GCPProject{ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "other", UID: "uid-other"}}
+	otherAdmin := &v1alpha1.GCPAdminProject{ObjectMeta: metav1.ObjectMeta{Namespace: "admin", Name: "admin", UID: "uid-admin"}}
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		adopt    bool
+		existing []runtime.Object
+		conflict bool
+	}{
+		{name: "unlabelled", labels: map[string]string{"team": "a"}},
+		{name: "owned", labels: Owner{Object: owner}.Labels()},
+		{name: "other cluster", labels: Owner{ClusterID: "elsewhere", Object: owner}.Labels(), adopt: true, conflict: true},
+		{name: "other resource", labels: Owner{Object: other}.Labels(), existing: []runtime.Object{other}, conflict: true},
+		{name: "other resource when adopting", labels: Owner{Object: other}.Labels(), adopt: true, existing: []runtime.Object{other}, conflict: true},
+		{name: "other admin project when adopting", labels: Owner{Object: otherAdmin}.Labels(), adopt: true, existing: []runtime.Object{otherAdmin}, conflict: true},
+		{name: "deleted resource", labels: Owner{Object: other}.Labels(), conflict: true},
+		{name: "deleted resource when adopting", labels: Owner{Object: other}.Labels(), adopt: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o := Owner{
+				Object: owner,
+				Adopt:  test.adopt,
+				Reader: fake.NewFakeClientWithScheme(scheme.Scheme, test.existing...),
+			}
+
+			err := o.Check(context.TODO(), test.labels)
+
+			if IsConflict(err) != test.conflict {
+				t.Errorf("expected conflict %t, got %v", test.conflict, err)
+			}
+			if err != nil && !IsConflict(err) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestLabelValue(t *testing.T) {
+	long := strings.Repeat("a", 70)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{name: "valid", value: "team-a_1", expected: "team-a_1"},
+		{name: "uppercase", value: "Team", expected: "team"},
+		{name: "invalid characters", value: "team.a/b", expected: "team_a_b"},
+		{name: "empty", value: "", expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value := LabelValue(test.value)
+
+			if value != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, value)
+			}
+			if len(value) > maxLabelLength {
+				t.Errorf("expected at most %d characters, got %d", maxLabelLength, len(value))
+			}
+		})
+	}
+
+	// long values are truncated and suffixed with a hash, so values differing only past the
+	// limit stay distinct
+	if value := LabelValue(long); len(value) != maxLabelLength || !strings.HasPrefix(value, strings.Repeat("a", 54)+"-") {
+		t.Errorf("expected the long value to be truncated and hashed, got %q", value)
+	}
+	if LabelValue(long+"b") == LabelValue(long+"c") {
+		t.Error("expected long values to be distinguished by their hash")
+	}
+}
+
+func TestValidateClusterID(t *testing.T) {
+	tests := []struct {
+		clusterID string
+		valid     bool
+	}{
+		{clusterID: "", valid: true},
+		{clusterID: "prod-eu_1", valid: true},
+		{clusterID: "Prod"},
+		{clusterID: "prod.eu"},
+		{clusterID: strings.Repeat("a", 64)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.clusterID, func(t *testing.T) {
+			if err := ValidateClusterID(test.clusterID); (err == nil) != test.valid {
+				t.Errorf("expected valid %t, got %v", test.valid, err)
+			}
+		})
+	}
+}
+
+func TestStamp(t *testing.T) {
+	owner := Owner{ClusterID: "prod", Object: &v1alpha1.GCPProject{ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "demo", UID: "uid-owner"}}}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		changed bool
+	}{
+		{name: "unlabelled", changed: true},
+		{name: "other labels", labels: map[string]string{"team": "a"}, changed: true},
+		{name: "stamped", labels: owner.Labels()},
+		{name: "other owner", labels: Owner{Object: &v1alpha1.GCPProject{ObjectMeta: metav1.ObjectMeta{UID: "uid-other"}}}.Labels(), changed: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			labels := test.labels
+
+			if changed := owner.Stamp(&labels); changed != test.changed {
+				t.Errorf("expected changed %t, got %t", test.changed, changed)
+			}
+			if !owner.IsOwner(labels) {
+				t.Errorf("expected the stamped labels to mark the owner, got %v", labels)
+			}
+		})
+	}
+}
+
+func TestIsOwner(t *testing.T) {
+	owner := Owner{ClusterID: "prod", Object: &v1alpha1.GCPProject{ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "demo", UID: "uid-owner"}}}
+
+	unmanaged := owner.Labels()
+	delete(unmanaged, ManagedByLabel)
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		owner  bool
+	}{
+		{name: "owned", labels: owner.Labels(), owner: true},
+		{name: "unlabelled", labels: map[string]string{"team": "a"}},
+		{name: "not managed", labels: unmanaged},
+		{name: "other cluster", labels: Owner{ClusterID: "dev", Object: owner.Object}.Labels()},
+		{name: "other resource", labels: Owner{ClusterID: "prod", Object: &v1alpha1.GCPProject{ObjectMeta: metav1.ObjectMeta{UID: "uid-other"}}}.Labels()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if isOwner := owner.IsOwner(test.labels); isOwner != test.owner {
+				t.Errorf("expected owner %t, got %t", test.owner, isOwner)
+			}
+		})
+	}
+}
+
+func TestCondition(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status v1alpha1.ConditionStatus
+		reason string
+	}{
+		{name: "owned", status: v1alpha1.ConditionFalse, reason: "Owned"},
+		{name: "other error", err: errors.New("unavailable"), status: v1alpha1.ConditionFalse, reason: "Owned"},
+		{name: "conflict", err: fmt.Errorf("%w: it belongs elsewhere", ErrConflict), status: v1alpha1.ConditionTrue, reason: "OwnedElsewhere"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			condition := Condition(test.err, 2)
+
+			if condition.Type != v1alpha1.ConflictCondition || condition.Status != test.status || condition.Reason != test.reason || condition.ObservedGeneration != 2 {
+				t.Errorf("expected %s %s with reason %s, got %+v", v1alpha1.ConflictCondition, test.status, test.reason, condition)
+			}
+		})
+	}
+}
+
+func TestVerifyAndOwns(t *testing.T) {
+	owner := Owner{Object: &v1alpha1.GCPProject{ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "demo", UID: "uid-owner"}}}
+	other := Owner{Object: &v1alpha1.GCPProject{ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "other", UID: "uid-other"}}}
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		missing  bool
+		conflict bool
+		owns     bool
+	}{
+		{name: "missing", missing: true},
+		{name: "unlabelled", labels: map[string]string{"team": "a"}},
+		{name: "owned", labels: owner.Labels(), owns: true},
+		{name: "other resource", labels: other.Labels(), conflict: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := gcpfake.New()
+			if !test.missing {
+				f.Projects["demo-project"] = &cloudresourcemanager.Project{ProjectId: "demo-project", Labels: test.labels}
+			}
+
+			c, err := f.New(context.TODO(), gcp.Auth{})
+			if err != nil {
+				t.Fatalf("failed to create the client: %v", err)
+			}
+
+			err = owner.Verify(context.TODO(), c.Projects, "demo-project")
+
+			if IsConflict(err) != test.conflict {
+				t.Errorf("expected conflict %t, got %v", test.conflict, err)
+			}
+
+			owns, err := owner.Owns(context.TODO(), c.Projects, "demo-project")
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if owns != test.owns {
+				t.Errorf("expected owns %t, got %t", test.owns, owns)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v1"
+)
+
+// DefaultBindings are applied when a project does not specify spec.iam
+var DefaultBindings = []v1alpha1.IAMBinding{
+	{Role: "roles/owner", Members: []string{v1alpha1.ServiceAccountMember}},
+}
+
+// Bindings returns the bindings requested in the spec with the service account placeholder
+// replaced, falling back to the defaults
+func Bindings(spec *v1alpha1.IAMPolicy, serviceAccountEmail string) []v1alpha1.IAMBinding {
+	requested := DefaultBindings
+	if spec != nil {
+		requested = spec.Bindings
+	}
+
+	// bindings for the same role are combined
+	var bindings []v1alpha1.IAMBinding
+	index := make(map[string]int)
+
+	for _, b := range requested {
+		i, found := index[b.Role]
+		if !found {
+			i = len(bindings)
+			index[b.Role] = i
+			bindings = append(bindings, v1alpha1.IAMBinding{Role: b.Role})
+		}
+
+		for _, m := range b.Members {
+			if m == v1alpha1.ServiceAccountMember {
+				m = "serviceAccount:" + serviceAccountEmail
+			}
+			bindings[i].Members = append(bindings[i].Members, m)
+		}
+	}
+	return bindings
+}
+
+// Mode returns the mode the bindings are applied with
+func Mode(spec *v1alpha1.IAMPolicy) v1alpha1.IAMPolicyMode {
+	if spec == nil || spec.Mode == "" {
+		return v1alpha1.AdditiveMode
+	}
+	return spec.Mode
+}
+
+// Managed returns the roles the bindings make the operator responsible for, which are those
+// applied authoritatively
+func Managed(bindings []v1alpha1.IAMBinding, mode v1alpha1.IAMPolicyMode) []string {
+	if mode != v1alpha1.AuthoritativeMode {
+		return nil
+	}
+
+	var roles []string
+	for _, b := range bindings {
+		roles = append(roles, b.Role)
+	}
+	return unique(roles)
+}
+
+// Dropped returns the roles previously managed which are no longer in the bindings
+func Dropped(managed []string, bindings []v1alpha1.IAMBinding) []string {
+	wanted := make(map[string]bool)
+	for _, b := range bindings {
+		wanted[b.Role] = true
+	}
+
+	var dropped []string
+	for _, role := range unique(managed) {
+		if !wanted[role] {
+			dropped = append(dropped, role)
+		}
+	}
+	return dropped
+}
+
+// Merge applies the bindings to the policy and removes every member of the dropped roles,
+// returning true if the policy was changed. Conditional bindings in the policy are never modified
+func Merge(policy *cloudresourcemanager.Policy, bindings []v1alpha1.IAMBinding, mode v1alpha1.IAMPolicyMode, dropped []string) bool {
+	changed := false
+
+	for _, role := range dropped {
+		for _, existing := range policy.Bindings {
+			if existing.Role == role && existing.Condition == nil && len(existing.Members) > 0 {
+				existing.Members = nil
+				changed = true
+			}
+		}
+	}
+
+	for _, b := range bindings {
+		var current *cloudresourcemanager.Binding
+		for _, existing := range policy.Bindings {
+			if existing.Role == b.Role && existing.Condition == nil {
+				current = existing
+				break
+			}
+		}
+
+		if current == nil {
+			current = &cloudresourcemanager.Binding{Role: b.Role}
+			policy.Bindings = append(policy.Bindings, current)
+		}
+
+		members := b.Members
+		if mode == v1alpha1.AdditiveMode {
+			members = append(append([]string{}, current.Members...), b.Members...)
+		}
+		members = unique(members)
+
+		if !equal(members, unique(current.Members)) {
+			current.Members = members
+			changed = true
+		}
+	}
+
+	// GCP rejects bindings without members
+	var bindingsWithMembers []*cloudresourcemanager.Binding
+	for _, b := range policy.Bindings {
+		if len(b.Members) > 0 {
+			bindingsWithMembers = append(bindingsWithMembers, b)
+		}
+	}
+	policy.Bindings = bindingsWithMembers
+
+	return changed
+}
+
+// Diff describes how the policy differs from the bindings: the members missing from each role,
+// in authoritative mode the members which should be removed, and the dropped roles still granted
+func Diff(policy *cloudresourcemanager.Policy, bindings []v1alpha1.IAMBinding, mode v1alpha1.IAMPolicyMode, dropped []string) []string {
+	var diff []string
+
+	for _, role := range dropped {
+		for _, existing := range policy.Bindings {
+			if existing.Role == role && existing.Condition == nil && len(existing.Members) > 0 {
+				diff = append(diff, fmt.Sprintf("%s is no longer wanted", role))
+				break
+			}
+		}
+	}
+
+	for _, b := range bindings {
+		current := make(map[string]bool)
+		for _, existing := range policy.Bindings {
+			if existing.Role == b.Role && existing.Condition == nil {
+				for _, m := range existing.Members {
+					current[m] = true
+				}
+			}
+		}
+
+		desired := make(map[string]bool)
+		for _, m := range unique(b.Members) {
+			desired[m] = true
+			if !current[m] {
+				diff = append(diff, fmt.Sprintf("%s is missing member %s", b.Role, m))
+			}
+		}
+
+		if mode == v1alpha1.AuthoritativeMode {
+			var unexpected []string
+			for m := range current {
+				if !desired[m] {
+					unexpected = append(unexpected, m)
+				}
+			}
+			for _, m := range unique(unexpected) {
+				diff = append(diff, fmt.Sprintf("%s has unexpected member %s", b.Role, m))
+			}
+		}
+	}
+
+	return diff
+}
+
+// Apply merges the bindings into the project policy and clears the dropped roles, only writing
+// the policy back if it changed, and returns true if it was. The etag read with the policy is
+// sent with the update so concurrent changes are rejected rather than overwritten
+func Apply(ctx context.Context, projects gcp.Projects, projectId string, bindings []v1alpha1.IAMBinding, mode v1alpha1.IAMPolicyMode, dropped []string) (bool, error) {
+	current, err := projects.GetIamPolicy(ctx, projectId)
+
+	if err != nil {
+		return false, err
+	}
+
+	if !Merge(current, bindings, mode, dropped) {
+		return false, nil
+	}
+
+	return true, projects.SetIamPolicy(ctx, projectId, current)
+}
+
+// unique returns the members sorted and without duplicates
+func unique(members []string) []string {
+	seen := make(map[string]bool)
+
+	var result []string
+	for _, m := range members {
+		if !seen[m] {
+			seen[m] = true
+			result = append(result, m)
+		}
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// equal checks if the sorted lists are the same
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
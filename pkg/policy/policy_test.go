@@ -0,0 +1,250 @@
+package policy
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/gcp/fake"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v1"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  []*cloudresourcemanager.Binding
+		bindings []v1alpha1.IAMBinding
+		mode     v1alpha1.IAMPolicyMode
+		dropped  []string
+		expected []*cloudresourcemanager.Binding
+		changed  bool
+	}{
+		{
+			name:     "adds a new role",
+			bindings: []v1alpha1.IAMBinding{{Role: "roles/viewer", Members: []string{"user:a@example.com"}}},
+			mode:     v1alpha1.AdditiveMode,
+			expected: []*cloudresourcemanager.Binding{{Role: "roles/viewer", Members: []string{"user:a@example.com"}}},
+			changed:  true,
+		},
+		{
+			name:     "additive keeps other members",
+			current:  []*cloudresourcemanager.Binding{{Role: "roles/viewer", Members: []string{"user:b@example.com"}}},
+			bindings: []v1alpha1.IAMBinding{{Role: "roles/viewer", Members: []string{"user:a@example.com"}}},
+			mode:     v1alpha1.AdditiveMode,
+			expected: []*cloudresourcemanager.Binding{{Role: "roles/viewer", Members: []string{"user:a@example.com", "user:b@example.com"}}},
+			changed:  true,
+		},
+		{
+			name:     "authoritative replaces other members",
+			current:  []*cloudresourcemanager.Binding{{Role: "roles/viewer", Members: []string{"user:b@example.com"}}},
+			bindings: []v1alpha1.IAMBinding{{Role: "roles/viewer", Members: []string{"user:a@example.com"}}},
+			mode:     v1alpha1.AuthoritativeMode,
+			expected: []*cloudresourcemanager.Binding{{Role: "roles/viewer", Members: []string{"user:a@example.com"}}},
+			changed:  true,
+		},
+		{
+			name:     "unchanged",
+			current:  []*cloudresourcemanager.Binding{{Role: "roles/viewer", Members: []string{"user:a@example.com"}}},
+			bindings: []v1alpha1.IAMBinding{{Role: "roles/viewer", Members: []string{"user:a@example.com"}}},
+			mode:     v1alpha1.AuthoritativeMode,
+			expected: []*cloudresourcemanager.Binding{{Role: "roles/viewer", Members: []string{"user:a@example.com"}}},
+		},
+		{
+			name: "clears dropped roles",
+			current: []*cloudresourcemanager.Binding{
+				{Role: "roles/editor", Members: []string{"user:b@example.com"}},
+				{Role: "roles/viewer", Members: []string{"user:a@example.com"}},
+			},
+			bindings: []v1alpha1.IAMBinding{{Role: "roles/viewer", Members: []string{"user:a@example.com"}}},
+			mode:     v1alpha1.AuthoritativeMode,
+			dropped:  []string{"roles/editor"},
+			expected: []*cloudresourcemanager.Binding{{Role: "roles/viewer", Members: []string{"user:a@example.com"}}},
+			changed:  true,
+		},
+		{
+			name: "leaves conditional bindings of dropped roles",
+			current: []*cloudresourcemanager.Binding{
+				{Role: "roles/editor", Members: []string{"user:b@example.com"}, Condition: &cloudresourcemanager.Expr{Expression: "true"}},
+			},
+			mode:     v1alpha1.AuthoritativeMode,
+			dropped:  []string{"roles/editor"},
+			expected: []*cloudresourcemanager.Binding{{Role: "roles/editor", Members: []string{"user:b@example.com"}, Condition: &cloudresourcemanager.Expr{Expression: "true"}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy := &cloudresourcemanager.Policy{Bindings: test.current}
+
+			changed := Merge(policy, test.bindings, test.mode, test.dropped)
+
+			if changed != test.changed {
+				t.Errorf("expected changed to be %t", test.changed)
+			}
+			if !reflect.DeepEqual(policy.Bindings, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, policy.Bindings)
+			}
+		})
+	}
+}
+
+func TestDropped(t *testing.T) {
+	bindings := []v1alpha1.IAMBinding{{Role: "roles/viewer"}}
+
+	if dropped := Dropped([]string{"roles/viewer", "roles/editor"}, bindings); !reflect.DeepEqual(dropped, []string{"roles/editor"}) {
+		t.Errorf("expected roles/editor to be dropped, got %v", dropped)
+	}
+	if dropped := Dropped(nil, bindings); len(dropped) != 0 {
+		t.Errorf("expected nothing to be dropped, got %v", dropped)
+	}
+}
+
+func TestManaged(t *testing.T) {
+	bindings := []v1alpha1.IAMBinding{{Role: "roles/viewer"}, {Role: "roles/editor"}, {Role: "roles/viewer"}}
+
+	if managed := Managed(bindings, v1alpha1.AuthoritativeMode); !reflect.DeepEqual(managed, []string{"roles/editor", "roles/viewer"}) {
+		t.Errorf("expected both roles to be managed, got %v", managed)
+	}
+	if managed := Managed(bindings, v1alpha1.AdditiveMode); managed != nil {
+		t.Errorf("expected no roles to be managed in additive mode, got %v", managed)
+	}
+}
+
+func TestBindings(t *testing.T) {
+	email := "robot-account@demo-project.iam.gserviceaccount.com"
+
+	tests := []struct {
+		name     string
+		spec     *v1alpha1.IAMPolicy
+		expected []v1alpha1.IAMBinding
+	}{
+		{
+			name:     "defaults",
+			expected: []v1alpha1.IAMBinding{{Role: "roles/owner", Members: []string{"serviceAccount:" + email}}},
+		},
+		{
+			name: "placeholder replaced",
+			spec: &v1alpha1.IAMPolicy{Bindings: []v1alpha1.IAMBinding{
+				{Role: "roles/editor", Members: []string{v1alpha1.ServiceAccountMember, "group:team@example.com"}},
+			}},
+			expected: []v1alpha1.IAMBinding{{Role: "roles/editor", Members: []string{"serviceAccount:" + email, "group:team@example.com"}}},
+		},
+		{
+			name: "roles combined",
+			spec: &v1alpha1.IAMPolicy{Bindings: []v1alpha1.IAMBinding{
+				{Role: "roles/viewer", Members: []string{"user:a@example.com"}},
+				{Role: "roles/editor", Members: []string{"user:b@example.com"}},
+				{Role: "roles/viewer", Members: []string{"user:c@example.com"}},
+			}},
+			expected: []v1alpha1.IAMBinding{
+				{Role: "roles/viewer", Members: []string{"user:a@example.com", "user:c@example.com"}},
+				{Role: "roles/editor", Members: []string{"user:b@example.com"}},
+			},
+		},
+		{
+			name: "no bindings",
+			spec: &v1alpha1.IAMPolicy{Mode: v1alpha1.AuthoritativeMode},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if bindings := Bindings(test.spec, email); !reflect.DeepEqual(bindings, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, bindings)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	viewer := []v1alpha1.IAMBinding{{Role: "roles/viewer", Members: []string{"user:a@example.com"}}}
+
+	tests := []struct {
+		name     string
+		current  []*cloudresourcemanager.Binding
+		mode     v1alpha1.IAMPolicyMode
+		dropped  []string
+		expected []string
+	}{
+		{
+			name:    "in sync",
+			current: []*cloudresourcemanager.Binding{{Role: "roles/viewer", Members: []string{"user:a@example.com"}}},
+			mode:    v1alpha1.AuthoritativeMode,
+		},
+		{
+			name:     "missing member",
+			mode:     v1alpha1.AdditiveMode,
+			expected: []string{"roles/viewer is missing member user:a@example.com"},
+		},
+		{
+			name:    "extra member when additive",
+			current: []*cloudresourcemanager.Binding{{Role: "roles/viewer", Members: []string{"user:a@example.com", "user:b@example.com"}}},
+			mode:    v1alpha1.AdditiveMode,
+		},
+		{
+			name:     "extra member when authoritative",
+			current:  []*cloudresourcemanager.Binding{{Role: "roles/viewer", Members: []string{"user:a@example.com", "user:b@example.com"}}},
+			mode:     v1alpha1.AuthoritativeMode,
+			expected: []string{"roles/viewer has unexpected member user:b@example.com"},
+		},
+		{
+			name:     "conditional binding ignored",
+			current:  []*cloudresourcemanager.Binding{{Role: "roles/viewer", Members: []string{"user:a@example.com"}, Condition: &cloudresourcemanager.Expr{Expression: "true"}}},
+			mode:     v1alpha1.AdditiveMode,
+			expected: []string{"roles/viewer is missing member user:a@example.com"},
+		},
+		{
+			name: "dropped role still granted",
+			current: []*cloudresourcemanager.Binding{
+				{Role: "roles/viewer", Members: []string{"user:a@example.com"}},
+				{Role: "roles/editor", Members: []string{"user:b@example.com"}},
+			},
+			mode:     v1alpha1.AdditiveMode,
+			dropped:  []string{"roles/editor"},
+			expected: []string{"roles/editor is no longer wanted"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy := &cloudresourcemanager.Policy{Bindings: test.current}
+
+			if diff := Diff(policy, viewer, test.mode, test.dropped); !reflect.DeepEqual(diff, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, diff)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	bindings := []v1alpha1.IAMBinding{{Role: "roles/viewer", Members: []string{"user:a@example.com"}}}
+
+	f := fake.New()
+	f.Projects["demo-project"] = &cloudresourcemanager.Project{ProjectId: "demo-project"}
+	f.Policies["demo-project"] = &cloudresourcemanager.Policy{Etag: "0"}
+
+	c, err := f.New(context.TODO(), gcp.Auth{})
+	if err != nil {
+		t.Fatalf("failed to create the client: %v", err)
+	}
+
+	for i, expected := range []bool{true, false} {
+		changed, err := Apply(context.TODO(), c.Projects, "demo-project", bindings, v1alpha1.AdditiveMode, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if changed != expected {
+			t.Errorf("expected apply %d to report changed %t", i+1, expected)
+		}
+	}
+
+	// the policy is only written when it changed
+	if f.Calls["Projects.SetIamPolicy"] != 1 {
+		t.Errorf("expected the policy to be written once, got %d", f.Calls["Projects.SetIamPolicy"])
+	}
+	if diff := Diff(f.Policies["demo-project"], bindings, v1alpha1.AdditiveMode, nil); len(diff) != 0 {
+		t.Errorf("expected the policy to hold the bindings, got %v", diff)
+	}
+}
@@ -0,0 +1,109 @@
+package provisioner
+
+import (
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/ownership"
+	"github.com/appvia/gcp-operator/pkg/steps"
+	core "github.com/appvia/hub-apis/pkg/apis/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Object is a GCPProject or GCPAdminProject
+type Object interface {
+	metav1.Object
+	runtime.Object
+}
+
+// Spec is the part of the spec of a GCPProject or GCPAdminProject the steps provision
+type Spec struct {
+	ProjectName            string
+	ParentType             string
+	ParentId               string
+	BillingAccountName     string
+	ServiceAccountName     string
+	Services               []string
+	DisableRemovedServices bool
+	CredentialsPolicy      gcpv1alpha1.CredentialsPolicy
+	KeyRotation            *gcpv1alpha1.KeyRotation
+	// GeneratedProjectId is set when the project ID was generated from a prefix, so another is
+	// generated if it turns out to be taken
+	GeneratedProjectId bool
+	// Bindings are the role bindings to apply to the project policy, with the service account
+	// placeholder replaced
+	Bindings []gcpv1alpha1.IAMBinding
+	// Mode is the mode the bindings are applied with
+	Mode gcpv1alpha1.IAMPolicyMode
+}
+
+// Status refers to the fields of the status of a GCPProject or GCPAdminProject the steps update
+type Status struct {
+	Status          *core.Status
+	Operations      *[]gcpv1alpha1.Operation
+	Steps           *[]gcpv1alpha1.StepStatus
+	EnabledServices *[]string
+	Conditions      *[]gcpv1alpha1.Condition
+	LabelKeys       *[]string
+	CredentialsRef  **core.Ownership
+	Keys            *[]gcpv1alpha1.ServiceAccountKey
+	// ProjectId is the generated project ID, nil when the resource always gives one in the spec
+	ProjectId *string
+	// ManagedRoles are the roles applied authoritatively, nil when the resource does not track them
+	ManagedRoles *[]string
+}
+
+// Resource describes a GCPProject or GCPAdminProject to the steps
+type Resource struct {
+	// Object is the resource itself
+	Object Object
+	// Kind is the kind of the resource
+	Kind string
+	// ProjectId is the ID of the GCP project
+	ProjectId string
+	Spec      Spec
+	Status    Status
+}
+
+// Provisioner runs the provisioning steps shared by GCPProject and GCPAdminProject
+type Provisioner struct {
+	Resource
+
+	Client   client.Client
+	Scheme   *runtime.Scheme
+	GCP      *gcp.Client
+	Recorder record.EventRecorder
+	// Owner is the owner stamped on the project
+	Owner ownership.Owner
+	// Labels are the labels to set on the project from the spec and the namespace
+	Labels map[string]string
+}
+
+// Steps returns the provisioning steps in the order they run
+func (p *Provisioner) Steps() []steps.Step {
+	return []steps.Step{
+		{Name: gcpv1alpha1.CreateProjectStep, Run: p.createProject},
+		{Name: gcpv1alpha1.LinkBillingStep, Run: p.linkBilling},
+		{Name: gcpv1alpha1.EnableServicesStep, Run: p.enableServices, Continuous: true},
+		{Name: gcpv1alpha1.CreateServiceAccountStep, Run: p.createServiceAccount},
+		{Name: gcpv1alpha1.GrantPermissionsStep, Run: p.grantPermissions},
+		{Name: gcpv1alpha1.IssueCredentialsStep, Run: p.issueCredentials},
+	}
+}
+
+// StepNames returns the names of the provisioning steps in the order they run
+func StepNames() []string {
+	var names []string
+	for _, s := range (&Provisioner{}).Steps() {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// event records a normal event on the resource
+func (p *Provisioner) event(reason, messageFmt string, args ...interface{}) {
+	p.Recorder.Eventf(p.Object, corev1.EventTypeNormal, reason, messageFmt, args...)
+}
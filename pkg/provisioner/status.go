@@ -0,0 +1,102 @@
+package provisioner
+
+import (
+	"context"
+	"time"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/conditions"
+	"github.com/appvia/gcp-operator/pkg/events"
+	"github.com/appvia/gcp-operator/pkg/steps"
+	core "github.com/appvia/hub-apis/pkg/apis/core/v1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Reporter records the progress of the provisioning in the status of the resource
+type Reporter struct {
+	Client   client.Client
+	Recorder record.EventRecorder
+	Logger   logr.Logger
+	// OnUpdate is called with the error which ended the reconcile, if any, before the status is
+	// written, to set the conditions depending on it. It may be nil
+	OnUpdate func(err error)
+}
+
+// WaitForOperations records the operations in the status and requeues the request to poll them
+func (r *Reporter) WaitForOperations(ctx context.Context, resource Resource, pollInterval time.Duration, ops ...gcpv1alpha1.Operation) (reconcile.Result, error) {
+	// Set status to pending
+	*resource.Status.Status = core.PendingStatus
+	*resource.Status.Operations = append(*resource.Status.Operations, ops...)
+	r.updating(nil)
+
+	if err := r.UpdateStatus(ctx, resource); err != nil {
+		r.Logger.Error(err, "failed to update the resource status")
+
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{RequeueAfter: pollInterval}, nil
+}
+
+// Conflict reports the project is managed by another resource or cluster, and requeues the
+// request to check again later without running any of the steps
+func (r *Reporter) Conflict(ctx context.Context, resource Resource, err error, interval time.Duration) (reconcile.Result, error) {
+	r.Logger.Info("The project is managed elsewhere, leaving it untouched", "Reason", err.Error())
+
+	r.Recorder.Event(resource.Object, corev1.EventTypeWarning, events.Conflict, err.Error())
+
+	*resource.Status.Status = core.FailureStatus
+	r.updating(nil)
+
+	conditions.Set(resource.Status.Conditions, gcpv1alpha1.Condition{
+		Type:               gcpv1alpha1.ReadyCondition,
+		Status:             gcpv1alpha1.ConditionFalse,
+		Reason:             "Conflict",
+		Message:            err.Error(),
+		ObservedGeneration: resource.Object.GetGeneration(),
+	})
+
+	// the step conditions are left as they are, so the status is written without deriving them
+	if err := r.Client.Status().Update(ctx, resource.Object); err != nil {
+		r.Logger.Error(err, "failed to update the resource status")
+
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{RequeueAfter: interval}, nil
+}
+
+// Failed records the failure of the step in the status
+func (r *Reporter) Failed(ctx context.Context, resource Resource, step string, err error) (reconcile.Result, error) {
+	r.Logger.Error(err, "provisioning step failed", "Step", step)
+
+	r.Recorder.Event(resource.Object, corev1.EventTypeWarning, events.StepFailed(step), err.Error())
+
+	*resource.Status.Status = core.FailureStatus
+	steps.SetFailed(resource.Status.Steps, step, err)
+	r.updating(err)
+
+	if err := r.UpdateStatus(ctx, resource); err != nil {
+		r.Logger.Error(err, "failed to update the resource status")
+	}
+
+	return reconcile.Result{}, err
+}
+
+// UpdateStatus derives the conditions from the progress of the steps and updates the status
+func (r *Reporter) UpdateStatus(ctx context.Context, resource Resource) error {
+	conditions.FromSteps(resource.Status.Conditions, *resource.Status.Steps, StepNames(), resource.Object.GetGeneration())
+
+	return r.Client.Status().Update(ctx, resource.Object)
+}
+
+// updating calls OnUpdate, if set
+func (r *Reporter) updating(err error) {
+	if r.OnUpdate != nil {
+		r.OnUpdate(err)
+	}
+}
@@ -0,0 +1,345 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/credentials"
+	"github.com/appvia/gcp-operator/pkg/events"
+	"github.com/appvia/gcp-operator/pkg/gcp"
+	"github.com/appvia/gcp-operator/pkg/labels"
+	"github.com/appvia/gcp-operator/pkg/operations"
+	"github.com/appvia/gcp-operator/pkg/ownership"
+	"github.com/appvia/gcp-operator/pkg/policy"
+	"github.com/appvia/gcp-operator/pkg/services"
+	"github.com/appvia/gcp-operator/pkg/steps"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// createProject creates the project, or takes over an existing project and updates its name,
+// parent and labels. Projects managed by another resource or cluster are never modified, and
+// unmanaged projects are only adopted if the owner allows it
+func (p *Provisioner) createProject(ctx context.Context) ([]gcpv1alpha1.Operation, error) {
+	spec := p.Spec
+	projectId := p.ProjectId
+
+	exists, err := p.GCP.Projects.Exists(ctx, projectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		project := p.desiredProject()
+		project.Labels = p.Owner.Labels()
+		labels.Apply(&project.Labels, p.Labels, nil)
+
+		operationName, err := p.GCP.Projects.Create(ctx, project)
+
+		// the generated ID is taken by a project the credentials cannot see, so a new one is
+		// generated on the next attempt
+		if gcp.IsAlreadyExists(err) && spec.GeneratedProjectId {
+			*p.Status.ProjectId = ""
+
+			return nil, fmt.Errorf("the generated project ID %s is already taken", projectId)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		*p.Status.LabelKeys = labels.Keys(p.Labels)
+
+		return []gcpv1alpha1.Operation{operations.New(operationName, gcpv1alpha1.CloudResourceManagerService, gcpv1alpha1.CreateProjectStep)}, nil
+	}
+
+	project, err := p.GCP.Projects.Get(ctx, projectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.Owner.Check(ctx, project.Labels); err != nil {
+		return nil, err
+	}
+
+	adopting := false
+
+	if !ownership.IsManaged(project.Labels) {
+		// projects created before the ownership labels were introduced are already ours
+		created := steps.Get(*p.Status.Steps, gcpv1alpha1.CreateProjectStep)
+		adopting = created == nil || created.ObservedGeneration == 0
+
+		if adopting && !p.Owner.Adopt {
+			return nil, ownership.ErrUnmanaged
+		}
+	} else if !p.Owner.IsOwner(project.Labels) {
+		// the check only lets through projects labelled for a deleted resource when adopting
+		adopting = true
+	}
+
+	moved := spec.ProjectName != project.Name || project.Parent == nil || spec.ParentType != project.Parent.Type || spec.ParentId != project.Parent.Id
+
+	relabelled := labels.Apply(&project.Labels, p.Labels, *p.Status.LabelKeys)
+
+	if !p.Owner.Stamp(&project.Labels) && !relabelled && !moved {
+		*p.Status.LabelKeys = labels.Keys(p.Labels)
+
+		return nil, nil
+	}
+
+	project.Name = spec.ProjectName
+	project.Parent = p.desiredProject().Parent
+
+	if err := p.GCP.Projects.Update(ctx, project); err != nil {
+		return nil, err
+	}
+
+	*p.Status.LabelKeys = labels.Keys(p.Labels)
+
+	if adopting {
+		p.event(events.ProjectAdopted, "Adopted the existing project %s", projectId)
+	}
+
+	if moved {
+		p.event(events.ProjectUpdated, "Updated the name and parent of project %s", projectId)
+	}
+
+	if relabelled {
+		p.event(events.ProjectUpdated, "Updated the labels of project %s", projectId)
+	}
+
+	return nil, nil
+}
+
+// desiredProject returns the project described by the spec
+func (p *Provisioner) desiredProject() *cloudresourcemanager.Project {
+	return &cloudresourcemanager.Project{
+		Name:      p.Spec.ProjectName,
+		ProjectId: p.ProjectId,
+		Parent: &cloudresourcemanager.ResourceId{
+			Id:   p.Spec.ParentId,
+			Type: p.Spec.ParentType,
+		},
+	}
+}
+
+// linkBilling links the billing account to the project if it is not already
+func (p *Provisioner) linkBilling(ctx context.Context) ([]gcpv1alpha1.Operation, error) {
+	projectId := p.ProjectId
+
+	billingAccountName, err := p.GCP.Billing.GetBillingAccount(ctx, projectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if billingAccountName == "billingAccounts/"+p.Spec.BillingAccountName {
+		return nil, nil
+	}
+
+	if err := p.GCP.Billing.SetBillingAccount(ctx, projectId, p.Spec.BillingAccountName); err != nil {
+		return nil, err
+	}
+
+	p.event(events.BillingLinked, "Linked billing account %s", p.Spec.BillingAccountName)
+
+	return nil, nil
+}
+
+// enableServices enables the baseline and requested services which are not enabled in the project
+// and, if requested, disables services which have been removed from the spec
+func (p *Provisioner) enableServices(ctx context.Context) ([]gcpv1alpha1.Operation, error) {
+	projectId := p.ProjectId
+	desired := services.Desired(p.Spec.Services)
+
+	enabled, err := p.GCP.Services.ListEnabled(ctx, projectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []gcpv1alpha1.Operation
+
+	for _, s := range services.Difference(desired, enabled) {
+		operationName, err := p.GCP.Services.Enable(ctx, projectId, s)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ops = append(ops, operations.New(operationName, gcpv1alpha1.ServiceManagementService, gcpv1alpha1.EnableServicesStep))
+
+		p.event(events.ServiceEnabled, "Enabling service %s", s)
+	}
+
+	// services being disabled are still reported until they have been
+	tracked := desired
+
+	if p.Spec.DisableRemovedServices {
+		removed := services.Intersection(services.Difference(*p.Status.EnabledServices, desired), enabled)
+		tracked = append(tracked, removed...)
+
+		for _, s := range removed {
+			operationName, err := p.GCP.Services.Disable(ctx, projectId, s)
+
+			if err != nil {
+				return nil, err
+			}
+
+			p.event(events.ServiceDisabled, "Disabling service %s which was removed from the spec", s)
+
+			ops = append(ops, operations.New(operationName, gcpv1alpha1.ServiceManagementService, gcpv1alpha1.EnableServicesStep))
+		}
+	}
+
+	*p.Status.EnabledServices = services.Intersection(tracked, enabled)
+
+	return ops, nil
+}
+
+// createServiceAccount creates the service account if it does not exist
+func (p *Provisioner) createServiceAccount(ctx context.Context) ([]gcpv1alpha1.Operation, error) {
+	projectId, name := p.ProjectId, p.Spec.ServiceAccountName
+
+	_, err := p.GCP.IAM.GetServiceAccount(ctx, projectId, name)
+
+	if err == nil || !gcp.IsNotFound(err) {
+		return nil, err
+	}
+
+	account, err := p.GCP.IAM.CreateServiceAccount(ctx, projectId, name, "Created by the Appvia Hub")
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.event(events.ServiceAccountCreated, "Created service account %s", account.Email)
+
+	return nil, nil
+}
+
+// grantPermissions applies the role bindings to the project policy, clearing the roles previously
+// applied authoritatively which are no longer bound
+func (p *Provisioner) grantPermissions(ctx context.Context) ([]gcpv1alpha1.Operation, error) {
+	projectId := p.ProjectId
+
+	var dropped []string
+	if p.Status.ManagedRoles != nil {
+		dropped = policy.Dropped(*p.Status.ManagedRoles, p.Spec.Bindings)
+	}
+
+	changed, err := policy.Apply(ctx, p.GCP.Projects, projectId, p.Spec.Bindings, p.Spec.Mode, dropped)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case changed && len(dropped) > 0:
+		p.event(events.IAMPolicyUpdated, "Applied %d role bindings to the policy of project %s, removing the dropped roles %v", len(p.Spec.Bindings), projectId, dropped)
+	case changed:
+		p.event(events.IAMPolicyUpdated, "Applied %d role bindings to the policy of project %s", len(p.Spec.Bindings), projectId)
+	}
+
+	if p.Status.ManagedRoles != nil {
+		*p.Status.ManagedRoles = policy.Managed(p.Spec.Bindings, p.Spec.Mode)
+	}
+
+	return nil, nil
+}
+
+// issueCredentials creates or updates the GCPCredentials for the service account and, unless they
+// impersonate it, the Secret holding its key. Both are owned by the resource so they are garbage
+// collected along with it. Switching to impersonation revokes the keys issued before
+func (p *Provisioner) issueCredentials(ctx context.Context) ([]gcpv1alpha1.Operation, error) {
+	projectId, account := p.ProjectId, p.Spec.ServiceAccountName
+	name := credentials.Name(projectId)
+	impersonate := p.Spec.CredentialsPolicy == gcpv1alpha1.ImpersonateCredentialsPolicy
+
+	if !impersonate {
+		_, err := credentials.EnsureSecret(ctx, p.Client, p.Scheme, p.Kind, p.Object, name, func() (string, error) {
+			return p.GCP.IAM.CreateServiceAccountKey(ctx, projectId, account)
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	organizationId, err := credentials.OrganizationId(ctx, p.GCP.Projects, projectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	spec := credentials.Spec(p.Spec.CredentialsPolicy, name, projectId, organizationId, account)
+
+	generated, result, err := credentials.Ensure(ctx, p.Client, p.Scheme, p.Kind, p.Object, name, spec)
+
+	if err != nil {
+		return nil, err
+	}
+
+	*p.Status.CredentialsRef = credentials.Reference(generated)
+
+	switch {
+	case result == controllerutil.OperationResultCreated && impersonate:
+		p.event(events.CredentialsIssued, "Issued GCPCredentials %s impersonating service account %s", name, account)
+	case result == controllerutil.OperationResultCreated:
+		p.event(events.CredentialsIssued, "Issued a key for service account %s in GCPCredentials %s", account, name)
+	case result == controllerutil.OperationResultUpdated:
+		p.event(events.CredentialsUpdated, "Updated GCPCredentials %s", name)
+	}
+
+	if !impersonate {
+		return nil, nil
+	}
+
+	// the credentials no longer refer to the key, so any issued before are revoked
+	revoked, err := credentials.Drop(ctx, p.Client, p.GCP.IAM, types.NamespacedName{Namespace: p.Object.GetNamespace(), Name: name}, p.Status.Keys, time.Now())
+
+	for _, key := range revoked {
+		p.event(events.CredentialsRevoked, "Revoked service account key %s", key.Name)
+	}
+
+	return nil, err
+}
+
+// RotateKey records the key of the generated credentials in the status, replaces it once the
+// rotation interval has passed and deletes replaced keys once the overlap has passed. It returns
+// how long until the next key is due to be replaced or deleted, zero when none is
+func (p *Provisioner) RotateKey(ctx context.Context) (time.Duration, error) {
+	// impersonating credentials hold no key to rotate
+	if p.Spec.CredentialsPolicy == gcpv1alpha1.ImpersonateCredentialsPolicy {
+		return 0, nil
+	}
+
+	projectId := p.ProjectId
+	name := credentials.Name(projectId)
+
+	rotation := credentials.Rotation{
+		Client:             p.Client,
+		IAM:                p.GCP.IAM,
+		Secret:             types.NamespacedName{Namespace: p.Object.GetNamespace(), Name: name},
+		ProjectId:          projectId,
+		ServiceAccountName: p.Spec.ServiceAccountName,
+		Policy:             p.Spec.KeyRotation,
+	}
+
+	result, err := rotation.Rotate(ctx, p.Status.Keys, time.Now())
+
+	if result.Issued != nil {
+		p.event(events.KeyRotated, "Replaced the key of GCPCredentials %s with key %s", name, result.Issued.ID)
+	}
+
+	for _, key := range result.Deleted {
+		p.event(events.CredentialsRevoked, "Revoked service account key %s", key.Name)
+	}
+
+	return result.RequeueAfter, err
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"sort"
+)
+
+// Baseline are the services required by the operator, which are always enabled in a project
+var Baseline = []string{
+	"cloudresourcemanager.googleapis.com",
+	"cloudbilling.googleapis.com",
+	"iam.googleapis.com",
+	"compute.googleapis.com",
+	"serviceusage.googleapis.com",
+}
+
+// Desired returns the baseline merged with the requested services, sorted and without duplicates
+func Desired(requested []string) []string {
+	return union(Baseline, requested)
+}
+
+// Difference returns the services in a which are not in b, sorted
+func Difference(a, b []string) []string {
+	in := make(map[string]bool)
+	for _, s := range b {
+		in[s] = true
+	}
+
+	var result []string
+	for _, s := range union(a) {
+		if !in[s] {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// Intersection returns the services in both a and b, sorted
+func Intersection(a, b []string) []string {
+	return Difference(a, Difference(a, b))
+}
+
+// union returns the services in any of the lists, sorted and without duplicates
+func union(lists ...[]string) []string {
+	seen := make(map[string]bool)
+
+	var result []string
+	for _, list := range lists {
+		for _, s := range list {
+			if !seen[s] {
+				seen[s] = true
+				result = append(result, s)
+			}
+		}
+	}
+	sort.Strings(result)
+
+	return result
+}
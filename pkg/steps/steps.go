@@ -0,0 +1,87 @@
+package steps
+
+import (
+	"context"
+
+	"github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	core "github.com/appvia/hub-apis/pkg/apis/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Step is a named, idempotent unit of provisioning work. Run returns any long running
+// operations it has started, in which case the step completes once they have finished
+type Step struct {
+	// Name is the name recorded in the status
+	Name string
+	// Run performs the step
+	Run func(ctx context.Context) ([]v1alpha1.Operation, error)
+	// Continuous steps are run on every reconcile rather than once per generation, so
+	// they can correct drift
+	Continuous bool
+}
+
+// Get returns the status of the named step, or nil if the step has not run
+func Get(status []v1alpha1.StepStatus, name string) *v1alpha1.StepStatus {
+	for i := range status {
+		if status[i].Name == name {
+			return &status[i]
+		}
+	}
+	return nil
+}
+
+// IsComplete checks if the step has completed for the generation of the resource
+func IsComplete(status []v1alpha1.StepStatus, name string, generation int64) bool {
+	s := Get(status, name)
+
+	return s != nil && s.Status == core.SuccessStatus && s.ObservedGeneration >= generation
+}
+
+// IsFailed checks if the step failed when it last ran
+func IsFailed(status []v1alpha1.StepStatus, name string) bool {
+	s := Get(status, name)
+
+	return s != nil && s.Status == core.FailureStatus
+}
+
+// AllComplete checks if all of the steps have completed for the generation of the resource
+func AllComplete(status []v1alpha1.StepStatus, names []string, generation int64) bool {
+	for _, name := range names {
+		if !IsComplete(status, name, generation) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetComplete marks the step as completed for the generation
+func SetComplete(status *[]v1alpha1.StepStatus, name string, generation int64) {
+	set(status, name, core.SuccessStatus, "").ObservedGeneration = generation
+}
+
+// SetPending marks the step as in progress
+func SetPending(status *[]v1alpha1.StepStatus, name string) {
+	set(status, name, core.PendingStatus, "")
+}
+
+// SetFailed marks the step as failed with the error
+func SetFailed(status *[]v1alpha1.StepStatus, name string, err error) {
+	set(status, name, core.FailureStatus, err.Error())
+}
+
+// set updates the status of the step, adding it if required
+func set(status *[]v1alpha1.StepStatus, name string, s core.Status, message string) *v1alpha1.StepStatus {
+	current := Get(*status, name)
+	if current == nil {
+		*status = append(*status, v1alpha1.StepStatus{Name: name})
+		current = &(*status)[len(*status)-1]
+	}
+
+	if current.Status != s {
+		current.LastTransitionTime = metav1.Now()
+	}
+	current.Status = s
+	current.Message = message
+
+	return current
+}
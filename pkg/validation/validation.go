@@ -0,0 +1,131 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// projectIdPattern is 6 to 30 lowercase letters, digits or hyphens, starting with a letter
+	// and not ending with a hyphen
+	projectIdPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+	// projectIdPrefixPattern leaves room for a hyphen and a suffix of 6 characters
+	projectIdPrefixPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{2,21}[a-z0-9]$`)
+	// projectNamePattern is 4 to 30 letters, digits, quotes, hyphens, spaces or exclamation marks
+	projectNamePattern = regexp.MustCompile(`^[a-zA-Z0-9'" !-]{4,30}$`)
+	// billingAccountPattern is the ID of a billing account e.g. 012345-567890-ABCDEF
+	billingAccountPattern = regexp.MustCompile(`^[0-9A-F]{6}-[0-9A-F]{6}-[0-9A-F]{6}$`)
+	// serviceAccountIdPattern is 6 to 30 lowercase letters, digits or hyphens, starting with a
+	// letter and not ending with a hyphen
+	serviceAccountIdPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+	// numericIdPattern is the ID of an organization or folder
+	numericIdPattern = regexp.MustCompile(`^[0-9]+$`)
+	// serviceAccountEmailPattern is the email of a service account, in a project or managed by Google
+	serviceAccountEmailPattern = regexp.MustCompile(`^[a-z0-9-]+@[a-z0-9.-]+\.gserviceaccount\.com$`)
+)
+
+// ProjectId checks the project ID follows the GCP rules
+func ProjectId(projectId string) error {
+	if !projectIdPattern.MatchString(projectId) {
+		return fmt.Errorf("project ID %q must be 6 to 30 lowercase letters, digits or hyphens, start with a letter and not end with a hyphen", projectId)
+	}
+	if strings.Contains(projectId, "google") || strings.Contains(projectId, "null") || strings.Contains(projectId, "undefined") {
+		return fmt.Errorf("project ID %q must not contain the words google, null or undefined", projectId)
+	}
+	return nil
+}
+
+// ProjectIdPrefix checks the prefix leaves room for the random suffix of a generated project ID
+func ProjectIdPrefix(prefix string) error {
+	if !projectIdPrefixPattern.MatchString(prefix) {
+		return fmt.Errorf("project ID prefix %q must be 4 to 23 lowercase letters, digits or hyphens, start with a letter and not end with a hyphen", prefix)
+	}
+	return ProjectId(prefix + "-suffix")
+}
+
+// ProjectName checks the project name follows the GCP rules
+func ProjectName(projectName string) error {
+	if !projectNamePattern.MatchString(projectName) {
+		return fmt.Errorf("project name %q must be 4 to 30 letters, digits, quotes, hyphens, spaces or exclamation marks", projectName)
+	}
+	return nil
+}
+
+// BillingAccountName checks the billing account is given as its ID
+func BillingAccountName(billingAccountName string) error {
+	if strings.HasPrefix(billingAccountName, "billingAccounts/") {
+		return fmt.Errorf("billing account %q must be given without the billingAccounts/ prefix", billingAccountName)
+	}
+	if !billingAccountPattern.MatchString(billingAccountName) {
+		return fmt.Errorf("billing account %q must be of the form 012345-567890-ABCDEF", billingAccountName)
+	}
+	return nil
+}
+
+// ServiceAccountId checks the service account ID follows the GCP rules
+func ServiceAccountId(name string) error {
+	if !serviceAccountIdPattern.MatchString(name) {
+		return fmt.Errorf("service account ID %q must be 6 to 30 lowercase letters, digits or hyphens, start with a letter and not end with a hyphen", name)
+	}
+	return nil
+}
+
+// Parent checks the parent ID suits the type of parent
+func Parent(parentType, parentId string) error {
+	switch parentType {
+	case "organization", "folder":
+		if !numericIdPattern.MatchString(parentId) {
+			return fmt.Errorf("the ID of the parent %s must be numeric, found %q", parentType, parentId)
+		}
+	case "project":
+		return ProjectId(parentId)
+	default:
+		return fmt.Errorf("parent type %q must be organization, folder or project", parentType)
+	}
+	return nil
+}
+
+// Defaulted checks the parent and service account name of a project, which are defaulted by the
+// controllers and the webhook when left out, were given or defaulted
+func Defaulted(parentType, parentId, serviceAccountName string) error {
+	if parentType == "" && parentId == "" {
+		return errors.New("spec.parentType and spec.parentId must be set, or the namespace annotated with the default parent")
+	}
+
+	if err := Parent(parentType, parentId); err != nil {
+		return err
+	}
+
+	return ServiceAccountId(serviceAccountName)
+}
+
+// OrganizationId checks the organization ID is numeric
+func OrganizationId(organizationId string) error {
+	if !numericIdPattern.MatchString(organizationId) {
+		return fmt.Errorf("organization ID %q must be numeric", organizationId)
+	}
+	return nil
+}
+
+// ServiceAccountEmail checks the email is that of a service account
+func ServiceAccountEmail(email string) error {
+	if !serviceAccountEmailPattern.MatchString(email) {
+		return fmt.Errorf("service account email %q must be of the form name@project.iam.gserviceaccount.com", email)
+	}
+	return nil
+}
+
+// KeyRotation checks keys are replaced after a positive interval and a replaced key is deleted
+// before the key replacing it is itself replaced
+func KeyRotation(interval, overlap time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("the key rotation interval must be positive, found %s", interval)
+	}
+	if overlap < 0 || overlap >= interval {
+		return fmt.Errorf("the key rotation overlap must be at least zero and shorter than the interval %s, found %s", interval, overlap)
+	}
+	return nil
+}
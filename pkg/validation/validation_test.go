@@ -0,0 +1,193 @@
+package validation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProjectId(t *testing.T) {
+	tests := []struct {
+		projectId string
+		valid     bool
+	}{
+		{projectId: "demo-project", valid: true},
+		{projectId: "a12345", valid: true},
+		{projectId: "abcde"},
+		{projectId: "a23456789012345678901234567890", valid: true},
+		{projectId: "a234567890123456789012345678901"},
+		{projectId: "1demo-project"},
+		{projectId: "demo-project-"},
+		{projectId: "Demo-project"},
+		{projectId: "demo_project"},
+		{projectId: "my-google-project"},
+		{projectId: "null-project"},
+		{projectId: "undefined-project"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.projectId, func(t *testing.T) {
+			if err := ProjectId(test.projectId); (err == nil) != test.valid {
+				t.Errorf("expected valid %t, got %v", test.valid, err)
+			}
+		})
+	}
+}
+
+func TestProjectIdPrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		valid  bool
+	}{
+		{prefix: "team", valid: true},
+		{prefix: "tea"},
+		{prefix: "a2345678901234567890123", valid: true},
+		{prefix: "a23456789012345678901234"},
+		{prefix: "team-"},
+		{prefix: "google"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.prefix, func(t *testing.T) {
+			if err := ProjectIdPrefix(test.prefix); (err == nil) != test.valid {
+				t.Errorf("expected valid %t, got %v", test.valid, err)
+			}
+		})
+	}
+}
+
+func TestProjectName(t *testing.T) {
+	tests := []struct {
+		projectName string
+		valid       bool
+	}{
+		{projectName: "Demo", valid: true},
+		{projectName: `Team's "demo" project!`, valid: true},
+		{projectName: "Dem"},
+		{projectName: "A project name which is far too long"},
+		{projectName: "demo_project"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.projectName, func(t *testing.T) {
+			if err := ProjectName(test.projectName); (err == nil) != test.valid {
+				t.Errorf("expected valid %t, got %v", test.valid, err)
+			}
+		})
+	}
+}
+
+func TestBillingAccountName(t *testing.T) {
+	tests := []struct {
+		billingAccountName string
+		valid              bool
+	}{
+		{billingAccountName: "012345-567890-ABCDEF", valid: true},
+		{billingAccountName: "billingAccounts/012345-567890-ABCDEF"},
+		{billingAccountName: "012345-567890-abcdef"},
+		{billingAccountName: "012345567890ABCDEF"},
+		{billingAccountName: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.billingAccountName, func(t *testing.T) {
+			if err := BillingAccountName(test.billingAccountName); (err == nil) != test.valid {
+				t.Errorf("expected valid %t, got %v", test.valid, err)
+			}
+		})
+	}
+}
+
+func TestParent(t *testing.T) {
+	tests := []struct {
+		name       string
+		parentType string
+		parentId   string
+		valid      bool
+	}{
+		{name: "organization", parentType: "organization", parentId: "123456789012", valid: true},
+		{name: "folder", parentType: "folder", parentId: "987654321", valid: true},
+		{name: "project", parentType: "project", parentId: "demo-project", valid: true},
+		{name: "organization named", parentType: "organization", parentId: "example.com"},
+		{name: "folder prefixed", parentType: "folder", parentId: "folders/987654321"},
+		{name: "invalid project", parentType: "project", parentId: "Demo"},
+		{name: "unknown type", parentType: "billingAccount", parentId: "123456789012"},
+		{name: "missing type", parentId: "123456789012"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := Parent(test.parentType, test.parentId); (err == nil) != test.valid {
+				t.Errorf("expected valid %t, got %v", test.valid, err)
+			}
+		})
+	}
+}
+
+func TestDefaulted(t *testing.T) {
+	tests := []struct {
+		name               string
+		parentType         string
+		parentId           string
+		serviceAccountName string
+		valid              bool
+	}{
+		{name: "defaulted", parentType: "organization", parentId: "123456789012", serviceAccountName: "robot-account", valid: true},
+		{name: "missing parent", serviceAccountName: "robot-account"},
+		{name: "invalid parent", parentType: "folder", parentId: "team", serviceAccountName: "robot-account"},
+		{name: "missing service account", parentType: "organization", parentId: "123456789012"},
+		{name: "invalid service account", parentType: "organization", parentId: "123456789012", serviceAccountName: "robot"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := Defaulted(test.parentType, test.parentId, test.serviceAccountName); (err == nil) != test.valid {
+				t.Errorf("expected valid %t, got %v", test.valid, err)
+			}
+		})
+	}
+}
+
+func TestServiceAccountEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		valid bool
+	}{
+		{email: "robot-account@demo-project.iam.gserviceaccount.com", valid: true},
+		{email: "service-123@compute-system.iam.gserviceaccount.com", valid: true},
+		{email: "robot-account@example.com"},
+		{email: "robot-account"},
+		{email: "Robot@demo-project.iam.gserviceaccount.com"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.email, func(t *testing.T) {
+			if err := ServiceAccountEmail(test.email); (err == nil) != test.valid {
+				t.Errorf("expected valid %t, got %v", test.valid, err)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		overlap  time.Duration
+		valid    bool
+	}{
+		{name: "overlap", interval: 30 * 24 * time.Hour, overlap: 24 * time.Hour, valid: true},
+		{name: "no overlap", interval: time.Hour, valid: true},
+		{name: "zero interval"},
+		{name: "negative interval", interval: -time.Hour},
+		{name: "negative overlap", interval: time.Hour, overlap: -time.Minute},
+		{name: "overlap as long as the interval", interval: time.Hour, overlap: time.Hour},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := KeyRotation(test.interval, test.overlap); (err == nil) != test.valid {
+				t.Errorf("expected valid %t, got %v", test.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/defaults"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// defaulter fills in the fields left out of new GCP projects
+type defaulter struct {
+	defaults.Defaulter
+	decoder *admission.Decoder
+}
+
+// Handle defaults the GCPProject or GCPAdminProject being created
+func (d *defaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1beta1.Create {
+		return admission.Allowed("")
+	}
+
+	var obj runtime.Object
+	var err error
+
+	switch req.Kind.Kind {
+	case "GCPProject":
+		project := &gcpv1alpha1.GCPProject{}
+
+		if err := d.decoder.Decode(req, project); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+
+		obj, err = project, d.defaultProject(ctx, req.Namespace, project)
+	case "GCPAdminProject":
+		project := &gcpv1alpha1.GCPAdminProject{}
+
+		if err := d.decoder.Decode(req, project); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+
+		obj, err = project, d.defaultAdminProject(ctx, req.Namespace, project)
+	default:
+		return admission.Allowed("")
+	}
+
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	marshaled, err := json.Marshal(obj)
+
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// defaultProject fills in the service account name, the parent and the default labels
+func (d *defaulter) defaultProject(ctx context.Context, namespace string, project *gcpv1alpha1.GCPProject) error {
+	if err := d.Project(ctx, namespace, project); err != nil {
+		return err
+	}
+
+	project.Spec.Labels = d.WithLabels(project.Spec.Labels)
+
+	return nil
+}
+
+// defaultAdminProject fills in the service account name, the parent and the default labels
+func (d *defaulter) defaultAdminProject(ctx context.Context, namespace string, project *gcpv1alpha1.GCPAdminProject) error {
+	if err := d.AdminProject(ctx, namespace, project); err != nil {
+		return err
+	}
+
+	project.Spec.Labels = d.WithLabels(project.Spec.Labels)
+
+	return nil
+}
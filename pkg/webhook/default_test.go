@@ -0,0 +1,173 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/defaults"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestDefaultProject(t *testing.T) {
+	annotated := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        testNamespace,
+		Annotations: map[string]string{defaults.ParentTypeAnnotation: "folder", defaults.ParentIdAnnotation: "987654321"},
+	}}
+	plain := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}}
+
+	tests := []struct {
+		name     string
+		change   func(*gcpv1alpha1.GCPProject)
+		existing []runtime.Object
+		patches  map[string]interface{}
+	}{
+		{
+			name:     "complete",
+			change:   func(p *gcpv1alpha1.GCPProject) { p.Spec.Labels = map[string]string{"managed-via": "kubectl"} },
+			existing: []runtime.Object{plain},
+		},
+		{
+			name:     "service account",
+			change:   func(p *gcpv1alpha1.GCPProject) { p.Spec.ServiceAccountName = "" },
+			existing: []runtime.Object{plain},
+			patches: map[string]interface{}{
+				"/spec/serviceAccountName": "gcp-operator",
+				"/spec/labels":             map[string]interface{}{"managed-via": "hub"},
+			},
+		},
+		{
+			name:     "parent from the namespace",
+			change:   func(p *gcpv1alpha1.GCPProject) { p.Spec.ParentType, p.Spec.ParentId = "", "" },
+			existing: []runtime.Object{annotated, newCredentials()},
+			patches: map[string]interface{}{
+				"/spec/parentType": "folder",
+				"/spec/parentId":   "987654321",
+				"/spec/labels":     map[string]interface{}{"managed-via": "hub"},
+			},
+		},
+		{
+			name:     "parent from the credentials",
+			change:   func(p *gcpv1alpha1.GCPProject) { p.Spec.ParentType, p.Spec.ParentId = "", "" },
+			existing: []runtime.Object{plain, newCredentials()},
+			patches: map[string]interface{}{
+				"/spec/parentType": "organization",
+				"/spec/parentId":   "123456789012",
+				"/spec/labels":     map[string]interface{}{"managed-via": "hub"},
+			},
+		},
+		{
+			name:     "no parent found",
+			change:   func(p *gcpv1alpha1.GCPProject) { p.Spec.ParentType, p.Spec.ParentId = "", "" },
+			existing: []runtime.Object{plain},
+			patches: map[string]interface{}{
+				"/spec/labels": map[string]interface{}{"managed-via": "hub"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			project := newProject()
+			test.change(project)
+
+			d := &defaulter{
+				Defaulter: defaults.Defaulter{
+					Reader:             fake.NewFakeClientWithScheme(scheme.Scheme, test.existing...),
+					ServiceAccountName: "gcp-operator",
+					Labels:             map[string]string{"managed-via": "hub"},
+				},
+				decoder: newDecoder(t),
+			}
+
+			resp := d.Handle(context.TODO(), newRequest(t, admissionv1beta1.Create, "GCPProject", project, nil))
+
+			if !resp.Allowed {
+				t.Fatalf("expected the request to be allowed, got %+v", resp.Result)
+			}
+
+			checkPatches(t, resp, test.patches)
+		})
+	}
+}
+
+func TestDefaultAdminProject(t *testing.T) {
+	project := newAdminProject()
+	project.Spec.ServiceAccountName = ""
+
+	d := &defaulter{
+		Defaulter: defaults.Defaulter{
+			Reader:             fake.NewFakeClientWithScheme(scheme.Scheme, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}}),
+			ServiceAccountName: "gcp-operator",
+		},
+		decoder: newDecoder(t),
+	}
+
+	resp := d.Handle(context.TODO(), newRequest(t, admissionv1beta1.Create, "GCPAdminProject", project, nil))
+
+	if !resp.Allowed {
+		t.Fatalf("expected the request to be allowed, got %+v", resp.Result)
+	}
+
+	checkPatches(t, resp, map[string]interface{}{"/spec/serviceAccountName": "gcp-operator"})
+}
+
+func TestDefaultIgnoresUpdates(t *testing.T) {
+	project := newProject()
+	project.Spec.ServiceAccountName = ""
+
+	d := &defaulter{Defaulter: defaults.Defaulter{ServiceAccountName: "gcp-operator"}, decoder: newDecoder(t)}
+
+	resp := d.Handle(context.TODO(), newRequest(t, admissionv1beta1.Update, "GCPProject", project, project))
+
+	if !resp.Allowed || len(resp.Patches) != 0 {
+		t.Errorf("expected the update to be allowed unchanged, got %+v", resp)
+	}
+}
+
+// checkPatches checks the patches of the response add exactly the expected values by path
+func checkPatches(t *testing.T, resp admission.Response, expected map[string]interface{}) {
+	t.Helper()
+
+	patches := resp.Patches
+
+	if len(patches) != len(expected) {
+		t.Errorf("expected %d patches, got %+v", len(expected), patches)
+	}
+
+	for _, patch := range patches {
+		value, found := expected[patch.Path]
+		if !found {
+			t.Errorf("unexpected patch %+v", patch)
+			continue
+		}
+		if patch.Operation != "add" || !equalJSON(patch.Value, value) {
+			t.Errorf("expected %s to be set to %v, got %+v", patch.Path, value, patch)
+		}
+	}
+}
+
+// equalJSON compares a decoded JSON value with the expected one
+func equalJSON(value, expected interface{}) bool {
+	switch expected := expected.(type) {
+	case map[string]interface{}:
+		decoded, ok := value.(map[string]interface{})
+		if !ok || len(decoded) != len(expected) {
+			return false
+		}
+		for k, v := range expected {
+			if decoded[k] != v {
+				return false
+			}
+		}
+		return true
+	default:
+		return value == expected
+	}
+}
@@ -0,0 +1,320 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/keys"
+	"github.com/appvia/gcp-operator/pkg/labels"
+	"github.com/appvia/gcp-operator/pkg/ownership"
+	"github.com/appvia/gcp-operator/pkg/validation"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// AllowParentChangeAnnotation must be set to "true" on a resource to move its project to another parent
+const AllowParentChangeAnnotation = "gcp.compute.hub.appvia.io/allow-parent-change"
+
+// validator rejects GCP resources which GCP would refuse or which change immutable fields
+type validator struct {
+	// reader reads directly from the api server, the credentials may be in any namespace
+	reader  client.Reader
+	decoder *admission.Decoder
+}
+
+// Handle validates the GCPProject, GCPAdminProject or GCPCredentials being created or updated
+func (v *validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update {
+		return admission.Allowed("")
+	}
+
+	var problems []string
+	var err error
+
+	switch req.Kind.Kind {
+	case "GCPProject":
+		problems, err = v.validateProject(ctx, req)
+	case "GCPAdminProject":
+		problems, err = v.validateAdminProject(req)
+	case "GCPCredentials":
+		problems, err = v.validateCredentials(ctx, req)
+	default:
+		return admission.Allowed("")
+	}
+
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if len(problems) > 0 {
+		return admission.Denied(strings.Join(problems, "; "))
+	}
+
+	return admission.Allowed("")
+}
+
+// validateProject returns the problems with a GCPProject
+func (v *validator) validateProject(ctx context.Context, req admission.Request) ([]string, error) {
+	project := &gcpv1alpha1.GCPProject{}
+
+	if err := v.decoder.Decode(req, project); err != nil {
+		return nil, err
+	}
+
+	old := &gcpv1alpha1.GCPProject{}
+
+	if req.Operation == admissionv1beta1.Update {
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return nil, err
+		}
+
+		// the finalizer must always be removable, and resources created before the webhook
+		// must remain updatable by the operator
+		if reflect.DeepEqual(old.Spec, project.Spec) {
+			return nil, nil
+		}
+	}
+
+	if project.GetDeletionTimestamp() != nil {
+		return nil, nil
+	}
+
+	spec := project.Spec
+
+	problems := check(
+		validateProjectId(spec.ProjectId, spec.ProjectIdPrefix),
+		validation.ProjectName(spec.ProjectName),
+		validation.Parent(spec.ParentType, spec.ParentId),
+		validation.BillingAccountName(spec.BillingAccountName),
+		validation.ServiceAccountId(spec.ServiceAccountName),
+		validateLabels(spec.Labels),
+		validateKeyRotation(spec.KeyRotation),
+		validateCredentialsPolicy(spec.CredentialsPolicy, spec.KeyRotation),
+	)
+
+	if spec.Use.Name == "" || spec.Use.Namespace == "" {
+		problems = append(problems, "spec.use must give the name and namespace of the GCPCredentials")
+	} else {
+		reference := types.NamespacedName{Namespace: spec.Use.Namespace, Name: spec.Use.Name}
+
+		err := v.reader.Get(ctx, reference, &gcpv1alpha1.GCPCredentials{})
+
+		switch {
+		case apierrors.IsNotFound(err):
+			problems = append(problems, "the GCPCredentials "+reference.String()+" in spec.use do not exist")
+		case err != nil:
+			return nil, err
+		}
+	}
+
+	if req.Operation == admissionv1beta1.Update {
+		problems = append(problems, immutable(project.GetAnnotations(),
+			old.Spec.ProjectId, spec.ProjectId,
+			old.Spec.ParentType+"/"+old.Spec.ParentId, spec.ParentType+"/"+spec.ParentId)...)
+
+		if old.Spec.ProjectIdPrefix != spec.ProjectIdPrefix {
+			problems = append(problems, fmt.Sprintf("spec.projectIdPrefix cannot be changed from %q", old.Spec.ProjectIdPrefix))
+		}
+	}
+
+	return problems, nil
+}
+
+// validateAdminProject returns the problems with a GCPAdminProject
+func (v *validator) validateAdminProject(req admission.Request) ([]string, error) {
+	project := &gcpv1alpha1.GCPAdminProject{}
+
+	if err := v.decoder.Decode(req, project); err != nil {
+		return nil, err
+	}
+
+	old := &gcpv1alpha1.GCPAdminProject{}
+
+	if req.Operation == admissionv1beta1.Update {
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return nil, err
+		}
+
+		if reflect.DeepEqual(old.Spec, project.Spec) {
+			return nil, nil
+		}
+	}
+
+	if project.GetDeletionTimestamp() != nil {
+		return nil, nil
+	}
+
+	spec := project.Spec
+
+	problems := check(
+		validation.ProjectId(spec.ProjectId),
+		validation.ProjectName(spec.ProjectName),
+		validation.Parent(spec.ParentType, spec.ParentId),
+		validation.BillingAccountName(spec.BillingAccountName),
+		validation.ServiceAccountId(spec.ServiceAccountName),
+		validateLabels(spec.Labels),
+		validateKeyRotation(spec.KeyRotation),
+		validateCredentialsPolicy(spec.CredentialsPolicy, spec.KeyRotation),
+	)
+
+	if spec.Token == "" && spec.TokenRef == nil {
+		problems = append(problems, "either spec.token or spec.tokenRef must be set")
+	}
+
+	// keys are replaced using the token, so it must be kept
+	if spec.KeyRotation != nil && spec.DeleteTokenAfterBootstrap {
+		problems = append(problems, "spec.keyRotation cannot be used with spec.deleteTokenAfterBootstrap")
+	}
+
+	if req.Operation == admissionv1beta1.Update {
+		problems = append(problems, immutable(project.GetAnnotations(),
+			old.Spec.ProjectId, spec.ProjectId,
+			old.Spec.ParentType+"/"+old.Spec.ParentId, spec.ParentType+"/"+spec.ParentId)...)
+	}
+
+	return problems, nil
+}
+
+// validateCredentials returns the problems with a GCPCredentials
+func (v *validator) validateCredentials(ctx context.Context, req admission.Request) ([]string, error) {
+	credentials := &gcpv1alpha1.GCPCredentials{}
+
+	if err := v.decoder.Decode(req, credentials); err != nil {
+		return nil, err
+	}
+
+	if req.Operation == admissionv1beta1.Update {
+		old := &gcpv1alpha1.GCPCredentials{}
+
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return nil, err
+		}
+
+		if reflect.DeepEqual(old.Spec, credentials.Spec) {
+			return nil, nil
+		}
+	}
+
+	if credentials.GetDeletionTimestamp() != nil {
+		return nil, nil
+	}
+
+	spec := credentials.Spec
+
+	problems := check(
+		validation.ProjectId(spec.ProjectId),
+		validation.OrganizationId(spec.OrganizationId),
+	)
+
+	if spec.ImpersonateServiceAccount == "" {
+		if spec.Key == "" && spec.KeyRef == nil {
+			problems = append(problems, "one of spec.key, spec.keyRef or spec.impersonateServiceAccount must be set")
+		}
+		if spec.TokenSecretRef != nil {
+			problems = append(problems, "spec.tokenSecretRef can only be used with spec.impersonateServiceAccount")
+		}
+	} else {
+		problems = append(problems, check(validation.ServiceAccountEmail(spec.ImpersonateServiceAccount))...)
+	}
+
+	// without a key the service account is impersonated by the operator itself
+	if spec.ImpersonateServiceAccount != "" && !keys.HasKey(credentials) {
+		account, err := keys.OwnServiceAccount(ctx, v.reader, credentials)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if account != spec.ImpersonateServiceAccount {
+			problems = append(problems, keys.ImpersonationNotAllowed)
+		}
+	}
+
+	// the token would overwrite the key the credentials use
+	if spec.TokenSecretRef != nil && spec.KeyRef != nil && spec.TokenSecretRef.Name == spec.KeyRef.Name {
+		problems = append(problems, "spec.tokenSecretRef must refer to a different Secret than spec.keyRef")
+	}
+
+	return problems, nil
+}
+
+// validateProjectId checks exactly one of the project ID and the prefix of a generated ID is set
+func validateProjectId(projectId, prefix string) error {
+	switch {
+	case projectId != "" && prefix != "":
+		return errors.New("only one of spec.projectId and spec.projectIdPrefix can be set")
+	case prefix != "":
+		return validation.ProjectIdPrefix(prefix)
+	default:
+		return validation.ProjectId(projectId)
+	}
+}
+
+// immutable returns the problems with changes to the project ID or parent, the parent can only be
+// changed when the resource is annotated to allow it
+func immutable(annotations map[string]string, oldProjectId, projectId, oldParent, parent string) []string {
+	var problems []string
+
+	if oldProjectId != projectId {
+		problems = append(problems, fmt.Sprintf("spec.projectId cannot be changed from %q", oldProjectId))
+	}
+
+	if oldParent != parent && annotations[AllowParentChangeAnnotation] != "true" {
+		problems = append(problems, fmt.Sprintf("moving the project from %s to %s needs the annotation %s: \"true\"", oldParent, parent, AllowParentChangeAnnotation))
+	}
+
+	return problems
+}
+
+// validateLabels checks the labels in the spec follow the GCP rules and do not use the keys of
+// the ownership labels
+func validateLabels(specLabels map[string]string) error {
+	for _, k := range labels.Keys(specLabels) {
+		if ownership.IsReserved(k) {
+			return fmt.Errorf("label %s is reserved for the operator", k)
+		}
+	}
+	return labels.Validate(specLabels)
+}
+
+// validateCredentialsPolicy checks keys are only rotated when the credentials hold one
+func validateCredentialsPolicy(policy gcpv1alpha1.CredentialsPolicy, rotation *gcpv1alpha1.KeyRotation) error {
+	if policy == gcpv1alpha1.ImpersonateCredentialsPolicy && rotation != nil {
+		return errors.New("spec.keyRotation cannot be used with the Impersonate spec.credentialsPolicy")
+	}
+	return nil
+}
+
+// validateKeyRotation checks the interval and overlap of the key rotation, if any
+func validateKeyRotation(rotation *gcpv1alpha1.KeyRotation) error {
+	if rotation == nil {
+		return nil
+	}
+
+	var overlap time.Duration
+	if rotation.Overlap != nil {
+		overlap = rotation.Overlap.Duration
+	}
+
+	return validation.KeyRotation(rotation.Interval.Duration, overlap)
+}
+
+// check returns the messages of the errors which are not nil
+func check(errs ...error) []string {
+	var problems []string
+	for _, err := range errs {
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	return problems
+}
@@ -0,0 +1,369 @@
+package webhook
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	"github.com/appvia/gcp-operator/pkg/ownership"
+	core "github.com/appvia/hub-apis/pkg/apis/core/v1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// newProject returns a valid GCPProject using the credentials returned by newCredentials
+func newProject() *gcpv1alpha1.GCPProject {
+	return &gcpv1alpha1.GCPProject{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: testNamespace},
+		Spec: gcpv1alpha1.GCPProjectSpec{
+			ProjectId:          "demo-project",
+			ProjectName:        "Demo",
+			ParentType:         "organization",
+			ParentId:           "123456789012",
+			BillingAccountName: "012345-567890-ABCDEF",
+			ServiceAccountName: "robot-account",
+			Use:                core.Ownership{Namespace: testNamespace, Name: "admin"},
+		},
+	}
+}
+
+// newAdminProject returns a valid GCPAdminProject
+func newAdminProject() *gcpv1alpha1.GCPAdminProject {
+	return &gcpv1alpha1.GCPAdminProject{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin", Namespace: testNamespace},
+		Spec: gcpv1alpha1.GCPAdminProjectSpec{
+			TokenRef:           &gcpv1alpha1.SecretKeyReference{Name: "gcp-admin-token"},
+			ProjectId:          "admin-project",
+			ProjectName:        "Admin",
+			ParentType:         "organization",
+			ParentId:           "123456789012",
+			BillingAccountName: "012345-567890-ABCDEF",
+			ServiceAccountName: "admin-account",
+		},
+	}
+}
+
+// newCredentials returns valid GCPCredentials holding a key
+func newCredentials() *gcpv1alpha1.GCPCredentials {
+	return &gcpv1alpha1.GCPCredentials{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin", Namespace: testNamespace},
+		Spec: gcpv1alpha1.GCPCredentialsSpec{
+			Key:            "e30=",
+			ProjectId:      "admin-project",
+			OrganizationId: "123456789012",
+		},
+	}
+}
+
+// validate runs the request through a validator reading the objects
+func validate(t *testing.T, req admission.Request, objs ...runtime.Object) admission.Response {
+	t.Helper()
+
+	v := &validator{reader: fake.NewFakeClientWithScheme(scheme.Scheme, objs...), decoder: newDecoder(t)}
+
+	return v.Handle(context.TODO(), req)
+}
+
+// checkResponse checks the request was allowed, or denied with a reason containing the problem.
+// Errors decoding or reading are reported in the message, so they never match
+func checkResponse(t *testing.T, resp admission.Response, problem string) {
+	t.Helper()
+
+	if problem == "" {
+		if !resp.Allowed {
+			t.Errorf("expected the request to be allowed, denied with %q", resp.Result.Reason)
+		}
+		return
+	}
+
+	if resp.Allowed {
+		t.Errorf("expected the request to be denied with %q", problem)
+		return
+	}
+	if !strings.Contains(string(resp.Result.Reason), problem) {
+		t.Errorf("expected the request to be denied with %q, got %q", problem, resp.Result.Reason)
+	}
+}
+
+func TestValidateProject(t *testing.T) {
+	tests := []struct {
+		name        string
+		change      func(*gcpv1alpha1.GCPProject)
+		credentials bool
+		problem     string
+	}{
+		{name: "valid", change: func(*gcpv1alpha1.GCPProject) {}, credentials: true},
+		{
+			name:        "generated project ID",
+			change:      func(p *gcpv1alpha1.GCPProject) { p.Spec.ProjectId, p.Spec.ProjectIdPrefix = "", "team" },
+			credentials: true,
+		},
+		{
+			name:        "project ID and prefix",
+			change:      func(p *gcpv1alpha1.GCPProject) { p.Spec.ProjectIdPrefix = "team" },
+			credentials: true,
+			problem:     "only one of spec.projectId and spec.projectIdPrefix",
+		},
+		{
+			name:        "invalid project ID",
+			change:      func(p *gcpv1alpha1.GCPProject) { p.Spec.ProjectId = "Demo" },
+			credentials: true,
+			problem:     `project ID "Demo"`,
+		},
+		{
+			name:        "invalid billing account",
+			change:      func(p *gcpv1alpha1.GCPProject) { p.Spec.BillingAccountName = "billingAccounts/012345-567890-ABCDEF" },
+			credentials: true,
+			problem:     "without the billingAccounts/ prefix",
+		},
+		{
+			name:        "reserved label",
+			change:      func(p *gcpv1alpha1.GCPProject) { p.Spec.Labels = map[string]string{ownership.UIDLabel: "x"} },
+			credentials: true,
+			problem:     "reserved for the operator",
+		},
+		{
+			name: "rotating impersonated credentials",
+			change: func(p *gcpv1alpha1.GCPProject) {
+				p.Spec.CredentialsPolicy = gcpv1alpha1.ImpersonateCredentialsPolicy
+				p.Spec.KeyRotation = &gcpv1alpha1.KeyRotation{Interval: metav1.Duration{Duration: time.Hour}}
+			},
+			credentials: true,
+			problem:     "cannot be used with the Impersonate spec.credentialsPolicy",
+		},
+		{
+			name: "overlap longer than the interval",
+			change: func(p *gcpv1alpha1.GCPProject) {
+				p.Spec.KeyRotation = &gcpv1alpha1.KeyRotation{Interval: metav1.Duration{Duration: time.Hour}, Overlap: &metav1.Duration{Duration: 2 * time.Hour}}
+			},
+			credentials: true,
+			problem:     "key rotation overlap",
+		},
+		{
+			name:    "missing credentials",
+			change:  func(*gcpv1alpha1.GCPProject) {},
+			problem: "in spec.use do not exist",
+		},
+		{
+			name:        "no credentials given",
+			change:      func(p *gcpv1alpha1.GCPProject) { p.Spec.Use = core.Ownership{} },
+			credentials: true,
+			problem:     "spec.use must give the name and namespace",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			project := newProject()
+			test.change(project)
+
+			var objs []runtime.Object
+			if test.credentials {
+				objs = append(objs, newCredentials())
+			}
+
+			resp := validate(t, newRequest(t, admissionv1beta1.Create, "GCPProject", project, nil), objs...)
+
+			checkResponse(t, resp, test.problem)
+		})
+	}
+}
+
+func TestValidateProjectUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*gcpv1alpha1.GCPProject)
+		problem string
+	}{
+		{name: "unchanged", change: func(*gcpv1alpha1.GCPProject) {}},
+		{name: "renamed", change: func(p *gcpv1alpha1.GCPProject) { p.Spec.ProjectName = "Renamed" }},
+		{
+			name:    "project ID changed",
+			change:  func(p *gcpv1alpha1.GCPProject) { p.Spec.ProjectId = "other-project" },
+			problem: `spec.projectId cannot be changed from "demo-project"`,
+		},
+		{
+			name:    "moved",
+			change:  func(p *gcpv1alpha1.GCPProject) { p.Spec.ParentType, p.Spec.ParentId = "folder", "987654321" },
+			problem: "needs the annotation " + AllowParentChangeAnnotation,
+		},
+		{
+			name: "moved with the annotation",
+			change: func(p *gcpv1alpha1.GCPProject) {
+				p.Spec.ParentType, p.Spec.ParentId = "folder", "987654321"
+				p.Annotations = map[string]string{AllowParentChangeAnnotation: "true"}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			old := newProject()
+			project := old.DeepCopy()
+			test.change(project)
+
+			resp := validate(t, newRequest(t, admissionv1beta1.Update, "GCPProject", project, old), newCredentials())
+
+			checkResponse(t, resp, test.problem)
+		})
+	}
+}
+
+func TestValidateProjectAllowsUnchangedInvalidSpec(t *testing.T) {
+	// resources created before the webhook must remain updatable by the operator
+	old := newProject()
+	old.Spec.ProjectName = "x"
+	project := old.DeepCopy()
+	project.Finalizers = []string{"gcp.compute.hub.appvia.io/cleanup"}
+
+	resp := validate(t, newRequest(t, admissionv1beta1.Update, "GCPProject", project, old))
+
+	checkResponse(t, resp, "")
+}
+
+func TestValidateAdminProject(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*gcpv1alpha1.GCPAdminProject)
+		problem string
+	}{
+		{name: "valid", change: func(*gcpv1alpha1.GCPAdminProject) {}},
+		{
+			name:   "inline token",
+			change: func(p *gcpv1alpha1.GCPAdminProject) { p.Spec.TokenRef, p.Spec.Token = nil, "token" },
+		},
+		{
+			name:    "no token",
+			change:  func(p *gcpv1alpha1.GCPAdminProject) { p.Spec.TokenRef = nil },
+			problem: "either spec.token or spec.tokenRef must be set",
+		},
+		{
+			name: "rotating after deleting the token",
+			change: func(p *gcpv1alpha1.GCPAdminProject) {
+				p.Spec.DeleteTokenAfterBootstrap = true
+				p.Spec.KeyRotation = &gcpv1alpha1.KeyRotation{Interval: metav1.Duration{Duration: time.Hour}}
+			},
+			problem: "cannot be used with spec.deleteTokenAfterBootstrap",
+		},
+		{
+			name:    "invalid parent",
+			change:  func(p *gcpv1alpha1.GCPAdminProject) { p.Spec.ParentType = "billingAccount" },
+			problem: `parent type "billingAccount"`,
+		},
+		{
+			name:    "invalid service account",
+			change:  func(p *gcpv1alpha1.GCPAdminProject) { p.Spec.ServiceAccountName = "admin" },
+			problem: `service account ID "admin"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			project := newAdminProject()
+			test.change(project)
+
+			resp := validate(t, newRequest(t, admissionv1beta1.Create, "GCPAdminProject", project, nil))
+
+			checkResponse(t, resp, test.problem)
+		})
+	}
+}
+
+func TestValidateCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*gcpv1alpha1.GCPCredentials)
+		problem string
+	}{
+		{name: "inline key", change: func(*gcpv1alpha1.GCPCredentials) {}},
+		{
+			name: "key in a secret",
+			change: func(c *gcpv1alpha1.GCPCredentials) {
+				c.Spec.Key, c.Spec.KeyRef = "", &gcpv1alpha1.SecretKeyReference{Name: "admin-key"}
+			},
+		},
+		{
+			name:    "no key",
+			change:  func(c *gcpv1alpha1.GCPCredentials) { c.Spec.Key = "" },
+			problem: "one of spec.key, spec.keyRef or spec.impersonateServiceAccount must be set",
+		},
+		{
+			name:    "invalid organization",
+			change:  func(c *gcpv1alpha1.GCPCredentials) { c.Spec.OrganizationId = "example.com" },
+			problem: `organization ID "example.com"`,
+		},
+		{
+			name: "impersonating with a key",
+			change: func(c *gcpv1alpha1.GCPCredentials) {
+				c.Spec.ImpersonateServiceAccount = "robot-account@demo-project.iam.gserviceaccount.com"
+			},
+		},
+		{
+			name: "impersonating an invalid email",
+			change: func(c *gcpv1alpha1.GCPCredentials) {
+				c.Spec.ImpersonateServiceAccount = "robot-account@example.com"
+			},
+			problem: `service account email "robot-account@example.com"`,
+		},
+		{
+			name: "impersonating another service account without a key",
+			change: func(c *gcpv1alpha1.GCPCredentials) {
+				c.Spec.Key = ""
+				c.Spec.ImpersonateServiceAccount = "robot-account@demo-project.iam.gserviceaccount.com"
+			},
+			problem: "credentials without a key can only impersonate",
+		},
+		{
+			name: "token secret without impersonation",
+			change: func(c *gcpv1alpha1.GCPCredentials) {
+				c.Spec.TokenSecretRef = &gcpv1alpha1.SecretKeyReference{Name: "admin-token"}
+			},
+			problem: "spec.tokenSecretRef can only be used with spec.impersonateServiceAccount",
+		},
+		{
+			name: "token written over the key",
+			change: func(c *gcpv1alpha1.GCPCredentials) {
+				c.Spec.Key, c.Spec.KeyRef = "", &gcpv1alpha1.SecretKeyReference{Name: "admin-key"}
+				c.Spec.ImpersonateServiceAccount = "robot-account@demo-project.iam.gserviceaccount.com"
+				c.Spec.TokenSecretRef = &gcpv1alpha1.SecretKeyReference{Name: "admin-key"}
+			},
+			problem: "spec.tokenSecretRef must refer to a different Secret than spec.keyRef",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			credentials := newCredentials()
+			test.change(credentials)
+
+			resp := validate(t, newRequest(t, admissionv1beta1.Create, "GCPCredentials", credentials, nil))
+
+			checkResponse(t, resp, test.problem)
+		})
+	}
+}
+
+func TestValidateIgnoresOtherRequests(t *testing.T) {
+	project := newProject()
+	project.Spec.ProjectId = "Demo"
+
+	tests := []struct {
+		name string
+		req  admission.Request
+	}{
+		{name: "delete", req: newRequest(t, admissionv1beta1.Delete, "GCPProject", project, nil)},
+		{name: "other kind", req: newRequest(t, admissionv1beta1.Create, "Namespace", project, nil)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkResponse(t, validate(t, test.req), "")
+		})
+	}
+}
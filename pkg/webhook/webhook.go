@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"github.com/appvia/gcp-operator/pkg/config"
+
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// ValidatePath is the path the validating webhook is served on
+	ValidatePath = "/validate-gcp-compute-hub-appvia-io-v1alpha1"
+	// DefaultPath is the path the defaulting webhook is served on
+	DefaultPath = "/mutate-gcp-compute-hub-appvia-io-v1alpha1"
+)
+
+// AddToManager registers the admission webhooks with the webhook server of the manager
+func AddToManager(m manager.Manager, c config.Config) error {
+	decoder, err := admission.NewDecoder(m.GetScheme())
+	if err != nil {
+		return err
+	}
+
+	server := m.GetWebhookServer()
+
+	server.Register(ValidatePath, &webhook.Admission{Handler: &validator{reader: m.GetAPIReader(), decoder: decoder}})
+	server.Register(DefaultPath, &webhook.Admission{Handler: &defaulter{
+		Defaulter: c.Defaulter(m.GetAPIReader()),
+		decoder:   decoder,
+	}})
+
+	return nil
+}
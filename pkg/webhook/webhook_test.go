@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+
+	gcpv1alpha1 "github.com/appvia/gcp-operator/pkg/apis/gcp/v1alpha1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const testNamespace = "team"
+
+func init() {
+	// the fake client decodes with the client-go scheme, so the types are registered there
+	if err := gcpv1alpha1.SchemeBuilder.AddToScheme(scheme.Scheme); err != nil {
+		panic(err)
+	}
+}
+
+// newDecoder returns a decoder for the GCP types
+func newDecoder(t *testing.T) *admission.Decoder {
+	t.Helper()
+
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	if err != nil {
+		t.Fatalf("failed to create the decoder: %v", err)
+	}
+
+	return decoder
+}
+
+// newRequest returns the admission request for the operation on the object, old is only sent on
+// updates
+func newRequest(t *testing.T, operation admissionv1beta1.Operation, kind string, obj, old runtime.Object) admission.Request {
+	t.Helper()
+
+	req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Operation: operation,
+		Kind:      metav1.GroupVersionKind{Group: gcpv1alpha1.SchemeGroupVersion.Group, Version: gcpv1alpha1.SchemeGroupVersion.Version, Kind: kind},
+		Namespace: testNamespace,
+		Object:    runtime.RawExtension{Raw: encode(t, kind, obj)},
+	}}
+
+	if old != nil {
+		req.OldObject = runtime.RawExtension{Raw: encode(t, kind, old)}
+	}
+
+	return req
+}
+
+// encode returns the JSON of the object with its kind set, as sent by the api server
+func encode(t *testing.T, kind string, obj runtime.Object) []byte {
+	t.Helper()
+
+	obj = obj.DeepCopyObject()
+	obj.GetObjectKind().SetGroupVersionKind(gcpv1alpha1.SchemeGroupVersion.WithKind(kind))
+
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("failed to encode the %s: %v", kind, err)
+	}
+
+	return raw
+}